@@ -3,6 +3,7 @@ package clients
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/cyberark/conjur-api-go/conjurapi"
 	"github.com/cyberark/conjur-api-go/conjurapi/authn"
@@ -46,6 +47,41 @@ func LoginWithPromptFallback(
 	return authenticatePair, nil
 }
 
+// HostLoginID returns the login of a host, prefixing its ID with host/ unless it already is
+func HostLoginID(id string) string {
+	if id == "" || strings.HasPrefix(id, "host/") {
+		return id
+	}
+	return "host/" + id
+}
+
+// LoginWithAPIKey logs in to Conjur with the API key of a host or user, rather than a password.
+// Conjur accepts an API key wherever it accepts a password, so the client exchanges it for the
+// API key to cache as it does for a password login. If either the login or API key is missing
+// then a prompt is presented to interactively request it from the user. With host, the login is
+// the ID of a host and is prefixed with host/.
+func LoginWithAPIKey(
+	client ConjurClient,
+	login string,
+	apiKey string,
+	host bool,
+) (*authn.LoginPair, error) {
+	login, apiKey, err := prompts.MaybeAskForAPIKeyCredentials(login, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	if host {
+		login = HostLoginID(login)
+	}
+
+	data, err := client.Login(login, apiKey)
+	if err != nil {
+		return nil, errors.New("Unable to authenticate with Conjur. Please check your credentials.")
+	}
+
+	return &authn.LoginPair{Login: login, APIKey: string(data)}, nil
+}
+
 func JWTAuthenticate(conjurClient ConjurClient) error {
 	_, err := conjurClient.GetAuthenticator().RefreshToken()
 	return err
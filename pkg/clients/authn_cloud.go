@@ -0,0 +1,288 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go-v2/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"gopkg.in/yaml.v3"
+)
+
+// MetadataURLEnvVar overrides the metadata endpoint of the cloud authenticators
+const MetadataURLEnvVar = "CONJUR_AUTHN_METADATA_URL"
+
+// CloudAuthnConfig holds the settings of the cloud authenticators (iam, azure and gcp) which the
+// Conjur API does not read. They are stored in the configuration file next to the Conjur
+// configuration.
+type CloudAuthnConfig struct {
+	// MetadataURL is the endpoint the identity of the workload is obtained from: the EC2 instance
+	// metadata service for iam, the Azure managed identity token endpoint for azure and the GCP
+	// metadata identity endpoint for gcp. The cloud's own endpoint is used when it is empty.
+	MetadataURL string `yaml:"authn_metadata_url,omitempty"`
+}
+
+// IsCloudAuthnType reports whether an authentication type obtains the identity of the workload
+// from the metadata service of a cloud
+func IsCloudAuthnType(authnType string) bool {
+	return authnType == "iam" || authnType == "azure" || authnType == "gcp"
+}
+
+// ConfigFilePath returns the configuration file in use: the file of the selected or active
+// profile, the file named by CONJURRC or ~/.conjurrc
+func ConfigFilePath(profile string) (string, error) {
+	name, err := ActiveProfile(profile)
+	if err != nil {
+		return "", err
+	}
+	if name != "" {
+		store, err := DefaultProfileStore()
+		if err != nil {
+			return "", err
+		}
+		return store.Path(name), nil
+	}
+
+	if conjurrc := os.Getenv("CONJURRC"); conjurrc != "" {
+		return conjurrc, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".conjurrc"), nil
+}
+
+// LoadCloudAuthnConfig loads the settings of the cloud authenticators from the configuration file
+// in use with a profile, selected as by LoadConfigForProfile. Environment variables override the
+// file.
+func LoadCloudAuthnConfig(profile string) (CloudAuthnConfig, error) {
+	cloudConfig := CloudAuthnConfig{}
+
+	path, err := ConfigFilePath(profile)
+	if err != nil {
+		return cloudConfig, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return cloudConfig, err
+	}
+	if err := yaml.Unmarshal(data, &cloudConfig); err != nil {
+		return cloudConfig, fmt.Errorf("Parsing error %s: %s", path, err)
+	}
+
+	if metadataURL := os.Getenv(MetadataURLEnvVar); metadataURL != "" {
+		cloudConfig.MetadataURL = metadataURL
+	}
+	return cloudConfig, nil
+}
+
+// ValidateMetadataURL checks that a metadata endpoint is an absolute HTTP URL
+func ValidateMetadataURL(metadataURL string) error {
+	u, err := url.Parse(metadataURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("Invalid metadata URL %q: must be an http or https URL", metadataURL)
+	}
+	return nil
+}
+
+// environmentAuthn reports whether the environment selects the authenticator, which
+// conjurapi.NewClientFromEnvironment then prefers to the authentication type of the configuration
+func environmentAuthn(config conjurapi.Config) bool {
+	for _, name := range []string{"CONJUR_AUTHN_TOKEN_FILE", "CONJUR_AUTHN_TOKEN", "CONJUR_AUTHN_JWT_SERVICE_ID"} {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return config.JWTFilePath != "" ||
+		(os.Getenv("CONJUR_AUTHN_LOGIN") != "" && os.Getenv("CONJUR_AUTHN_API_KEY") != "")
+}
+
+// newClientFromEnvironment creates a client like conjurapi.NewClientFromEnvironment, except that a
+// cloud authenticator with a configured metadata endpoint obtains the identity of the workload
+// from it. The identity is only requested when the client authenticates.
+func newClientFromEnvironment(config conjurapi.Config, profile string) (*conjurapi.Client, error) {
+	if !IsCloudAuthnType(config.AuthnType) || config.JWTContent != "" || environmentAuthn(config) {
+		return conjurapi.NewClientFromEnvironment(config)
+	}
+
+	cloudConfig, err := LoadCloudAuthnConfig(profile)
+	if err != nil {
+		return nil, err
+	}
+	if cloudConfig.MetadataURL == "" {
+		return conjurapi.NewClientFromEnvironment(config)
+	}
+
+	return newCloudClient(config, cloudConfig)
+}
+
+// newCloudClient creates a client authenticating with the identity of the workload on its cloud,
+// obtained from the configured metadata endpoint when there is one. The identity is requested
+// when the client authenticates, not here.
+func newCloudClient(config conjurapi.Config, cloudConfig CloudAuthnConfig) (*conjurapi.Client, error) {
+	metadataURL := cloudConfig.MetadataURL
+	if config.JWTContent != "" {
+		metadataURL = ""
+	}
+	if metadataURL != "" {
+		if err := ValidateMetadataURL(metadataURL); err != nil {
+			return nil, err
+		}
+	}
+
+	switch config.AuthnType {
+	case "iam":
+		client, err := conjurapi.NewClientFromAWSCredentials(config)
+		if err != nil || metadataURL == "" {
+			return client, err
+		}
+		client.SetAuthenticator(&authn.IAMAuthenticator{
+			Authenticate: func() ([]byte, error) {
+				creds, err := fetchIAMCredentials(metadataURL)
+				if err != nil {
+					return nil, fmt.Errorf("Unable to get the iam credentials from %s: %s", metadataURL, err)
+				}
+				return client.IAMAuthenticateWithCredentials(creds)
+			},
+		})
+		return client, nil
+	case "azure":
+		client, err := conjurapi.NewClientFromAzureCredentials(config)
+		if err != nil || metadataURL == "" {
+			return client, err
+		}
+		client.SetAuthenticator(&metadataAuthenticator{
+			fetchToken: func() (string, error) {
+				return fetchAzureToken(metadataURL, config.AzureClientID)
+			},
+			authnType:    config.AuthnType,
+			metadataURL:  metadataURL,
+			authenticate: client.AzureAuthenticate,
+		})
+		return client, nil
+	case "gcp":
+		// The Conjur API requests the identity token from the metadata endpoint itself, when
+		// authenticating
+		return conjurapi.NewClientFromGCPCredentials(config, metadataURL)
+	}
+	return nil, fmt.Errorf("unsupported authentication type: %s", config.AuthnType)
+}
+
+// metadataAuthenticator authenticates with Conjur using an identity token fetched from a
+// metadata endpoint every time the access token is refreshed
+type metadataAuthenticator struct {
+	fetchToken   func() (string, error)
+	authnType    string
+	metadataURL  string
+	authenticate func(token string) ([]byte, error)
+}
+
+func (a *metadataAuthenticator) RefreshToken() ([]byte, error) {
+	token, err := a.fetchToken()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to get the %s identity token from %s: %s", a.authnType, a.metadataURL, err)
+	}
+	return a.authenticate(token)
+}
+
+func (a *metadataAuthenticator) NeedsTokenRefresh() bool {
+	return false
+}
+
+// fetchIAMCredentials requests the credentials of the role of the instance from an EC2 instance
+// metadata service
+func fetchIAMCredentials(metadataURL string) (*authn.IAMCredentials, error) {
+	provider := ec2rolecreds.New(func(options *ec2rolecreds.Options) {
+		options.Client = imds.New(imds.Options{Endpoint: metadataURL})
+	})
+	creds, err := provider.Retrieve(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &authn.IAMCredentials{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+	}, nil
+}
+
+// fetchAzureToken requests a managed identity token from an Azure token endpoint, with the
+// parameters the Azure Instance Metadata Service expects
+func fetchAzureToken(metadataURL string, clientID string) (string, error) {
+	authenticator := authn.AzureAuthenticator{ClientID: clientID}
+	req, err := authenticator.AzureTokenRequest()
+	if err != nil {
+		return "", err
+	}
+
+	endpoint, err := url.Parse(metadataURL)
+	if err != nil {
+		return "", err
+	}
+	query := req.URL.Query()
+	for key, values := range endpoint.Query() {
+		query[key] = values
+	}
+	endpoint.RawQuery = query.Encode()
+	req.URL = endpoint
+	req.Host = endpoint.Host
+
+	body, err := doMetadataRequest(req)
+	if err != nil {
+		return "", err
+	}
+
+	response := authn.AzureResponse{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", err
+	}
+	if response.AccessToken == "" {
+		return "", fmt.Errorf("no access token in the response")
+	}
+	return response.AccessToken, nil
+}
+
+func doMetadataRequest(req *http.Request) ([]byte, error) {
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Non-OK HTTP status: %s", res.Status)
+	}
+	return io.ReadAll(res.Body)
+}
+
+// CloudLogin authenticates with Conjur using the identity of the workload on its cloud, obtained
+// from the metadata endpoint configured in a profile, and caches the Conjur access token
+func CloudLogin(config conjurapi.Config, profile string) (ConjurClient, error) {
+	if !IsCloudAuthnType(config.AuthnType) {
+		return nil, fmt.Errorf("unsupported authentication type: %s", config.AuthnType)
+	}
+
+	cloudConfig, err := LoadCloudAuthnConfig(profile)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newCloudClient(config, cloudConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refreshes the access token and caches it locally
+	if err := client.ForceRefreshToken(); err != nil {
+		return nil, fmt.Errorf("Unable to authenticate with Conjur using the %s identity: %s", config.AuthnType, err)
+	}
+	return client, nil
+}
@@ -0,0 +1,247 @@
+package clients
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/stretchr/testify/assert"
+)
+
+// startMetadataServer starts a stand-in for the metadata services of AWS, which hands out the
+// credentials of the "conjur-role" role, and of Azure and GCP, which hand out identity tokens. It
+// counts the requests it receives.
+func startMetadataServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	requests := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/latest/api/token":
+			fmt.Fprint(w, "imds-session-token")
+		case "/latest/meta-data/iam/security-credentials/":
+			fmt.Fprint(w, "conjur-role")
+		case "/latest/meta-data/iam/security-credentials/conjur-role":
+			fmt.Fprintf(w, `{"Code":"Success","AccessKeyId":"AKIDCONJUR","SecretAccessKey":"secret","Token":"session","Expiration":"%s"}`,
+				time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		case "/metadata/identity/oauth2/token":
+			if r.Header.Get("Metadata") != "true" || r.URL.Query().Get("resource") != "https://management.azure.com/" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintf(w, `{"access_token":"azure-identity-token-%s"}`, r.URL.Query().Get("client_id"))
+		case "/computeMetadata/v1/instance/service-accounts/default/identity":
+			if r.Header.Get("Metadata-Flavor") != "Google" || r.URL.Query().Get("audience") != "conjur/dev/host/myapp" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, "gcp-identity-token")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+// startConjurServer starts a stand-in for Conjur, which returns an access token when the identity
+// token it is given matches the one of the metadata server
+func startConjurServer(t *testing.T) *httptest.Server {
+	payload := fmt.Sprintf(`{"sub":"host/myapp","iat":%d}`, time.Now().Unix())
+	accessToken := fmt.Sprintf(`{"protected":"","payload":"%s","signature":""}`, base64.StdEncoding.EncodeToString([]byte(payload)))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/authn-iam/prod/dev/") && strings.Contains(string(body), "Credential=AKIDCONJUR/"),
+			strings.HasPrefix(r.URL.Path, "/authn-azure/prod/dev/") && string(body) == "jwt=azure-identity-token-client-id",
+			strings.HasPrefix(r.URL.Path, "/authn-gcp/dev/") && string(body) == "jwt=gcp-identity-token":
+			fmt.Fprint(w, accessToken)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLoadCloudAuthnConfig(t *testing.T) {
+	conjurrc := filepath.Join(t.TempDir(), ".conjurrc")
+	assert.NoError(t, os.WriteFile(conjurrc, []byte(`account: dev
+appliance_url: https://conjur
+authn_type: gcp
+authn_metadata_url: http://localhost:8080/computeMetadata/v1/instance/service-accounts/default/identity
+`), 0644))
+	t.Setenv("CONJURRC", conjurrc)
+	t.Setenv(ProfileEnvVar, "")
+	t.Setenv(MetadataURLEnvVar, "")
+
+	t.Run("Reads the metadata URL from the configuration file", func(t *testing.T) {
+		cloudConfig, err := LoadCloudAuthnConfig("")
+		assert.NoError(t, err)
+		assert.Equal(t, "http://localhost:8080/computeMetadata/v1/instance/service-accounts/default/identity", cloudConfig.MetadataURL)
+	})
+
+	t.Run("The environment overrides the configuration file", func(t *testing.T) {
+		t.Setenv(MetadataURLEnvVar, "http://localhost:9090/identity")
+
+		cloudConfig, err := LoadCloudAuthnConfig("")
+		assert.NoError(t, err)
+		assert.Equal(t, "http://localhost:9090/identity", cloudConfig.MetadataURL)
+	})
+
+	t.Run("Returns an empty configuration without a configuration file", func(t *testing.T) {
+		t.Setenv("CONJURRC", filepath.Join(t.TempDir(), "missing"))
+
+		cloudConfig, err := LoadCloudAuthnConfig("")
+		assert.NoError(t, err)
+		assert.Equal(t, CloudAuthnConfig{}, cloudConfig)
+	})
+}
+
+func TestNewClientFromEnvironment(t *testing.T) {
+	metadata, metadataRequests := startMetadataServer(t)
+	conjur := startConjurServer(t)
+	t.Setenv("CONJURRC", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv(ProfileEnvVar, "")
+	t.Setenv(MetadataURLEnvVar, metadata.URL+"/metadata/identity/oauth2/token")
+	t.Setenv("CONJUR_AUTHN_TOKEN", "")
+	t.Setenv("CONJUR_AUTHN_TOKEN_FILE", "")
+
+	newConfig := func(t *testing.T) conjurapi.Config {
+		return conjurapi.Config{
+			Account:           "dev",
+			ApplianceURL:      conjur.URL,
+			AuthnType:         "azure",
+			ServiceID:         "prod",
+			JWTHostID:         "myapp",
+			AzureClientID:     "client-id",
+			CredentialStorage: conjurapi.CredentialStorageFile,
+			NetRCPath:         filepath.Join(t.TempDir(), ".netrc"),
+		}
+	}
+
+	t.Run("Only fetches the identity token when the client authenticates", func(t *testing.T) {
+		metadataRequests.Store(0)
+		client, err := newClientFromEnvironment(newConfig(t), "")
+		assert.NoError(t, err)
+		assert.Zero(t, metadataRequests.Load())
+
+		assert.NoError(t, client.RefreshToken())
+		assert.Equal(t, int32(1), metadataRequests.Load())
+	})
+
+	t.Run("Returns an error when the metadata endpoint fails", func(t *testing.T) {
+		t.Setenv(MetadataURLEnvVar, metadata.URL+"/missing")
+
+		client, err := newClientFromEnvironment(newConfig(t), "")
+		assert.NoError(t, err)
+		err = client.RefreshToken()
+		assert.ErrorContains(t, err, "Unable to get the azure identity token from "+metadata.URL+"/missing: Non-OK HTTP status: 404")
+	})
+
+	t.Run("Returns an error for an invalid metadata URL", func(t *testing.T) {
+		t.Setenv(MetadataURLEnvVar, "metadata.internal")
+
+		_, err := newClientFromEnvironment(newConfig(t), "")
+		assert.EqualError(t, err, `Invalid metadata URL "metadata.internal": must be an http or https URL`)
+	})
+}
+
+func TestCloudLogin(t *testing.T) {
+	metadata, _ := startMetadataServer(t)
+	conjur := startConjurServer(t)
+	t.Setenv("CONJURRC", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv(ProfileEnvVar, "")
+	// Only the stand-in metadata service provides AWS credentials
+	for _, name := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE", "AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_CONTAINER_CREDENTIALS_FULL_URI", "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_EC2_METADATA_SERVICE_ENDPOINT", "")
+
+	testCases := []struct {
+		name        string
+		config      conjurapi.Config
+		metadataURL string
+		assert      func(t *testing.T, client ConjurClient, err error)
+	}{
+		{
+			name: "Authenticates with the azure identity",
+			config: conjurapi.Config{
+				AuthnType:     "azure",
+				ServiceID:     "prod",
+				JWTHostID:     "myapp",
+				AzureClientID: "client-id",
+			},
+			metadataURL: metadata.URL + "/metadata/identity/oauth2/token",
+			assert: func(t *testing.T, client ConjurClient, err error) {
+				assert.NoError(t, err)
+				assert.NotNil(t, client)
+			},
+		},
+		{
+			name: "Authenticates with the iam identity",
+			config: conjurapi.Config{
+				AuthnType: "iam",
+				ServiceID: "prod",
+				JWTHostID: "myapp",
+			},
+			metadataURL: metadata.URL,
+			assert: func(t *testing.T, client ConjurClient, err error) {
+				assert.NoError(t, err)
+				assert.NotNil(t, client)
+				// Processes started by the CLI must not inherit the metadata endpoint
+				assert.Empty(t, os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT"))
+			},
+		},
+		{
+			name: "Authenticates with the gcp identity",
+			config: conjurapi.Config{
+				AuthnType: "gcp",
+				JWTHostID: "myapp",
+			},
+			metadataURL: metadata.URL + "/computeMetadata/v1/instance/service-accounts/default/identity",
+			assert: func(t *testing.T, client ConjurClient, err error) {
+				assert.NoError(t, err)
+				assert.NotNil(t, client)
+			},
+		},
+		{
+			name: "Returns an error when Conjur rejects the identity",
+			config: conjurapi.Config{
+				AuthnType: "azure",
+				ServiceID: "prod",
+				JWTHostID: "myapp",
+			},
+			metadataURL: metadata.URL + "/metadata/identity/oauth2/token",
+			assert: func(t *testing.T, client ConjurClient, err error) {
+				assert.Nil(t, client)
+				assert.ErrorContains(t, err, "Unable to authenticate with Conjur using the azure identity")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(MetadataURLEnvVar, tc.metadataURL)
+			config := tc.config
+			config.Account = "dev"
+			config.ApplianceURL = conjur.URL
+			config.CredentialStorage = conjurapi.CredentialStorageNone
+
+			client, err := CloudLogin(config, "")
+			tc.assert(t, client, err)
+		})
+	}
+}
@@ -2,6 +2,10 @@ package clients
 
 import (
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/cyberark/conjur-api-go/conjurapi"
@@ -72,3 +76,48 @@ func TestGetOidcProviderInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestLoginWithAPIKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		login, apiKey, ok := r.BasicAuth()
+		if r.URL.Path != "/authn/dev/login" || !ok || login != "host/myapp" || apiKey != "api-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("api-key"))
+	}))
+	defer server.Close()
+
+	netrcPath := filepath.Join(t.TempDir(), ".netrc")
+	client, err := conjurapi.NewClient(conjurapi.Config{
+		Account:           "dev",
+		ApplianceURL:      server.URL,
+		CredentialStorage: conjurapi.CredentialStorageFile,
+		NetRCPath:         netrcPath,
+	})
+	assert.NoError(t, err)
+
+	t.Run("returns the login pair and stores the api key when it is accepted", func(t *testing.T) {
+		pair, err := LoginWithAPIKey(client, "host/myapp", "api-key", false)
+		assert.NoError(t, err)
+		assert.Equal(t, "host/myapp", pair.Login)
+		assert.Equal(t, "api-key", pair.APIKey)
+
+		netrc, err := os.ReadFile(netrcPath)
+		assert.NoError(t, err)
+		assert.Contains(t, string(netrc), "login host/myapp")
+		assert.Contains(t, string(netrc), "password api-key")
+	})
+
+	t.Run("prefixes the login of a host", func(t *testing.T) {
+		pair, err := LoginWithAPIKey(client, "myapp", "api-key", true)
+		assert.NoError(t, err)
+		assert.Equal(t, "host/myapp", pair.Login)
+	})
+
+	t.Run("returns error when the api key is rejected", func(t *testing.T) {
+		pair, err := LoginWithAPIKey(client, "host/myapp", "wrong", false)
+		assert.Nil(t, pair)
+		assert.EqualError(t, err, "Unable to authenticate with Conjur. Please check your credentials.")
+	})
+}
@@ -0,0 +1,57 @@
+package clients
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+)
+
+// MaybeClientCertForClient configures the HTTP client of a Conjur client to present the client
+// certificate of the configuration when the server asks for one, such as when Conjur sits behind
+// a proxy requiring mutual TLS. The certificate is read on every handshake so that it can be
+// renewed in place.
+func MaybeClientCertForClient(client ConjurClient) {
+	if client == nil {
+		return
+	}
+
+	config := client.GetConfig()
+	// The Conjur API already configures mutual TLS for the cert authenticator
+	if config.AuthnType == "cert" || config.ClientCertFile == "" || config.ClientCertKeyFile == "" {
+		return
+	}
+
+	httpClient := client.GetHttpClient()
+	if httpClient == nil {
+		return
+	}
+	transport, ok := httpClient.Transport.(*http.Transport)
+	if !ok {
+		return
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if transport.TLSClientConfig != nil {
+		tlsConfig = transport.TLSClientConfig.Clone()
+	}
+	tlsConfig.GetClientCertificate = clientCertificateFunc(config)
+	transport.TLSClientConfig = tlsConfig
+}
+
+func clientCertificateFunc(config conjurapi.Config) func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		cert, err := config.ReadClientCert()
+		if err != nil {
+			return nil, err
+		}
+		return &cert, nil
+	}
+}
+
+// CertAuthenticate checks that the client certificate of a Conjur client configured for the cert
+// authenticator is accepted by Conjur
+func CertAuthenticate(conjurClient ConjurClient) error {
+	_, err := conjurClient.GetAuthenticator().RefreshToken()
+	return err
+}
@@ -1,6 +1,7 @@
 package clients
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -51,10 +52,16 @@ type ConjurClient interface {
 
 // LoadAndValidateConjurConfig loads and validate Conjur configuration
 func LoadAndValidateConjurConfig(timeout time.Duration) (conjurapi.Config, error) {
+	return LoadAndValidateConjurConfigForProfile("", timeout)
+}
+
+// LoadAndValidateConjurConfigForProfile loads and validate the Conjur configuration of the selected
+// profile, or of the active one when it is empty
+func LoadAndValidateConjurConfigForProfile(profile string, timeout time.Duration) (conjurapi.Config, error) {
 	// TODO: extract this common code for gathering configuring into a seperate package
 	// Some of the code is in conjur-api-go and needs to be made configurable so that you can pass a custom path to .conjurrc
 
-	config, err := conjurapi.LoadConfig()
+	config, err := LoadConfigForProfile(profile)
 	if err != nil {
 		return config, err
 	}
@@ -71,6 +78,16 @@ func LoadAndValidateConjurConfig(timeout time.Duration) (conjurapi.Config, error
 // AuthenticatedConjurClientForCommand attempts to get an authenticated Conjur client by iterating through
 // configuration, environment variables and then ultimately falling back on prompting the user for credentials.
 func AuthenticatedConjurClientForCommand(cmd *cobra.Command) (ConjurClient, error) {
+	return authenticatedConjurClient(cmd, SelectedProfile(cmd))
+}
+
+// AuthenticatedConjurClientForProfile returns an authenticated Conjur client for a named profile,
+// regardless of the active profile, so that a command can talk to several Conjur servers
+func AuthenticatedConjurClientForProfile(cmd *cobra.Command, profile string) (ConjurClient, error) {
+	return authenticatedConjurClient(cmd, profile)
+}
+
+func authenticatedConjurClient(cmd *cobra.Command, profile string) (ConjurClient, error) {
 	var err error
 
 	debug, err := cmd.Flags().GetBool("debug")
@@ -82,6 +99,7 @@ func AuthenticatedConjurClientForCommand(cmd *cobra.Command) (ConjurClient, erro
 	// temporary Conjur client being created at that point in time. We should really not be creating so many Conjur clients
 	// we should just have one then the rest is an attempt to get an authenticator
 	decorateConjurClient := func(client ConjurClient) {
+		MaybeClientCertForClient(client)
 		MaybeDebugLoggingForClient(debug, cmd, client)
 	}
 
@@ -90,13 +108,12 @@ func AuthenticatedConjurClientForCommand(cmd *cobra.Command) (ConjurClient, erro
 		return nil, err
 	}
 
-	config, err := LoadAndValidateConjurConfig(timeout)
+	config, err := LoadAndValidateConjurConfigForProfile(profile, timeout)
 	if err != nil {
 		return nil, err
 	}
-
 	var client ConjurClient
-	client, err = conjurapi.NewClientFromEnvironment(config)
+	client, err = newClientFromEnvironment(config, profile)
 	if err != nil {
 		return nil, err
 	}
@@ -115,6 +132,10 @@ func AuthenticatedConjurClientForCommand(cmd *cobra.Command) (ConjurClient, erro
 			client, err = OidcLogin(client, "", "")
 		} else if config.AuthnType == "jwt" {
 			// Will use the token in the config
+		} else if config.AuthnType == "cert" {
+			client, err = conjurapi.NewClientFromCertificate(config)
+		} else if IsCloudAuthnType(config.AuthnType) {
+			client, err = CloudLogin(config, profile)
 		} else {
 			return nil, fmt.Errorf("unsupported authentication type: %s", config.AuthnType)
 		}
@@ -128,6 +149,31 @@ func AuthenticatedConjurClientForCommand(cmd *cobra.Command) (ConjurClient, erro
 	return client, nil
 }
 
+// CachedConjurClientForCommand returns a Conjur client authenticated from the environment or from
+// the credentials stored by 'conjur login'. Unlike AuthenticatedConjurClientForCommand it never
+// prompts, so that it can be used where nobody can answer, such as in shell completion.
+func CachedConjurClientForCommand(cmd *cobra.Command) (ConjurClient, error) {
+	timeout, err := GetTimeout(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := SelectedProfile(cmd)
+	config, err := LoadAndValidateConjurConfigForProfile(profile, timeout)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newClientFromEnvironment(config, profile)
+	if err != nil {
+		return nil, err
+	}
+	if client.GetAuthenticator() == nil {
+		return nil, errors.New("Not logged in, run 'conjur login' first")
+	}
+	MaybeClientCertForClient(client)
+	return client, nil
+}
+
 // GetTimeout extracts the timeout from the command flags only if explicitly set
 func GetTimeout(cmd *cobra.Command) (timeout time.Duration, err error) {
 	if cmd.Flags().Changed("timeout") {
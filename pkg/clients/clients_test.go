@@ -59,6 +59,22 @@ func TestAuthenticatedConjurClientForCommand(t *testing.T) {
 	})
 }
 
+func TestAuthenticatedConjurClientForProfile(t *testing.T) {
+	t.Run("Uses the named profile without changing the environment", func(t *testing.T) {
+		t.Setenv(ProfilesDirEnvVar, t.TempDir())
+		t.Setenv(ProfileEnvVar, "staging")
+
+		var cmd = &cobra.Command{}
+		cmd.Flags().Bool("debug", false, "Debug logging enabled")
+
+		client, err := AuthenticatedConjurClientForProfile(cmd, "prod")
+
+		assert.Nil(t, client)
+		assert.EqualError(t, err, "Profile \"prod\" does not exist")
+		assert.Equal(t, "staging", os.Getenv(ProfileEnvVar))
+	})
+}
+
 func TestGetTimeout(t *testing.T) {
 	five := 5 * time.Second
 	tests := []struct {
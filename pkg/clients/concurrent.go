@@ -0,0 +1,66 @@
+package clients
+
+import (
+	"sync"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+)
+
+// ConcurrentClients returns n Conjur clients to call Conjur from as many goroutines, one client
+// each. A Conjur client refreshes its access token without locking, so it cannot be shared between
+// goroutines. Instead, the clients share the access token of the given authenticated client, so
+// that Conjur is only authenticated with once between them, and never prompted for again.
+func ConcurrentClients(client ConjurClient, n int) ([]ConjurClient, error) {
+	shared := &sharedTokenAuthenticator{client: client}
+
+	workers := make([]ConjurClient, 0, n)
+	for i := 0; i < n; i++ {
+		worker, err := conjurapi.NewClient(client.GetConfig())
+		if err != nil {
+			return nil, err
+		}
+		// Reuse the HTTP client of the given client, which presents the client certificate and
+		// logs requests in debug mode
+		if httpClient := client.GetHttpClient(); httpClient != nil {
+			worker.SetHttpClient(httpClient)
+		}
+		worker.SetAuthenticator(shared)
+		workers = append(workers, worker)
+	}
+	return workers, nil
+}
+
+// sharedTokenAuthenticator hands out the access token of a client, authenticating again when it is
+// about to expire. Calls to the client are serialized, the client not being safe for concurrent
+// use.
+type sharedTokenAuthenticator struct {
+	mutex  sync.Mutex
+	client ConjurClient
+	token  *authn.AuthnToken
+}
+
+func (a *sharedTokenAuthenticator) RefreshToken() ([]byte, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.token != nil && !a.token.ShouldRefresh() {
+		return a.token.Raw(), nil
+	}
+
+	data, err := a.client.InternalAuthenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := authn.NewToken(data)
+	if err != nil {
+		return nil, err
+	}
+	a.token = token
+	return token.Raw(), nil
+}
+
+func (a *sharedTokenAuthenticator) NeedsTokenRefresh() bool {
+	return false
+}
@@ -0,0 +1,62 @@
+package clients
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentClients(t *testing.T) {
+	var authentications atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/authn/dev/alice/authenticate":
+			authentications.Add(1)
+			payload := fmt.Sprintf(`{"sub":"alice","iat":%d}`, time.Now().Unix())
+			w.Write([]byte(`{"protected":"e30=","payload":"` + base64.StdEncoding.EncodeToString([]byte(payload)) + `","signature":"c2ln"}`))
+		case "/secrets/dev/variable/db%2Fpassword":
+			if r.Header.Get("Authorization") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte("secret"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := conjurapi.NewClientFromKey(conjurapi.Config{
+		Account:           "dev",
+		ApplianceURL:      server.URL,
+		CredentialStorage: conjurapi.CredentialStorageNone,
+	}, authn.LoginPair{Login: "alice", APIKey: "api-key"})
+	assert.NoError(t, err)
+
+	workers, err := ConcurrentClients(client, 3)
+	assert.NoError(t, err)
+	assert.Len(t, workers, 3)
+
+	var wg sync.WaitGroup
+	for _, worker := range workers {
+		wg.Add(1)
+		go func(worker ConjurClient) {
+			defer wg.Done()
+			value, err := worker.RetrieveSecret("db/password")
+			assert.NoError(t, err)
+			assert.Equal(t, "secret", string(value))
+		}(worker)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), authentications.Load())
+}
@@ -0,0 +1,288 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ProfileEnvVar selects the connection profile to use, unless the global --profile flag does
+	ProfileEnvVar = "CONJUR_PROFILE"
+	// ProfilesDirEnvVar overrides the directory where connection profiles are stored
+	ProfilesDirEnvVar = "CONJUR_PROFILES_DIR"
+
+	profileFileExtension = ".conjurrc"
+	currentProfileFile   = "current"
+)
+
+var profileNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ProfileStore manages named connection profiles. Each profile is stored as a .conjurrc-formatted
+// file in Dir, and the name of the profile in use is stored in the "current" file of Dir.
+type ProfileStore struct {
+	Dir string
+}
+
+// DefaultProfileStore returns the profile store located in CONJUR_PROFILES_DIR, or in
+// ~/.conjur/profiles when the variable is not set
+func DefaultProfileStore() (ProfileStore, error) {
+	if dir := os.Getenv(ProfilesDirEnvVar); dir != "" {
+		return ProfileStore{Dir: dir}, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ProfileStore{}, err
+	}
+	return ProfileStore{Dir: filepath.Join(home, ".conjur", "profiles")}, nil
+}
+
+// ValidateProfileName checks that a profile name can be safely used as a file name
+func ValidateProfileName(name string) error {
+	if !profileNameRegexp.MatchString(name) {
+		return fmt.Errorf("Invalid profile name %q: only letters, digits, '.', '_' and '-' are allowed", name)
+	}
+	return nil
+}
+
+// Path returns the path of the configuration file of a profile
+func (s ProfileStore) Path(name string) string {
+	return filepath.Join(s.Dir, name+profileFileExtension)
+}
+
+// NetRCPath returns the path of the .netrc file caching the credentials of a profile when they are
+// not stored in the keyring, so that profiles of the same Conjur server do not overwrite each other
+func (s ProfileStore) NetRCPath(name string) string {
+	return filepath.Join(s.Dir, name+".netrc")
+}
+
+// Exists reports whether a profile exists
+func (s ProfileStore) Exists(name string) bool {
+	_, err := os.Stat(s.Path(name))
+	return err == nil
+}
+
+// Names returns the sorted names of all profiles
+func (s ProfileStore) Names() ([]string, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), profileFileExtension) {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(entry.Name(), profileFileExtension))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Load reads the configuration stored in a profile, without applying environment overrides
+func (s ProfileStore) Load(name string) (conjurapi.Config, error) {
+	config := conjurapi.Config{}
+
+	data, err := os.ReadFile(s.Path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return config, fmt.Errorf("Profile %q does not exist", name)
+	}
+	if err != nil {
+		return config, err
+	}
+
+	err = yaml.Unmarshal(data, &config)
+	return config, err
+}
+
+// Save writes the configuration of a profile, creating the store directory if needed
+func (s ProfileStore) Save(name string, config conjurapi.Config) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.Path(name), config.Conjurrc(), 0600)
+}
+
+// Remove deletes a profile and the credentials cached in its .netrc file. If it is the current
+// profile, no profile is in use afterwards.
+func (s ProfileStore) Remove(name string) error {
+	if !s.Exists(name) {
+		return fmt.Errorf("Profile %q does not exist", name)
+	}
+	if err := os.Remove(s.Path(name)); err != nil {
+		return err
+	}
+	if err := os.Remove(s.NetRCPath(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	current, err := s.Current()
+	if err != nil {
+		return err
+	}
+	if current == name {
+		return s.SetCurrent("")
+	}
+	return nil
+}
+
+// Current returns the name of the profile in use, or an empty string when none is
+func (s ProfileStore) Current() (string, error) {
+	data, err := os.ReadFile(filepath.Join(s.Dir, currentProfileFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// SetCurrent selects the profile in use. An empty name clears the selection.
+func (s ProfileStore) SetCurrent(name string) error {
+	currentPath := filepath.Join(s.Dir, currentProfileFile)
+	if name == "" {
+		err := os.Remove(currentPath)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if !s.Exists(name) {
+		return fmt.Errorf("Profile %q does not exist", name)
+	}
+	return os.WriteFile(currentPath, []byte(name+"\n"), 0600)
+}
+
+// SelectedProfile returns the profile selected with the global --profile flag of a command, or an
+// empty string when none is
+func SelectedProfile(cmd *cobra.Command) string {
+	flag := cmd.Flags().Lookup("profile")
+	if flag == nil {
+		return ""
+	}
+	return flag.Value.String()
+}
+
+// ActiveProfile returns the name of the profile to use. The selected profile (from the --profile
+// flag) takes precedence, then the CONJUR_PROFILE environment variable, then an explicit CONJURRC
+// environment variable disables profiles, and finally the profile selected with
+// 'conjur profile use' is returned.
+func ActiveProfile(selected string) (string, error) {
+	if selected != "" {
+		return selected, nil
+	}
+	if name := os.Getenv(ProfileEnvVar); name != "" {
+		return name, nil
+	}
+	if os.Getenv("CONJURRC") != "" {
+		return "", nil
+	}
+
+	store, err := DefaultProfileStore()
+	if err != nil {
+		return "", err
+	}
+	return store.Current()
+}
+
+// LoadConfig loads the Conjur configuration from the active profile, falling back to the
+// .conjurrc file when no profile is in use. Environment variables override both.
+func LoadConfig() (conjurapi.Config, error) {
+	return LoadConfigForProfile("")
+}
+
+// LoadConfigForProfile loads the Conjur configuration like LoadConfig, from the selected profile
+// when it is not empty
+func LoadConfigForProfile(selected string) (conjurapi.Config, error) {
+	name, err := ActiveProfile(selected)
+	if err != nil {
+		return conjurapi.Config{}, err
+	}
+	if name == "" {
+		return conjurapi.LoadConfig()
+	}
+
+	store, err := DefaultProfileStore()
+	if err != nil {
+		return conjurapi.Config{}, err
+	}
+	config, err := store.Load(name)
+	if err != nil {
+		return config, err
+	}
+
+	// Profiles created before they had their own .netrc file share the default one
+	if config.NetRCPath == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			config.NetRCPath = filepath.Join(home, ".netrc")
+		}
+	}
+	mergeEnvConfig(&config)
+	return config, nil
+}
+
+// mergeEnvConfig overrides a configuration with the environment variables the Conjur API reads
+// on top of the .conjurrc file. The credential storage mode and keychain namespace are resolved
+// from the environment when the configuration is validated.
+func mergeEnvConfig(config *conjurapi.Config) {
+	for name, value := range map[string]*string{
+		"CONJUR_APPLIANCE_URL":         &config.ApplianceURL,
+		"CONJUR_SSL_CERTIFICATE":       &config.SSLCert,
+		"CONJUR_CERT_FILE":             &config.SSLCertPath,
+		"CONJUR_ACCOUNT":               &config.Account,
+		"CONJUR_NETRC_PATH":            &config.NetRCPath,
+		"CONJUR_CREDENTIAL_STORAGE":    &config.CredentialStorage,
+		"CONJUR_AUTHN_TYPE":            &config.AuthnType,
+		"CONJUR_SERVICE_ID":            &config.ServiceID,
+		"CONJUR_AUTHN_JWT_TOKEN":       &config.JWTContent,
+		"JWT_TOKEN_PATH":               &config.JWTFilePath,
+		"CONJUR_AUTHN_JWT_HOST_ID":     &config.JWTHostID,
+		"CONJUR_AUTHN_AZURE_CLIENT_ID": &config.AzureClientID,
+		"CONJUR_AUTHN_CERT_FILE":       &config.ClientCertFile,
+		"CONJUR_AUTHN_CERT_KEY_FILE":   &config.ClientCertKeyFile,
+		"CONJUR_AUTHN_CERT_HOST_ID":    &config.CertHostID,
+	} {
+		if env := os.Getenv(name); env != "" {
+			*value = env
+		}
+	}
+
+	if env := os.Getenv("CONJUR_ENVIRONMENT"); env != "" {
+		config.Environment = conjurapi.EnvironmentType(env)
+	}
+	if timeout, err := strconv.Atoi(os.Getenv("CONJUR_HTTP_TIMEOUT")); err == nil {
+		config.HTTPTimeout = timeout
+	}
+	if disable, err := strconv.ParseBool(os.Getenv("CONJUR_DISABLE_KEEP_ALIVES")); err == nil {
+		config.DisableKeepAlives = disable
+	}
+
+	// A service ID of the jwt or cert authenticator selects it, and overrides CONJUR_SERVICE_ID
+	if serviceID := os.Getenv("CONJUR_AUTHN_JWT_SERVICE_ID"); serviceID != "" {
+		config.AuthnType = "jwt"
+		config.ServiceID = serviceID
+	}
+	if serviceID := os.Getenv("CONJUR_AUTHN_CERT_SERVICE_ID"); serviceID != "" {
+		config.AuthnType = "cert"
+		config.ServiceID = serviceID
+	}
+}
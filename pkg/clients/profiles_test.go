@@ -0,0 +1,156 @@
+package clients
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileStore(t *testing.T) {
+	store := ProfileStore{Dir: filepath.Join(t.TempDir(), "profiles")}
+
+	t.Run("empty store", func(t *testing.T) {
+		names, err := store.Names()
+		assert.NoError(t, err)
+		assert.Empty(t, names)
+
+		current, err := store.Current()
+		assert.NoError(t, err)
+		assert.Empty(t, current)
+	})
+
+	t.Run("save and load profiles", func(t *testing.T) {
+		err := store.Save("prod", conjurapi.Config{Account: "prod", ApplianceURL: "https://conjur-prod"})
+		assert.NoError(t, err)
+		err = store.Save("dev", conjurapi.Config{Account: "dev", ApplianceURL: "https://conjur-dev", KeychainNamespace: "dev"})
+		assert.NoError(t, err)
+
+		names, err := store.Names()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"dev", "prod"}, names)
+
+		config, err := store.Load("dev")
+		assert.NoError(t, err)
+		assert.Equal(t, "dev", config.Account)
+		assert.Equal(t, "https://conjur-dev", config.ApplianceURL)
+		assert.Equal(t, "dev", config.KeychainNamespace)
+
+		info, err := os.Stat(store.Path("dev"))
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("rejects invalid names", func(t *testing.T) {
+		err := store.Save("../escape", conjurapi.Config{})
+		assert.EqualError(t, err, "Invalid profile name \"../escape\": only letters, digits, '.', '_' and '-' are allowed")
+	})
+
+	t.Run("set current profile", func(t *testing.T) {
+		assert.NoError(t, store.SetCurrent("dev"))
+
+		current, err := store.Current()
+		assert.NoError(t, err)
+		assert.Equal(t, "dev", current)
+
+		assert.EqualError(t, store.SetCurrent("missing"), "Profile \"missing\" does not exist")
+	})
+
+	t.Run("removing current profile clears selection", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(store.NetRCPath("dev"), []byte("machine https://conjur/authn\n"), 0600))
+		assert.NoError(t, store.Remove("dev"))
+		assert.NoFileExists(t, store.NetRCPath("dev"))
+
+		current, err := store.Current()
+		assert.NoError(t, err)
+		assert.Empty(t, current)
+
+		_, err = store.Load("dev")
+		assert.EqualError(t, err, "Profile \"dev\" does not exist")
+		assert.EqualError(t, store.Remove("dev"), "Profile \"dev\" does not exist")
+	})
+}
+
+func TestSelectedProfile(t *testing.T) {
+	cmd := &cobra.Command{}
+	assert.Empty(t, SelectedProfile(cmd))
+
+	cmd.Flags().String("profile", "", "")
+	assert.NoError(t, cmd.Flags().Set("profile", "prod"))
+	assert.Equal(t, "prod", SelectedProfile(cmd))
+}
+
+func TestLoadConfigWithProfile(t *testing.T) {
+	store := ProfileStore{Dir: t.TempDir()}
+	assert.NoError(t, store.Save("staging", conjurapi.Config{Account: "staging", ApplianceURL: "https://conjur-staging"}))
+	assert.NoError(t, store.Save("prod", conjurapi.Config{Account: "prod", ApplianceURL: "https://conjur-prod"}))
+	assert.NoError(t, store.SetCurrent("staging"))
+
+	t.Setenv(ProfilesDirEnvVar, store.Dir)
+	t.Setenv("CONJUR_ACCOUNT", "")
+	t.Setenv("CONJUR_APPLIANCE_URL", "")
+	t.Setenv("CONJURRC", "")
+
+	t.Run("uses current profile", func(t *testing.T) {
+		t.Setenv(ProfileEnvVar, "")
+
+		config, err := LoadConfig()
+		assert.NoError(t, err)
+		assert.Equal(t, "staging", config.Account)
+		assert.Equal(t, "https://conjur-staging", config.ApplianceURL)
+	})
+
+	t.Run("CONJUR_PROFILE overrides current profile", func(t *testing.T) {
+		t.Setenv(ProfileEnvVar, "prod")
+
+		config, err := LoadConfig()
+		assert.NoError(t, err)
+		assert.Equal(t, "prod", config.Account)
+
+		// The profile must not leak into the configuration of later commands
+		assert.Empty(t, os.Getenv("CONJURRC"))
+	})
+
+	t.Run("selected profile overrides CONJUR_PROFILE", func(t *testing.T) {
+		t.Setenv(ProfileEnvVar, "staging")
+
+		config, err := LoadConfigForProfile("prod")
+		assert.NoError(t, err)
+		assert.Equal(t, "prod", config.Account)
+		assert.Equal(t, "staging", os.Getenv(ProfileEnvVar))
+	})
+
+	t.Run("environment overrides the profile", func(t *testing.T) {
+		t.Setenv(ProfileEnvVar, "")
+		t.Setenv("CONJUR_ACCOUNT", "from-env")
+		t.Setenv("CONJUR_AUTHN_JWT_SERVICE_ID", "github")
+
+		config, err := LoadConfig()
+		assert.NoError(t, err)
+		assert.Equal(t, "from-env", config.Account)
+		assert.Equal(t, "https://conjur-staging", config.ApplianceURL)
+		assert.Equal(t, "jwt", config.AuthnType)
+		assert.Equal(t, "github", config.ServiceID)
+	})
+
+	t.Run("CONJURRC disables current profile", func(t *testing.T) {
+		t.Setenv(ProfileEnvVar, "")
+		conjurrc := filepath.Join(t.TempDir(), ".conjurrc")
+		assert.NoError(t, os.WriteFile(conjurrc, []byte("account: legacy\nappliance_url: https://conjur\n"), 0600))
+		t.Setenv("CONJURRC", conjurrc)
+
+		config, err := LoadConfig()
+		assert.NoError(t, err)
+		assert.Equal(t, "legacy", config.Account)
+	})
+
+	t.Run("missing profile", func(t *testing.T) {
+		t.Setenv(ProfileEnvVar, "missing")
+
+		_, err := LoadConfig()
+		assert.EqualError(t, err, "Profile \"missing\" does not exist")
+	})
+}
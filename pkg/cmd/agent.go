@@ -0,0 +1,356 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/cyberark/conjur-cli-go/pkg/clients"
+	"github.com/spf13/cobra"
+)
+
+type agentClient interface {
+	InternalAuthenticate() ([]byte, error)
+	RetrieveSecret(variableID string) ([]byte, error)
+	RetrieveSecretWithVersion(variableID string, version int) ([]byte, error)
+}
+
+type agentClientFactoryFunc func(*cobra.Command) (agentClient, error)
+
+func agentClientFactory(cmd *cobra.Command) (agentClient, error) {
+	return clients.AuthenticatedConjurClientForCommand(cmd)
+}
+
+// agentRefreshInterval is how often the agent checks whether its access token should be refreshed
+const agentRefreshInterval = 30 * time.Second
+
+// conjurAgent serves a cached access token and variable values to local processes. Calls to the
+// Conjur client are serialized, the client not being safe for concurrent use.
+type conjurAgent struct {
+	mutex  sync.Mutex
+	client agentClient
+	token  *authn.AuthnToken
+}
+
+// accessToken returns the cached access token, authenticating again when it is about to expire
+func (a *conjurAgent) accessToken() ([]byte, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.token != nil && !a.token.ShouldRefresh() {
+		return a.token.Raw(), nil
+	}
+
+	data, err := a.client.InternalAuthenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := authn.NewToken(data)
+	if err != nil {
+		return nil, err
+	}
+	a.token = token
+	return token.Raw(), nil
+}
+
+func (a *conjurAgent) secret(variableID string, version int) ([]byte, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if version > 0 {
+		return a.client.RetrieveSecretWithVersion(variableID, version)
+	}
+	return a.client.RetrieveSecret(variableID)
+}
+
+// writeAgentError responds with the status code of Conjur errors, so that clients can tell a
+// missing variable from a denied access
+func writeAgentError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	var conjurErr *response.ConjurError
+	if errors.As(err, &conjurErr) && conjurErr.Code >= 400 {
+		status = conjurErr.Code
+	}
+	http.Error(w, err.Error(), status)
+}
+
+func (a *conjurAgent) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		token, err := a.accessToken()
+		if err != nil {
+			writeAgentError(w, err)
+			return
+		}
+
+		if r.URL.Query().Get("encoding") == "base64" {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(base64.StdEncoding.EncodeToString(token)))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(token)
+	})
+
+	mux.HandleFunc("/secrets", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query()
+		variableID := query.Get("variable_id")
+		if variableID == "" {
+			http.Error(w, "variable_id is required", http.StatusBadRequest)
+			return
+		}
+
+		version := 0
+		if v := query.Get("version"); v != "" {
+			var err error
+			version, err = strconv.Atoi(v)
+			if err != nil || version <= 0 {
+				http.Error(w, "version must be a positive integer", http.StatusBadRequest)
+				return
+			}
+		}
+
+		value, err := a.secret(variableID, version)
+		if err != nil {
+			writeAgentError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(value)
+	})
+
+	return mux
+}
+
+// isLoopbackHost reports whether the Host header of a request names the loopback interface, as
+// an IP address or localhost, with an optional port
+func isLoopbackHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// loopbackHostOnly rejects requests whose Host header does not name the loopback interface. A web
+// page could otherwise reach an agent listening on a tcp:// address through DNS rebinding, by
+// resolving its own host name to the loopback address.
+func loopbackHostOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopbackHost(r.Host) {
+			http.Error(w, "invalid host", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// agentListener listens on a unix:// socket, created with the given mode, or on a tcp:// address
+// of the loopback interface. The API has no authentication of its own, so it must not be
+// reachable from other machines.
+// unixSocketListener removes its socket when closed, the socket having been moved to a path other
+// than the one it was created at
+type unixSocketListener struct {
+	net.Listener
+	path string
+}
+
+func (l *unixSocketListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: l.path, Net: "unix"}
+}
+
+func (l *unixSocketListener) Close() error {
+	err := l.Listener.Close()
+	os.Remove(l.path)
+	return err
+}
+
+// listenUnixSocket listens on a unix socket which is never accessible with a wider mode than the
+// given one: it is created in a private directory, where its mode is set, then moved into place.
+func listenUnixSocket(path string, mode os.FileMode) (net.Listener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".conjur-agent")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	tempPath := filepath.Join(dir, "s")
+	listener, err := net.Listen("unix", tempPath)
+	if err != nil {
+		return nil, err
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+
+	if err := os.Chmod(tempPath, mode); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return &unixSocketListener{Listener: listener, path: path}, nil
+}
+
+func agentListener(address string, mode os.FileMode) (net.Listener, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid listen address %q: %s", address, err)
+	}
+
+	switch u.Scheme {
+	case "unix":
+		path := u.Path
+		if path == "" {
+			return nil, fmt.Errorf("invalid listen address %q: missing socket path", address)
+		}
+		// Remove the socket left behind by an agent that did not shut down cleanly
+		if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(path)
+		}
+
+		return listenUnixSocket(path, mode)
+	case "tcp":
+		host, _, err := net.SplitHostPort(u.Host)
+		if err != nil {
+			return nil, fmt.Errorf("invalid listen address %q: %s", address, err)
+		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return nil, fmt.Errorf("invalid listen address %q: only loopback addresses are allowed", address)
+		}
+		return net.Listen("tcp", u.Host)
+	}
+	return nil, fmt.Errorf("invalid listen address %q: must start with unix:// or tcp://", address)
+}
+
+func newAgentCommand(clientFactory agentClientFactoryFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "agent",
+		Short: "Serve a cached access token and secrets to local processes",
+		Long: `Serve a cached access token and secrets to local processes.
+
+The agent authenticates once with the configured authentication method, keeps the access token refreshed, and serves a local HTTP API, so that processes on the same machine do not need to authenticate with Conjur themselves:
+
+  GET /token                                 the access token, as returned by 'conjur authenticate'
+  GET /token?encoding=base64                 the base64-encoded access token
+  GET /secrets?variable_id=[id]              the value of a variable
+  GET /secrets?variable_id=[id]&version=[n]  a version of a variable
+
+Errors returned by Conjur are forwarded with their status code. The API has no authentication of its own: access is controlled by the permissions of the socket, set with --socket-mode, or limited to the loopback interface for tcp:// addresses. On tcp:// addresses, requests are rejected unless their Host header is a loopback address or localhost, so that web pages cannot reach the agent through DNS rebinding.
+
+Examples:
+- conjur agent --listen unix:///run/conjur.sock
+- curl --unix-socket /run/conjur.sock 'http://localhost/secrets?variable_id=prod/db/password'
+- conjur agent --listen tcp://127.0.0.1:8200`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			listen, err := cmd.Flags().GetString("listen")
+			if err != nil {
+				return err
+			}
+
+			socketMode, err := cmd.Flags().GetString("socket-mode")
+			if err != nil {
+				return err
+			}
+			mode, err := strconv.ParseUint(socketMode, 8, 32)
+			if err != nil || mode > 0777 {
+				return fmt.Errorf("Invalid socket mode %q", socketMode)
+			}
+
+			client, err := clientFactory(cmd)
+			if err != nil {
+				return err
+			}
+
+			agent := &conjurAgent{client: client}
+			// Fail early when the credentials are invalid
+			if _, err := agent.accessToken(); err != nil {
+				return err
+			}
+
+			listener, err := agentListener(listen, os.FileMode(mode))
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			go func() {
+				ticker := time.NewTicker(agentRefreshInterval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						if _, err := agent.accessToken(); err != nil {
+							cmd.PrintErrf("Error: Unable to refresh the access token: %s\n", err)
+						}
+					}
+				}
+			}()
+
+			handler := agent.handler()
+			if listener.Addr().Network() == "tcp" {
+				handler = loopbackHostOnly(handler)
+			}
+
+			server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				server.Shutdown(shutdownCtx)
+			}()
+
+			cmd.PrintErrf("Listening on %s\n", listen)
+			if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("listen", "unix:///run/conjur.sock", "Address to listen on, as unix://[path] or tcp://[loopback address]:[port]")
+	cmd.Flags().String("socket-mode", "0600", "Permissions of the unix socket")
+
+	return cmd
+}
+
+func init() {
+	agentCmd := newAgentCommand(agentClientFactory)
+	rootCmd.AddCommand(agentCmd)
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAgentClient struct {
+	authentications *int
+	iat             time.Time
+}
+
+func (m mockAgentClient) InternalAuthenticate() ([]byte, error) {
+	*m.authentications++
+	payload := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"admin","iat":%d}`, m.iat.Unix())))
+	return []byte(fmt.Sprintf(`{"protected":"e30=","payload":"%s","signature":"c2ln"}`, payload)), nil
+}
+
+func (m mockAgentClient) RetrieveSecret(variableID string) ([]byte, error) {
+	return m.RetrieveSecretWithVersion(variableID, 0)
+}
+
+func (m mockAgentClient) RetrieveSecretWithVersion(variableID string, version int) ([]byte, error) {
+	switch variableID {
+	case "prod/db/password":
+		return []byte(fmt.Sprintf("s3cr3t-%d", version)), nil
+	case "prod/denied":
+		return nil, &response.ConjurError{Code: 403, Message: "Forbidden"}
+	}
+	return nil, fmt.Errorf("connection refused")
+}
+
+func newTestAgent(iat time.Time) (*conjurAgent, *int) {
+	authentications := new(int)
+	return &conjurAgent{client: mockAgentClient{authentications: authentications, iat: iat}}, authentications
+}
+
+func agentGet(t *testing.T, handler http.Handler, target string) (int, string) {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	return recorder.Code, recorder.Body.String()
+}
+
+func TestAgentToken(t *testing.T) {
+	t.Run("caches the access token", func(t *testing.T) {
+		agent, authentications := newTestAgent(time.Now())
+		handler := agent.handler()
+
+		status, body := agentGet(t, handler, "/token")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, `"signature":"c2ln"`)
+
+		status, encoded := agentGet(t, handler, "/token?encoding=base64")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(body)), encoded)
+
+		assert.Equal(t, 1, *authentications)
+	})
+
+	t.Run("refreshes the access token before it expires", func(t *testing.T) {
+		agent, authentications := newTestAgent(time.Now().Add(-6 * time.Minute))
+
+		_, err := agent.accessToken()
+		assert.NoError(t, err)
+		_, err = agent.accessToken()
+		assert.NoError(t, err)
+
+		assert.Equal(t, 2, *authentications)
+	})
+
+	t.Run("only allows GET", func(t *testing.T) {
+		agent, _ := newTestAgent(time.Now())
+		recorder := httptest.NewRecorder()
+		agent.handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/token", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	})
+}
+
+func TestAgentSecrets(t *testing.T) {
+	agent, _ := newTestAgent(time.Now())
+	handler := agent.handler()
+
+	testCases := []struct {
+		target         string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"/secrets?variable_id=prod/db/password", http.StatusOK, "s3cr3t-0"},
+		{"/secrets?variable_id=prod%2Fdb%2Fpassword&version=2", http.StatusOK, "s3cr3t-2"},
+		{"/secrets?variable_id=prod/db/password&version=0", http.StatusBadRequest, "version must be a positive integer\n"},
+		{"/secrets", http.StatusBadRequest, "variable_id is required\n"},
+		{"/secrets?variable_id=prod/denied", http.StatusForbidden, "Forbidden\n"},
+		{"/secrets?variable_id=prod/unreachable", http.StatusBadGateway, "connection refused\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.target, func(t *testing.T) {
+			status, body := agentGet(t, handler, tc.target)
+			assert.Equal(t, tc.expectedStatus, status)
+			assert.Equal(t, tc.expectedBody, body)
+		})
+	}
+}
+
+func TestAgentListener(t *testing.T) {
+	t.Run("unix socket", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "conjur.sock")
+		listener, err := agentListener("unix://"+path, 0600)
+		assert.NoError(t, err)
+
+		info, err := os.Stat(path)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		agent, _ := newTestAgent(time.Now())
+		server := &http.Server{Handler: agent.handler()}
+		go server.Serve(listener)
+		defer server.Close()
+
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", path)
+			},
+		}}
+		resp, err := client.Get("http://localhost/secrets?variable_id=prod/db/password")
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "s3cr3t-0", string(body))
+
+		// The socket is created in a private directory, which is removed once the socket is in place
+		entries, err := os.ReadDir(filepath.Dir(path))
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+
+		assert.NoError(t, listener.Close())
+		assert.NoFileExists(t, path)
+	})
+
+	t.Run("invalid addresses", func(t *testing.T) {
+		_, err := agentListener("tcp://0.0.0.0:8200", 0600)
+		assert.EqualError(t, err, `invalid listen address "tcp://0.0.0.0:8200": only loopback addresses are allowed`)
+
+		_, err = agentListener("http://localhost:8200", 0600)
+		assert.EqualError(t, err, `invalid listen address "http://localhost:8200": must start with unix:// or tcp://`)
+
+		_, err = agentListener("unix://", 0600)
+		assert.EqualError(t, err, `invalid listen address "unix://": missing socket path`)
+	})
+}
+
+func TestAgentLoopbackHostOnly(t *testing.T) {
+	agent, _ := newTestAgent(time.Now())
+	handler := loopbackHostOnly(agent.handler())
+
+	for _, target := range []string{
+		"http://127.0.0.1:8200/token",
+		"http://localhost:8200/token",
+		"http://[::1]:8200/token",
+		"http://127.0.0.1/token",
+	} {
+		code, _ := agentGet(t, handler, target)
+		assert.Equal(t, http.StatusOK, code, target)
+	}
+
+	for _, target := range []string{
+		"http://attacker.example:8200/token",
+		"http://attacker.example/secrets?variable_id=prod/db/password",
+		"http://10.0.0.1:8200/token",
+	} {
+		code, body := agentGet(t, handler, target)
+		assert.Equal(t, http.StatusForbidden, code, target)
+		assert.Equal(t, "invalid host\n", body)
+	}
+}
@@ -0,0 +1,349 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"filippo.io/age"
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-cli-go/pkg/clients"
+	"github.com/cyberark/conjur-cli-go/pkg/prompts"
+	"github.com/cyberark/conjur-cli-go/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+type backupClient interface {
+	policyClient
+	Resources(filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error)
+	RetrieveBatchSecretsSafe(variableIDs []string) (map[string][]byte, error)
+	AddSecret(variableID string, secretValue string) error
+}
+
+type backupClientFactoryFunc func(*cobra.Command) (backupClient, error)
+
+func backupClientFactory(cmd *cobra.Command) (backupClient, error) {
+	return clients.AuthenticatedConjurClientForCommand(cmd)
+}
+
+// backupPassphraseEnv is the environment variable holding the passphrase of a backup, which is
+// prompted for otherwise
+const backupPassphraseEnv = "CONJUR_BACKUP_PASSPHRASE"
+
+// backupPolicyBranch is the policy branch captured by a backup
+const backupPolicyBranch = "root"
+
+// backupPassphrase returns the passphrase of a backup from --passphrase-file, the environment or
+// a prompt
+func backupPassphrase(cmd *cobra.Command) (string, error) {
+	file, err := cmd.Flags().GetString("passphrase-file")
+	if err != nil {
+		return "", err
+	}
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		return string(bytes.TrimRight(data, "\r\n")), nil
+	}
+
+	if passphrase := os.Getenv(backupPassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	return prompts.AskForBackupPassphrase()
+}
+
+// backupVariables fetches the values of every variable visible to the current user, in chunks,
+// keyed by variable IDs without the account
+func backupVariables(cmd *cobra.Command, client backupClient, chunkSize int) (map[string][]byte, error) {
+	ids := []string{}
+	filter := conjurapi.ResourceFilter{Kind: "variable"}
+	err := forEachResourcePage(client, filter, 1000, func(page []map[string]interface{}) error {
+		for _, resource := range page {
+			if id, ok := resource["id"].(string); ok {
+				ids = append(ids, variableIDFromResourceID(id))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make(map[string][]byte, len(ids))
+	for start := 0; start < len(ids); start += chunkSize {
+		end := start + chunkSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		values, err := retrieveVariableChunk(cmd, client, ids[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for fullID, value := range values {
+			secrets[variableIDFromResourceID(fullID)] = value
+		}
+	}
+	return secrets, nil
+}
+
+func newBackupCreateCmd(clientFactory backupClientFactoryFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Back up the policy and variable values of Conjur to an archive",
+		Long: `Back up the policy and variable values of Conjur to an archive.
+
+The archive is a gzipped tar file holding the effective policy of the root branch, as YAML and JSON. With --secrets, the values of every variable visible to the current user are also fetched and stored encrypted with age (https://age-encryption.org), either to the age public keys given with --recipient or with a passphrase read from --passphrase-file, the CONJUR_BACKUP_PASSPHRASE environment variable or a prompt. Variables without a value are skipped, while any other error fetching a value fails the backup.
+
+The archive is only readable by the current user.
+
+Examples:
+- conjur backup create -o backup.tar.gz
+- conjur backup create -o backup.tar.gz --secrets
+- conjur backup create -o backup.tar.gz --secrets -r age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := cmd.Flags().GetString("file")
+			if err != nil {
+				return err
+			}
+
+			withSecrets, err := cmd.Flags().GetBool("secrets")
+			if err != nil {
+				return err
+			}
+
+			publicKeys, err := cmd.Flags().GetStringSlice("recipient")
+			if err != nil {
+				return err
+			}
+
+			chunkSize, err := cmd.Flags().GetInt("chunk-size")
+			if err != nil {
+				return err
+			}
+			if chunkSize <= 0 {
+				return errors.New("Chunk size must be greater than 0")
+			}
+
+			if err := validateFilePath(file); err != nil {
+				return err
+			}
+
+			backup := utils.Backup{
+				Manifest: utils.BackupManifest{
+					Version:   utils.BackupFormatVersion,
+					CreatedAt: time.Now().UTC().Format(time.RFC3339),
+					Branch:    backupPolicyBranch,
+				},
+			}
+
+			// Resolve the encryption key before fetching anything, so that a missing key fails early
+			var recipients []age.Recipient
+			if withSecrets {
+				passphrase := ""
+				if len(publicKeys) == 0 {
+					if passphrase, err = backupPassphrase(cmd); err != nil {
+						return err
+					}
+				}
+				if recipients, err = utils.BackupRecipients(publicKeys, passphrase); err != nil {
+					return err
+				}
+			}
+
+			client, err := clientFactory(cmd)
+			if err != nil {
+				return err
+			}
+
+			if backup.PolicyYAML, err = fetchPolicy(client, backupPolicyBranch, false, 64, 100000); err != nil {
+				return err
+			}
+			if backup.PolicyJSON, err = fetchPolicy(client, backupPolicyBranch, true, 64, 100000); err != nil {
+				return err
+			}
+
+			var secrets map[string][]byte
+			if withSecrets {
+				if secrets, err = backupVariables(cmd, client, chunkSize); err != nil {
+					return err
+				}
+				if backup.Secrets, err = utils.EncryptBackupSecrets(secrets, recipients...); err != nil {
+					return err
+				}
+				backup.Manifest.Variables = len(secrets)
+			}
+
+			out, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+			if err != nil {
+				return err
+			}
+			// An existing file keeps its mode when it is opened
+			if err := out.Chmod(0600); err != nil {
+				out.Close()
+				return err
+			}
+			if err := utils.WriteBackup(out, backup); err != nil {
+				out.Close()
+				return err
+			}
+			if err := out.Close(); err != nil {
+				return err
+			}
+
+			if withSecrets {
+				cmd.Printf("Backed up the policy and %d variable value(s) to %s\n", len(secrets), file)
+			} else {
+				cmd.Printf("Backed up the policy to %s\n", file)
+			}
+			return nil
+		},
+	}
+
+	// -o is local to this command: the global --output flag has no shorthand
+	cmd.Flags().StringP("file", "o", "", "The backup archive to write")
+	cmd.Flags().Bool("secrets", false, "Also back up the values of variables, encrypted")
+	cmd.Flags().StringSliceP("recipient", "r", []string{}, "Encrypt the values of variables to these age public keys instead of a passphrase")
+	cmd.Flags().String("passphrase-file", "", "Read the passphrase encrypting the values of variables from this file")
+	cmd.Flags().Int("chunk-size", 100, "Number of variable values fetched per request")
+	cmd.MarkFlagRequired("file")
+
+	return cmd
+}
+
+func newBackupRestoreCmd(clientFactory backupClientFactoryFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore the policy and variable values of Conjur from an archive",
+		Long: `Restore the policy and variable values of Conjur from an archive written by 'conjur backup create'.
+
+The policy is loaded into the root branch, adding to the existing policy unless --replace is given, and the values of the variables in the archive are then set. The values are decrypted with the age identity file given with --identity, or with a passphrase read from --passphrase-file, the CONJUR_BACKUP_PASSPHRASE environment variable or a prompt.
+
+The effective policy may not fully replicate the policy defined in Conjur, in which case loading it may fail.
+
+Examples:
+- conjur backup restore -f backup.tar.gz
+- conjur backup restore -f backup.tar.gz --identity key.txt
+- conjur backup restore -f backup.tar.gz --skip-secrets --replace`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := cmd.Flags().GetString("file")
+			if err != nil {
+				return err
+			}
+
+			identityFile, err := cmd.Flags().GetString("identity")
+			if err != nil {
+				return err
+			}
+
+			skipSecrets, err := cmd.Flags().GetBool("skip-secrets")
+			if err != nil {
+				return err
+			}
+
+			replace, err := cmd.Flags().GetBool("replace")
+			if err != nil {
+				return err
+			}
+
+			in, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			backup, err := utils.ReadBackup(in)
+			in.Close()
+			if err != nil {
+				return fmt.Errorf("%s: %s", file, err)
+			}
+
+			// Decrypt the values before loading the policy, so that a wrong key changes nothing
+			secrets := map[string][]byte{}
+			if len(backup.Secrets) > 0 && !skipSecrets {
+				passphrase := ""
+				if identityFile == "" {
+					if passphrase, err = backupPassphrase(cmd); err != nil {
+						return err
+					}
+				}
+				identities, err := utils.BackupIdentities(identityFile, passphrase)
+				if err != nil {
+					return err
+				}
+				if secrets, err = utils.DecryptBackupSecrets(backup.Secrets, identities...); err != nil {
+					return fmt.Errorf("Unable to decrypt the values of variables: %s", err)
+				}
+			}
+
+			client, err := clientFactory(cmd)
+			if err != nil {
+				return err
+			}
+
+			policyMode := conjurapi.PolicyModePost
+			if replace {
+				policyMode = conjurapi.PolicyModePut
+			}
+			if _, err := LoadPolicy(client, policyMode, backup.Manifest.Branch, bytes.NewReader(backup.PolicyYAML)); err != nil {
+				return err
+			}
+			cmd.Printf("Loaded the policy into %s\n", backup.Manifest.Branch)
+
+			ids := make([]string, 0, len(secrets))
+			for id := range secrets {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+
+			failed := 0
+			for _, id := range ids {
+				if err := client.AddSecret(id, string(secrets[id])); err != nil {
+					failed++
+					cmd.PrintErrf("Failed %s: %s\n", id, err)
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d variable(s) failed", failed, len(ids))
+			}
+			if len(ids) > 0 {
+				cmd.Printf("%d variable(s) set\n", len(ids))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("file", "f", "", "The backup archive to restore")
+	cmd.Flags().StringP("identity", "i", "", "Decrypt the values of variables with this age identity file instead of a passphrase")
+	cmd.Flags().String("passphrase-file", "", "Read the passphrase decrypting the values of variables from this file")
+	cmd.Flags().Bool("skip-secrets", false, "Only restore the policy")
+	cmd.Flags().Bool("replace", false, "Replace the root policy instead of adding to it")
+	cmd.MarkFlagRequired("file")
+
+	return cmd
+}
+
+func newBackupCmd(clientFactory backupClientFactoryFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up and restore Conjur",
+		Run: func(cmd *cobra.Command, args []string) {
+			// Print --help if called without subcommand
+			cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newBackupCreateCmd(clientFactory))
+	cmd.AddCommand(newBackupRestoreCmd(clientFactory))
+	return cmd
+}
+
+func init() {
+	backupCmd := newBackupCmd(backupClientFactory)
+	rootCmd.AddCommand(backupCmd)
+}
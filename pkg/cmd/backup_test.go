@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/cyberark/conjur-cli-go/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBackupClient struct {
+	mockPolicyClient
+	mockVariableTransferClient
+}
+
+func newMockBackupClient(t *testing.T, loaded map[conjurapi.PolicyMode]string) mockBackupClient {
+	transfer := newMockVariableTransferClient(t)
+	transfer.resources = []map[string]interface{}{
+		{"id": "dev:variable:prod/db/password"},
+		{"id": "dev:variable:prod/db/user"},
+		{"id": "dev:variable:prod/empty"},
+	}
+	transfer.values = map[string][]byte{
+		"prod/db/password": []byte("secret"),
+		"prod/db/user":     []byte("admin"),
+	}
+
+	return mockBackupClient{
+		mockPolicyClient: mockPolicyClient{
+			t: t,
+			fetchPolicy: func(t *testing.T, policyBranch string, returnJSON bool, policyTreeDepth uint, sizeLimit uint) ([]byte, error) {
+				assert.Equal(t, "root", policyBranch)
+				if returnJSON {
+					return []byte(`[{"!variable": "prod/db/password"}]`), nil
+				}
+				return []byte("- !variable prod/db/password\n"), nil
+			},
+			loadPolicy: func(t *testing.T, mode conjurapi.PolicyMode, policyBranch string, policySrc io.Reader) (*conjurapi.PolicyResponse, error) {
+				assert.Equal(t, "root", policyBranch)
+				data, err := io.ReadAll(policySrc)
+				assert.NoError(t, err)
+				loaded[mode] = string(data)
+				return &conjurapi.PolicyResponse{}, nil
+			},
+		},
+		mockVariableTransferClient: transfer,
+	}
+}
+
+func newBackupTestCmd(client backupClient) *cobra.Command {
+	return newBackupCmd(func(cmd *cobra.Command) (backupClient, error) {
+		return client, nil
+	})
+}
+
+func TestBackupCmd(t *testing.T) {
+	t.Run("restricts the mode of an existing archive", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "backup.tar.gz")
+		assert.NoError(t, os.WriteFile(file, []byte("old"), 0644))
+		client := newMockBackupClient(t, map[conjurapi.PolicyMode]string{})
+
+		_, _, err := executeCommandForTest(t, newBackupTestCmd(client), "backup", "create", "--file", file)
+		assert.NoError(t, err)
+
+		info, err := os.Stat(file)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("policy only", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "backup.tar.gz")
+		loaded := map[conjurapi.PolicyMode]string{}
+		client := newMockBackupClient(t, loaded)
+
+		stdout, _, err := executeCommandForTest(t, newBackupTestCmd(client), "backup", "create", "-o", file)
+		assert.NoError(t, err)
+		assert.Equal(t, "Backed up the policy to "+file+"\n", stdout)
+
+		info, err := os.Stat(file)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		in, err := os.Open(file)
+		assert.NoError(t, err)
+		defer in.Close()
+		backup, err := utils.ReadBackup(in)
+		assert.NoError(t, err)
+		assert.Equal(t, "- !variable prod/db/password\n", string(backup.PolicyYAML))
+		assert.Equal(t, `[{"!variable": "prod/db/password"}]`, string(backup.PolicyJSON))
+		assert.Empty(t, backup.Secrets)
+
+		stdout, _, err = executeCommandForTest(t, newBackupTestCmd(client), "backup", "restore", "-f", file, "--replace")
+		assert.NoError(t, err)
+		assert.Equal(t, "Loaded the policy into root\n", stdout)
+		assert.Equal(t, map[conjurapi.PolicyMode]string{conjurapi.PolicyModePut: "- !variable prod/db/password\n"}, loaded)
+		assert.Empty(t, client.added)
+	})
+
+	t.Run("secrets encrypted to an age key", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "backup.tar.gz")
+		identity, err := age.GenerateX25519Identity()
+		assert.NoError(t, err)
+		identityFile := filepath.Join(dir, "key.txt")
+		assert.NoError(t, os.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600))
+
+		loaded := map[conjurapi.PolicyMode]string{}
+		client := newMockBackupClient(t, loaded)
+
+		stdout, stderr, err := executeCommandForTest(t, newBackupTestCmd(client), "backup", "create", "--file", file, "--secrets", "-r", identity.Recipient().String())
+		assert.NoError(t, err)
+		assert.Contains(t, stderr, "Skipped prod/empty")
+		assert.Equal(t, "Backed up the policy and 2 variable value(s) to "+file+"\n", stdout)
+
+		stdout, _, err = executeCommandForTest(t, newBackupTestCmd(client), "backup", "restore", "-f", file, "-i", identityFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "Loaded the policy into root\n2 variable(s) set\n", stdout)
+		assert.Equal(t, map[conjurapi.PolicyMode]string{conjurapi.PolicyModePost: "- !variable prod/db/password\n"}, loaded)
+		assert.Equal(t, map[string]string{"prod/db/password": "secret", "prod/db/user": "admin"}, client.added)
+	})
+
+	t.Run("secrets encrypted with a passphrase", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "backup.tar.gz")
+		loaded := map[conjurapi.PolicyMode]string{}
+		client := newMockBackupClient(t, loaded)
+
+		t.Setenv(backupPassphraseEnv, "correct horse")
+		_, _, err := executeCommandForTest(t, newBackupTestCmd(client), "backup", "create", "--file", file, "--secrets")
+		assert.NoError(t, err)
+
+		t.Setenv(backupPassphraseEnv, "wrong")
+		_, _, err = executeCommandForTest(t, newBackupTestCmd(client), "backup", "restore", "-f", file)
+		assert.ErrorContains(t, err, "Unable to decrypt the values of variables")
+		assert.Empty(t, loaded)
+
+		passphraseFile := filepath.Join(t.TempDir(), "passphrase")
+		assert.NoError(t, os.WriteFile(passphraseFile, []byte("correct horse\n"), 0600))
+		stdout, _, err := executeCommandForTest(t, newBackupTestCmd(client), "backup", "restore", "-f", file, "--passphrase-file", passphraseFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "Loaded the policy into root\n2 variable(s) set\n", stdout)
+		assert.Equal(t, "secret", client.added["prod/db/password"])
+	})
+
+	t.Run("binary values", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "backup.tar.gz")
+		client := newMockBackupClient(t, map[conjurapi.PolicyMode]string{})
+		client.values["prod/db/password"] = []byte{0xff, 0x00, 0xfe, 0x80}
+
+		t.Setenv(backupPassphraseEnv, "correct horse")
+		_, _, err := executeCommandForTest(t, newBackupTestCmd(client), "backup", "create", "--file", file, "--secrets")
+		assert.NoError(t, err)
+		_, _, err = executeCommandForTest(t, newBackupTestCmd(client), "backup", "restore", "-f", file)
+		assert.NoError(t, err)
+		assert.Equal(t, "\xff\x00\xfe\x80", client.added["prod/db/password"])
+	})
+
+	t.Run("fails when a value cannot be fetched", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "backup.tar.gz")
+		client := newMockBackupClient(t, map[conjurapi.PolicyMode]string{})
+		client.resources = append(client.resources, map[string]interface{}{"id": "dev:variable:prod/broken"})
+		client.retrieveError = &response.ConjurError{Code: 403, Message: "Forbidden"}
+
+		t.Setenv(backupPassphraseEnv, "correct horse")
+		_, stderr, err := executeCommandForTest(t, newBackupTestCmd(client), "backup", "create", "--file", file, "--secrets")
+		assert.Error(t, err)
+		assert.Contains(t, stderr, "Error: Forbidden")
+		assert.NoFileExists(t, file)
+	})
+
+	t.Run("invalid archive", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "backup.tar.gz")
+		assert.NoError(t, os.WriteFile(file, []byte("- !variable x\n"), 0600))
+
+		_, _, err := executeCommandForTest(t, newBackupTestCmd(newMockBackupClient(t, nil)), "backup", "restore", "-f", file)
+		assert.ErrorContains(t, err, file+": not a backup archive")
+	})
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/cyberark/conjur-cli-go/pkg/clients"
 	"github.com/spf13/cobra"
 )
@@ -8,6 +10,12 @@ import (
 type checkClient interface {
 	CheckPermission(resourceID string, privilege string) (bool, error)
 	CheckPermissionForRole(resourceID string, roleID string, privilege string) (bool, error)
+	WhoAmI() ([]byte, error)
+	Role(roleID string) (map[string]interface{}, error)
+	RoleMembers(roleID string) ([]map[string]interface{}, error)
+	RoleMembershipsAll(roleID string) ([]string, error)
+	PermittedRoles(resourceID, privilege string) ([]string, error)
+	Resource(resourceID string) (map[string]interface{}, error)
 }
 
 type checkClientFactoryFunc func(*cobra.Command) (checkClient, error)
@@ -22,22 +30,60 @@ func newCheckCmd(clientFactory checkClientFactoryFunc) *cobra.Command {
 		Short: "Check a role's privilege on a resource",
 		Long: `Check a role's privilege on a resource
 
-This command requires a [resource-id] and a [privilege].
+This command requires a [resource-id] and a [privilege], unless
+[--matrix] is given.
 
 By default, this command checks if the currently authenticated
 user has privilege over the resource. When the optional [-r|--role]
 flag is provided, the command checks if the specified role has
 privilege over the resource.
 
+The optional [--explain] flag prints each chain of memberships
+through which the role holds the privilege, ending with the
+privilege granted to the last role of the chain (or "owner" when
+it owns the resource). With [--json] or the global [--output] flag,
+the result is printed as an object with the field "allowed", and
+"paths" with [--explain].
+
+With the optional [--matrix] flag, the checks listed in a YAML or
+JSON file are run concurrently instead, and a report is printed as
+text, as JUnit XML with [--format junit], or as an object with the
+fields "checks", "passed" and "failed" with [--json] or the global
+[--output] flag. The command fails when a check does not have the
+expected result:
+
+  - name: billing can fetch its password
+    role: dev:host:apps/billing
+    resource: dev:variable:prod/db/password
+    privilege: execute
+    expected: true
+  - role: dev:user:bob
+    resource: dev:variable:prod/db/password
+    privilege: execute
+    expected: false
+
 Examples:
 
 - conjur check dev:host:somehost write
 - conjur check -r user:someuser dev:variable:somevariable read
-- conjur check -r dev:user:someuser dev:variable:somevariable read`,
+- conjur check -r dev:user:someuser dev:variable:somevariable read
+- conjur check --explain -r user:someuser dev:variable:somevariable execute
+- conjur check --json dev:variable:somevariable execute
+- conjur check --explain --output json dev:variable:somevariable execute
+- conjur check --matrix checks.yml --format junit > report.xml`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var resourceID string
 			var privilege string
 
+			matrix, err := cmd.Flags().GetString("matrix")
+			if err != nil {
+				return err
+			}
+			if matrix != "" {
+				cmd.SilenceUsage = true
+				return runCheckMatrixCommand(cmd, clientFactory, matrix)
+			}
+
 			if len(args) < 2 {
 				cmd.Help()
 				return nil
@@ -66,13 +112,45 @@ Examples:
 				return err
 			}
 
-			cmd.Println(result)
+			explain, err := cmd.Flags().GetBool("explain")
+			if err != nil {
+				return err
+			}
+
+			paths := []checkPath{}
+			if explain && result {
+				paths, err = explainCheck(client, resourceID, roleID, privilege)
+				if err != nil {
+					return err
+				}
+			}
+
+			data := map[string]interface{}{
+				"allowed": result,
+			}
+			if explain {
+				data["paths"] = paths
+			}
 
-			return nil
+			return printResult(cmd, data, func() error {
+				cmd.Println(result)
+				for _, path := range paths {
+					cmd.Println(path)
+				}
+				if explain && result && len(paths) == 0 {
+					cmd.PrintErrln("No membership path found, the privilege may be granted through roles that are not visible")
+				}
+				return nil
+			})
 		},
 	}
 
 	cmd.Flags().StringP("role", "r", "", "Partially- or fully-qualified role ID to check privilege for")
+	cmd.Flags().Bool("explain", false, "Print the membership paths that grant the privilege")
+	cmd.Flags().Bool("json", false, "Output a JSON response with field 'allowed' (same as --output json)")
+	cmd.Flags().String("matrix", "", "Run the checks listed in a YAML or JSON file, or - for stdin, instead of a single check")
+	cmd.Flags().String("format", "text", "Report format of --matrix: "+strings.Join(checkMatrixFormats, ", "))
+	cmd.Flags().Int("concurrency", 10, "Number of checks of --matrix run at the same time")
 
 	return cmd
 }
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// maxExplainPaths bounds the number of membership paths reported by 'check --explain'
+const maxExplainPaths = 20
+
+// checkPath is a chain of roles, starting with the checked role, whose last role is granted the
+// privilege, directly or as the owner of the resource
+type checkPath struct {
+	Roles []string `json:"roles"`
+	Grant string   `json:"grant"`
+}
+
+func (p checkPath) String() string {
+	return strings.Join(append(append([]string{}, p.Roles...), p.Grant), " -> ")
+}
+
+// currentRoleID returns the fully-qualified ID of the authenticated role
+func currentRoleID(client checkClient) (string, error) {
+	data, err := client.WhoAmI()
+	if err != nil {
+		return "", err
+	}
+
+	var whoami struct {
+		Account  string `json:"account"`
+		Username string `json:"username"`
+	}
+	if err := json.Unmarshal(data, &whoami); err != nil {
+		return "", err
+	}
+
+	if host, ok := strings.CutPrefix(whoami.Username, "host/"); ok {
+		return whoami.Account + ":host:" + host, nil
+	}
+	return whoami.Account + ":user:" + whoami.Username, nil
+}
+
+// explainCheck finds the membership paths through which a role holds a privilege on a resource.
+// Only the roles the role is a member of that are also permitted on the resource can be on such a
+// path, so the walk is limited to them.
+func explainCheck(client checkClient, resourceID string, roleID string, privilege string) ([]checkPath, error) {
+	var root string
+	if roleID == "" {
+		var err error
+		root, err = currentRoleID(client)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		role, err := client.Role(roleID)
+		if err != nil {
+			return nil, err
+		}
+		root, _ = role["id"].(string)
+	}
+
+	memberships, err := client.RoleMembershipsAll(root)
+	if err != nil {
+		return nil, err
+	}
+
+	permitted, err := client.PermittedRoles(resourceID, privilege)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := map[string]bool{}
+	for _, role := range permitted {
+		candidates[role] = true
+	}
+	onPath := map[string]bool{root: candidates[root]}
+	for _, membership := range memberships {
+		if candidates[membership] {
+			onPath[membership] = true
+		}
+	}
+
+	resource, err := client.Resource(resourceID)
+	if err != nil {
+		return nil, err
+	}
+	grants := map[string]string{}
+	for role, labels := range resourceGrants(resource, []string{privilege}) {
+		if onPath[role] {
+			grants[role] = labels[0]
+		}
+	}
+
+	// Find the direct memberships between the roles on a path
+	memberOf := map[string][]string{}
+	for role := range onPath {
+		if role == root {
+			continue
+		}
+		members, err := client.RoleMembers(role)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			member, _ := m["member"].(string)
+			if member != role && (member == root || onPath[member]) {
+				memberOf[member] = append(memberOf[member], role)
+			}
+		}
+	}
+	for _, roles := range memberOf {
+		sort.Strings(roles)
+	}
+
+	paths := []checkPath{}
+	var walk func(chain []string)
+	walk = func(chain []string) {
+		if len(paths) >= maxExplainPaths {
+			return
+		}
+		last := chain[len(chain)-1]
+		if grant, ok := grants[last]; ok {
+			paths = append(paths, checkPath{Roles: append([]string{}, chain...), Grant: grant})
+			return
+		}
+		for _, next := range memberOf[last] {
+			if !containsString(chain, next) {
+				walk(append(chain, next))
+			}
+		}
+	}
+	walk([]string{root})
+
+	sort.SliceStable(paths, func(i, j int) bool {
+		return len(paths[i].Roles) < len(paths[j].Roles)
+	})
+	return paths, nil
+}
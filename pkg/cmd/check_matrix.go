@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cyberark/conjur-cli-go/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// checkMatrixFormats lists the report formats of 'check --matrix'
+var checkMatrixFormats = []string{"text", "junit"}
+
+type checkMatrixResult struct {
+	utils.PermissionCheck
+	Allowed  bool    `json:"allowed"`
+	Passed   bool    `json:"passed"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"-"`
+}
+
+// runCheckMatrix runs the checks concurrently, one worker per client, and returns their results in
+// the order of the checks
+func runCheckMatrix(workers []checkClient, checks []utils.PermissionCheck) []checkMatrixResult {
+	results := make([]checkMatrixResult, len(checks))
+
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for _, client := range workers {
+		wg.Add(1)
+		go func(client checkClient) {
+			defer wg.Done()
+			for index := range jobs {
+				check := checks[index]
+				start := time.Now()
+				allowed, err := client.CheckPermissionForRole(check.Resource, check.Role, check.Privilege)
+
+				result := checkMatrixResult{PermissionCheck: check, Allowed: allowed, Duration: time.Since(start).Seconds()}
+				if err != nil {
+					result.Error = err.Error()
+				} else {
+					result.Passed = allowed == *check.Expected
+				}
+				results[index] = result
+			}
+		}(client)
+	}
+	for i := range checks {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+func describeAllowed(allowed bool) string {
+	if allowed {
+		return "allowed"
+	}
+	return "denied"
+}
+
+func writeCheckMatrixText(out io.Writer, results []checkMatrixResult, failed int) {
+	for _, result := range results {
+		switch {
+		case result.Error != "":
+			fmt.Fprintf(out, "ERROR %s: %s\n", result.PermissionCheck, result.Error)
+		case result.Passed:
+			fmt.Fprintf(out, "PASS  %s: %s\n", result.PermissionCheck, describeAllowed(result.Allowed))
+		default:
+			fmt.Fprintf(out, "FAIL  %s: expected %s, got %s\n", result.PermissionCheck, describeAllowed(*result.Expected), describeAllowed(result.Allowed))
+		}
+	}
+	fmt.Fprintf(out, "%d check(s), %d passed, %d failed\n", len(results), len(results)-failed, failed)
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+func writeCheckMatrixJUnit(out io.Writer, results []checkMatrixResult) error {
+	suite := junitTestSuite{Name: "conjur check", Tests: len(results)}
+	for _, result := range results {
+		testCase := junitTestCase{
+			Name:      result.PermissionCheck.String(),
+			ClassName: "conjur.check",
+			Time:      fmt.Sprintf("%.3f", result.Duration),
+		}
+		switch {
+		case result.Error != "":
+			suite.Errors++
+			testCase.Error = &junitMessage{Message: result.Error}
+		case !result.Passed:
+			suite.Failures++
+			testCase.Failure = &junitMessage{Message: fmt.Sprintf("expected %s, got %s", describeAllowed(*result.Expected), describeAllowed(result.Allowed))}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	data, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%s%s\n", xml.Header, data)
+	return err
+}
+
+// runCheckMatrixCommand runs the checks of a matrix file and reports the results. It fails when
+// at least one check does not have the expected result.
+func runCheckMatrixCommand(cmd *cobra.Command, clientFactory checkClientFactoryFunc, file string) error {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return err
+	}
+	if !containsString(checkMatrixFormats, format) {
+		return fmt.Errorf("Format must be one of %s", strings.Join(checkMatrixFormats, ", "))
+	}
+	if outputFormat, query := getOutputFlags(cmd); format == "junit" && (outputFormat != "" || query != "") {
+		return errors.New("--format junit cannot be combined with --json, --output or --query")
+	}
+
+	concurrency, err := cmd.Flags().GetInt("concurrency")
+	if err != nil {
+		return err
+	}
+	if concurrency <= 0 {
+		return errors.New("Concurrency must be greater than 0")
+	}
+
+	var data []byte
+	if file == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+
+	checks, err := utils.ParseCheckMatrix(data)
+	if err != nil {
+		return fmt.Errorf("%s: %s", file, err)
+	}
+
+	client, err := clientFactory(cmd)
+	if err != nil {
+		return err
+	}
+	workers, err := workerClients(client, min(concurrency, len(checks)))
+	if err != nil {
+		return err
+	}
+
+	results := runCheckMatrix(workers, checks)
+	failed := 0
+	for _, result := range results {
+		if !result.Passed {
+			failed++
+		}
+	}
+
+	if format == "junit" {
+		err = writeCheckMatrixJUnit(cmd.OutOrStdout(), results)
+	} else {
+		report := map[string]interface{}{
+			"checks": results,
+			"passed": len(results) - failed,
+			"failed": failed,
+		}
+		err = printResult(cmd, report, func() error {
+			writeCheckMatrixText(cmd.OutOrStdout(), results, failed)
+			return nil
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d check(s) failed", failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+const checkMatrixTestFile = `
+- name: billing can fetch its password
+  role: dev:host:apps/billing
+  resource: dev:variable:prod/db/password
+  privilege: execute
+- role: dev:user:bob
+  resource: dev:variable:prod/db/password
+  privilege: execute
+  expected: false
+- role: dev:user:alice
+  resource: dev:variable:prod/db/password
+  privilege: update
+  expected: false
+`
+
+func newCheckMatrixTestCmd(t *testing.T) *cobra.Command {
+	return newCheckCmd(func(cmd *cobra.Command) (checkClient, error) {
+		return newCheckMatrixTestClient(t), nil
+	})
+}
+
+func newCheckMatrixTestClient(t *testing.T) mockCheckClient {
+	return mockCheckClient{
+		t: t,
+		checkPermissionForRole: func(t *testing.T, resourceID string, roleID string, privilege string) (bool, error) {
+			switch roleID {
+			case "dev:host:apps/billing", "dev:user:bob":
+				return true, nil
+			case "dev:user:alice":
+				return false, errors.New("403 Forbidden")
+			}
+			return false, nil
+		},
+	}
+}
+
+func writeCheckMatrix(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "checks.yml")
+	assert.NoError(t, os.WriteFile(file, []byte(content), 0600))
+	return file
+}
+
+func TestCheckMatrix(t *testing.T) {
+	t.Run("text report", func(t *testing.T) {
+		stdout, stderr, err := executeCommandForTest(t, newCheckMatrixTestCmd(t), "check", "--matrix", writeCheckMatrix(t, checkMatrixTestFile), "--concurrency", "2")
+
+		assert.EqualError(t, err, "2 of 3 check(s) failed")
+		assert.Equal(t, `PASS  billing can fetch its password: allowed
+FAIL  dev:user:bob execute dev:variable:prod/db/password: expected denied, got allowed
+ERROR dev:user:alice update dev:variable:prod/db/password: 403 Forbidden
+3 check(s), 1 passed, 2 failed
+`, stdout)
+		assert.Contains(t, stderr, "Error: 2 of 3 check(s) failed\n")
+		assert.NotContains(t, stderr, "Usage:")
+	})
+
+	t.Run("passing checks", func(t *testing.T) {
+		stdout, _, err := executeCommandForTest(t, newCheckMatrixTestCmd(t), "check", "--matrix", writeCheckMatrix(t, "- role: dev:user:bob\n  resource: dev:variable:x\n  privilege: read\n"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "PASS  dev:user:bob read dev:variable:x: allowed\n1 check(s), 1 passed, 0 failed\n", stdout)
+	})
+
+	t.Run("json report", func(t *testing.T) {
+		for _, flags := range [][]string{{"--json"}, {"--output", "json"}} {
+			args := append([]string{"check", "--matrix", writeCheckMatrix(t, checkMatrixTestFile)}, flags...)
+			stdout, _, _ := executeCommandForTest(t, newCheckMatrixTestCmd(t), args...)
+
+			assert.Contains(t, stdout, `"failed": 2,`)
+			assert.Contains(t, stdout, `"passed": 1`)
+			assert.Contains(t, stdout, `"name": "billing can fetch its password",`)
+			assert.Contains(t, stdout, `"error": "403 Forbidden"`)
+		}
+	})
+
+	t.Run("yaml report", func(t *testing.T) {
+		stdout, _, _ := executeCommandForTest(t, newCheckMatrixTestCmd(t), "check", "--matrix", writeCheckMatrix(t, checkMatrixTestFile), "--output", "yaml")
+
+		assert.Contains(t, stdout, "failed: 2\n")
+		assert.Contains(t, stdout, "passed: 1\n")
+	})
+
+	t.Run("junit report", func(t *testing.T) {
+		stdout, _, _ := executeCommandForTest(t, newCheckMatrixTestCmd(t), "check", "--matrix", writeCheckMatrix(t, checkMatrixTestFile), "--format", "junit")
+
+		assert.Contains(t, stdout, `<?xml version="1.0" encoding="UTF-8"?>`)
+		assert.Contains(t, stdout, `<testsuite name="conjur check" tests="3" failures="1" errors="1">`)
+		assert.Contains(t, stdout, `<testcase name="billing can fetch its password" classname="conjur.check"`)
+		assert.Contains(t, stdout, `<failure message="expected denied, got allowed"></failure>`)
+		assert.Contains(t, stdout, `<error message="403 Forbidden"></error>`)
+	})
+
+	t.Run("authenticates once for all workers", func(t *testing.T) {
+		created := 0
+		cmd := newCheckCmd(func(cmd *cobra.Command) (checkClient, error) {
+			created++
+			return newCheckMatrixTestClient(t), nil
+		})
+
+		_, _, err := executeCommandForTest(t, cmd, "check", "--matrix", writeCheckMatrix(t, checkMatrixTestFile), "--concurrency", "5")
+		assert.Error(t, err)
+		assert.Equal(t, 1, created)
+	})
+
+	t.Run("invalid matrix", func(t *testing.T) {
+		file := writeCheckMatrix(t, "- role: dev:user:bob\n")
+		_, stderr, _ := executeCommandForTest(t, newCheckMatrixTestCmd(t), "check", "--matrix", file)
+		assert.Contains(t, stderr, "Error: "+file+": check 1: resource is required\n")
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		_, stderr, _ := executeCommandForTest(t, newCheckMatrixTestCmd(t), "check", "--matrix", "checks.yml", "--format", "xml")
+		assert.Contains(t, stderr, "Error: Format must be one of text, junit\n")
+	})
+
+	t.Run("junit report with an output format", func(t *testing.T) {
+		_, stderr, _ := executeCommandForTest(t, newCheckMatrixTestCmd(t), "check", "--matrix", "checks.yml", "--format", "junit", "--json")
+		assert.Contains(t, stderr, "Error: --format junit cannot be combined with --json, --output or --query\n")
+	})
+}
@@ -9,6 +9,7 @@ import (
 )
 
 type mockCheckClient struct {
+	mockGraphClient
 	t                      *testing.T
 	checkPermission        func(t *testing.T, resourceID string, privilege string) (bool, error)
 	checkPermissionForRole func(t *testing.T, resourceID string, roleID string, privilege string) (bool, error)
@@ -22,6 +23,10 @@ func (m mockCheckClient) CheckPermissionForRole(resourceID string, roleID string
 	return m.checkPermissionForRole(m.t, resourceID, roleID, privilege)
 }
 
+func (m mockCheckClient) WhoAmI() ([]byte, error) {
+	return []byte(`{"account":"dev","username":"alice"}`), nil
+}
+
 var checkCmdTestCases = []struct {
 	name                   string
 	args                   []string
@@ -119,6 +124,63 @@ var checkCmdTestCases = []struct {
 			assert.Contains(t, stdout, "false")
 		},
 	},
+	{
+		name: "check explains the membership paths",
+		args: []string{"check", "--explain", "-r", "user:alice", "variable:db/pass", "execute"},
+		checkPermissionForRole: func(t *testing.T, resourceID string, roleID string, privilege string) (bool, error) {
+			return true, nil
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, "true\ndev:user:alice -> dev:group:admins -> dev:group:secrets-users -> execute\n", stdout)
+		},
+	},
+	{
+		name: "check explains the paths of the current user as JSON",
+		args: []string{"check", "--explain", "--output", "json", "variable:db/pass", "execute"},
+		checkPermission: func(t *testing.T, resourceID string, privilege string) (bool, error) {
+			return true, nil
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, `{
+  "allowed": true,
+  "paths": [
+    {
+      "grant": "execute",
+      "roles": [
+        "dev:user:alice",
+        "dev:group:admins",
+        "dev:group:secrets-users"
+      ]
+    }
+  ]
+}
+`, stdout)
+		},
+	},
+	{
+		name: "check prints the result as JSON with --json",
+		args: []string{"check", "--json", "variable:db/pass", "execute"},
+		checkPermission: func(t *testing.T, resourceID string, privilege string) (bool, error) {
+			return true, nil
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, "{\n  \"allowed\": true\n}\n", stdout)
+		},
+	},
+	{
+		name: "check does not explain denied privileges",
+		args: []string{"check", "--explain", "--output", "json", "-r", "user:alice", "variable:db/pass", "update"},
+		checkPermissionForRole: func(t *testing.T, resourceID string, roleID string, privilege string) (bool, error) {
+			return false, nil
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, "{\n  \"allowed\": false,\n  \"paths\": []\n}\n", stdout)
+		},
+	},
 	{
 		name: "check client error",
 		args: []string{"check", "abcdefg", "hijklmn"},
@@ -143,6 +205,7 @@ func TestCheckCmd(t *testing.T) {
 	for _, tc := range checkCmdTestCases {
 		t.Run(tc.name, func(t *testing.T) {
 			mockClient := mockCheckClient{
+				mockGraphClient:        mockGraphClient{t: t},
 				t:                      t,
 				checkPermission:        tc.checkPermission,
 				checkPermissionForRole: tc.checkPermissionForRole,
@@ -0,0 +1,370 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-cli-go/pkg/clients"
+	"github.com/cyberark/conjur-cli-go/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+type completionClient interface {
+	GetConfig() conjurapi.Config
+	Resources(filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error)
+}
+
+type completionClientFactoryFunc func(*cobra.Command) (completionClient, error)
+
+func completionClientFactory(cmd *cobra.Command) (completionClient, error) {
+	return clients.CachedConjurClientForCommand(cmd)
+}
+
+const (
+	// completionCacheTTL is how long completion results are reused without querying the server
+	completionCacheTTL = 5 * time.Minute
+	// completionCacheMaxStale is how long completion results are reused when the server is unreachable
+	completionCacheMaxStale = time.Hour
+	// completionLimit is the maximum number of resources requested per completion query
+	completionLimit = 1000
+)
+
+// completionShells lists the shells supported by 'conjur completion'
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// roleKinds lists the kinds of resources which are also roles
+var roleKinds = []string{"user", "host", "group", "layer", "policy"}
+
+// resourceKinds lists the kinds of resources offered when completing a resource ID
+var resourceKinds = []string{"user", "host", "group", "layer", "policy", "variable", "webservice", "host_factory"}
+
+// completionSearch returns the search term used to complete an ID: the path segments typed so far,
+// so that the results of a query are cached and filtered locally while a segment is being typed
+func completionSearch(partialID string) string {
+	index := strings.LastIndex(partialID, "/")
+	if index <= 0 {
+		return ""
+	}
+	return partialID[:index]
+}
+
+// fetchCompletionIDs returns the fully-qualified IDs of the resources of a kind matching a search
+// term, from the completion cache when possible
+func fetchCompletionIDs(cmd *cobra.Command, clientFactory completionClientFactoryFunc, kind string, search string) ([]string, error) {
+	client, err := clientFactory(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	config := client.GetConfig()
+	key := strings.Join([]string{config.ApplianceURL, config.Account, kind, search}, "\n")
+
+	cache, err := utils.DefaultCompletionCache()
+	if err != nil {
+		return nil, err
+	}
+	if ids, ok := cache.Get(key, completionCacheTTL); ok {
+		return ids, nil
+	}
+
+	resources, err := client.Resources(&conjurapi.ResourceFilter{Kind: kind, Search: search, Limit: completionLimit})
+	if err != nil {
+		if ids, ok := cache.Get(key, completionCacheMaxStale); ok {
+			return ids, nil
+		}
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		if id, ok := resource["id"].(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	// Completion still works when the results cannot be cached
+	cache.Set(key, ids)
+	return ids, nil
+}
+
+// completeVariableIDs completes the variable IDs of a flag, after the comma-separated IDs already
+// typed when the flag takes several IDs
+func completeVariableIDs(clientFactory completionClientFactoryFunc) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		typed, partial := "", toComplete
+		if index := strings.LastIndex(toComplete, ","); index >= 0 {
+			typed, partial = toComplete[:index+1], toComplete[index+1:]
+		}
+
+		ids, err := fetchCompletionIDs(cmd, clientFactory, "variable", completionSearch(partial))
+		if err != nil {
+			cobra.CompDebugln(err.Error(), true)
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		completions := []string{}
+		for _, fullID := range ids {
+			if id := variableIDFromResourceID(fullID); strings.HasPrefix(id, partial) {
+				completions = append(completions, typed+id)
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeResourceIDs completes the first argument of a command with the IDs of resources of the
+// given kinds. The kind is completed first, then IDs in the kind:id or account:kind:id form typed.
+func completeResourceIDs(clientFactory completionClientFactoryFunc, kinds []string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		parts := strings.SplitN(toComplete, ":", 3)
+		if len(parts) == 1 {
+			completions := []string{}
+			for _, kind := range kinds {
+				if strings.HasPrefix(kind+":", toComplete) {
+					completions = append(completions, kind+":")
+				}
+			}
+			return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+		}
+
+		kind, partial := parts[0], parts[1]
+		if len(parts) == 3 {
+			kind, partial = parts[1], parts[2]
+		}
+		if !containsString(kinds, kind) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		ids, err := fetchCompletionIDs(cmd, clientFactory, kind, completionSearch(partial))
+		if err != nil {
+			cobra.CompDebugln(err.Error(), true)
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		completions := []string{}
+		for _, fullID := range ids {
+			id := fullID
+			if len(parts) == 2 {
+				// Drop the account to match the kind:id form typed
+				id = strings.SplitN(fullID, ":", 2)[1]
+			}
+			if strings.HasPrefix(id, toComplete) {
+				completions = append(completions, id)
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// writeCompletionScript writes the completion script of a shell
+func writeCompletionScript(root *cobra.Command, shell string, out io.Writer, descriptions bool) error {
+	switch shell {
+	case "bash":
+		return root.GenBashCompletionV2(out, descriptions)
+	case "zsh":
+		if descriptions {
+			return root.GenZshCompletion(out)
+		}
+		return root.GenZshCompletionNoDesc(out)
+	case "fish":
+		return root.GenFishCompletion(out, descriptions)
+	case "powershell":
+		if descriptions {
+			return root.GenPowerShellCompletionWithDesc(out)
+		}
+		return root.GenPowerShellCompletion(out)
+	}
+	return fmt.Errorf("Shell must be one of %s", strings.Join(completionShells, ", "))
+}
+
+// detectShell returns the shell of the current user, from the SHELL environment variable
+func detectShell() (string, error) {
+	if shell := filepath.Base(os.Getenv("SHELL")); containsString(completionShells, shell) {
+		return shell, nil
+	}
+	if runtime.GOOS == "windows" {
+		return "powershell", nil
+	}
+	return "", errors.New("Unable to detect the shell, specify one of " + strings.Join(completionShells, ", "))
+}
+
+// userDir returns the directory set in an XDG environment variable, or a directory of the home
+// directory when the variable is not set
+func userDir(envVar string, homeRelative ...string) (string, error) {
+	if dir := os.Getenv(envVar); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append([]string{home}, homeRelative...)...), nil
+}
+
+// completionInstallPath returns where the completion script of a shell is installed, and the
+// setup the shell needs to load it, if any
+func completionInstallPath(shell string, name string) (string, string, error) {
+	switch shell {
+	case "bash":
+		dir, err := userDir("XDG_DATA_HOME", ".local", "share")
+		if err != nil {
+			return "", "", err
+		}
+		return filepath.Join(dir, "bash-completion", "completions", name), "", nil
+	case "zsh":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", err
+		}
+		return filepath.Join(home, ".zfunc", "_"+name),
+			"Add the following to ~/.zshrc, before compinit is called:\n\n  fpath=(~/.zfunc $fpath)\n  autoload -U compinit && compinit", nil
+	case "fish":
+		dir, err := userDir("XDG_CONFIG_HOME", ".config")
+		if err != nil {
+			return "", "", err
+		}
+		return filepath.Join(dir, "fish", "completions", name+".fish"), "", nil
+	case "powershell":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", "", err
+		}
+		path := filepath.Join(dir, "powershell", name+".ps1")
+		return path, "Add the following to your PowerShell profile ($PROFILE):\n\n  . " + path, nil
+	}
+	return "", "", fmt.Errorf("Shell must be one of %s", strings.Join(completionShells, ", "))
+}
+
+func newCompletionInstallCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "install [shell]",
+		Short: "Install the completion script of a shell",
+		Long: `Install the completion script of a shell where the shell loads it, and print any setup the shell needs.
+
+The shell is one of bash, zsh, fish and powershell, and defaults to the shell in the SHELL environment variable. The scripts are installed in:
+
+- bash:       $XDG_DATA_HOME/bash-completion/completions/conjur (requires the bash-completion package)
+- zsh:        ~/.zfunc/_conjur
+- fish:       $XDG_CONFIG_HOME/fish/completions/conjur.fish
+- powershell: the powershell directory of the user configuration directory
+
+Examples:
+- conjur completion install
+- conjur completion install zsh
+- conjur completion install bash --path /etc/bash_completion.d/conjur`,
+		Args:         cobra.MaximumNArgs(1),
+		ValidArgs:    completionShells,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := cmd.Flags().GetString("path")
+			if err != nil {
+				return err
+			}
+
+			noDescriptions, err := cmd.Flags().GetBool("no-descriptions")
+			if err != nil {
+				return err
+			}
+
+			var shell string
+			if len(args) > 0 {
+				shell = args[0]
+			} else if shell, err = detectShell(); err != nil {
+				return err
+			}
+
+			root := cmd.Root()
+			defaultPath, setup, err := completionInstallPath(shell, root.Name())
+			if err != nil {
+				return err
+			}
+			if path == "" {
+				path = defaultPath
+			} else {
+				setup = ""
+			}
+
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			if err := writeCompletionScript(root, shell, file, !noDescriptions); err != nil {
+				file.Close()
+				return err
+			}
+			if err := file.Close(); err != nil {
+				return err
+			}
+
+			cmd.Printf("Installed %s completion in %s\n", shell, path)
+			if setup != "" {
+				cmd.Printf("\n%s\n", setup)
+			}
+			cmd.Println("\nStart a new shell for the completion to take effect.")
+			return nil
+		},
+	}
+
+	cmd.Flags().String("path", "", "Install the script in this file instead of the default location of the shell")
+	cmd.Flags().Bool("no-descriptions", false, "Disable completion descriptions")
+
+	return cmd
+}
+
+func newCompletionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "completion",
+		Short: "Generate the autocompletion script for the specified shell",
+		Long: `Generate the autocompletion script for conjur for the specified shell, or install it with 'conjur completion install'.
+
+Besides commands and flags, variable, role and resource IDs are completed by querying Conjur with the credentials stored by 'conjur login'. Results are cached for a few minutes, and for up to an hour while Conjur is unreachable, in the conjur/completion directory of the user cache directory or in the directory set in the CONJUR_COMPLETION_CACHE_DIR environment variable.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Print --help if called without subcommand
+			cmd.Help()
+		},
+	}
+
+	for _, shell := range completionShells {
+		shell := shell
+		shellCmd := &cobra.Command{
+			Use:   shell,
+			Short: "Generate the autocompletion script for " + shell,
+			Long: fmt.Sprintf(`Generate the autocompletion script for %s and print it to stdout.
+
+To load completions in every new shell, run 'conjur completion install %s'.`, shell, shell),
+			Args: cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				noDescriptions, err := cmd.Flags().GetBool("no-descriptions")
+				if err != nil {
+					return err
+				}
+				return writeCompletionScript(cmd.Root(), shell, cmd.OutOrStdout(), !noDescriptions)
+			},
+		}
+		shellCmd.Flags().Bool("no-descriptions", false, "Disable completion descriptions")
+		cmd.AddCommand(shellCmd)
+	}
+
+	cmd.AddCommand(newCompletionInstallCmd())
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(newCompletionCmd())
+}
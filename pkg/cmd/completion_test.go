@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-cli-go/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockCompletionClient struct {
+	t         *testing.T
+	resources []map[string]interface{}
+	filters   *[]conjurapi.ResourceFilter
+	err       error
+}
+
+func (m mockCompletionClient) GetConfig() conjurapi.Config {
+	return conjurapi.Config{Account: "dev", ApplianceURL: "https://conjur"}
+}
+
+func (m mockCompletionClient) Resources(filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error) {
+	*m.filters = append(*m.filters, *filter)
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	resources := []map[string]interface{}{}
+	for _, resource := range m.resources {
+		id := resource["id"].(string)
+		if strings.Split(id, ":")[1] == filter.Kind && strings.Contains(id, filter.Search) {
+			resources = append(resources, resource)
+		}
+	}
+	return resources, nil
+}
+
+func newMockCompletionClient(t *testing.T) mockCompletionClient {
+	t.Setenv(utils.CompletionCacheDirEnvVar, t.TempDir())
+	return mockCompletionClient{
+		t: t,
+		resources: []map[string]interface{}{
+			{"id": "dev:variable:prod/db/password"},
+			{"id": "dev:variable:prod/db/user"},
+			{"id": "dev:variable:staging/db/password"},
+			{"id": "dev:user:alice"},
+			{"id": "dev:user:bob"},
+			{"id": "dev:group:ops"},
+		},
+		filters: &[]conjurapi.ResourceFilter{},
+	}
+}
+
+func mockCompletionClientFactory(client *mockCompletionClient) completionClientFactoryFunc {
+	return func(cmd *cobra.Command) (completionClient, error) {
+		return *client, nil
+	}
+}
+
+func TestCompletionSearch(t *testing.T) {
+	assert.Equal(t, "", completionSearch(""))
+	assert.Equal(t, "", completionSearch("pro"))
+	assert.Equal(t, "prod", completionSearch("prod/"))
+	assert.Equal(t, "prod/db", completionSearch("prod/db/pa"))
+}
+
+func TestCompleteVariableIDs(t *testing.T) {
+	client := newMockCompletionClient(t)
+	complete := completeVariableIDs(mockCompletionClientFactory(&client))
+
+	completions, directive := complete(&cobra.Command{}, nil, "prod/db/p")
+	assert.Equal(t, []string{"prod/db/password"}, completions)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+	assert.Equal(t, []conjurapi.ResourceFilter{{Kind: "variable", Search: "prod/db", Limit: completionLimit}}, *client.filters)
+
+	// The following characters of a segment are completed from the cache
+	completions, _ = complete(&cobra.Command{}, nil, "prod/db/")
+	assert.Equal(t, []string{"prod/db/password", "prod/db/user"}, completions)
+	assert.Len(t, *client.filters, 1)
+
+	// Variables already typed in a comma-separated list are kept
+	completions, _ = complete(&cobra.Command{}, nil, "staging/db/password,prod/db/u")
+	assert.Equal(t, []string{"staging/db/password,prod/db/user"}, completions)
+
+	// Cached results are used while the server is unreachable
+	client.err = errors.New("connection refused")
+	completions, _ = complete(&cobra.Command{}, nil, "prod/db/pass")
+	assert.Equal(t, []string{"prod/db/password"}, completions)
+
+	completions, _ = complete(&cobra.Command{}, nil, "staging/")
+	assert.Empty(t, completions)
+}
+
+func TestCompleteResourceIDs(t *testing.T) {
+	client := newMockCompletionClient(t)
+	complete := completeResourceIDs(mockCompletionClientFactory(&client), roleKinds)
+
+	completions, directive := complete(&cobra.Command{}, nil, "")
+	assert.Equal(t, []string{"user:", "host:", "group:", "layer:", "policy:"}, completions)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveNoSpace, directive)
+
+	completions, _ = complete(&cobra.Command{}, nil, "u")
+	assert.Equal(t, []string{"user:"}, completions)
+
+	completions, _ = complete(&cobra.Command{}, nil, "user:a")
+	assert.Equal(t, []string{"user:alice"}, completions)
+
+	completions, _ = complete(&cobra.Command{}, nil, "dev:user:")
+	assert.Equal(t, []string{"dev:user:alice", "dev:user:bob"}, completions)
+
+	completions, _ = complete(&cobra.Command{}, nil, "variable:prod")
+	assert.Empty(t, completions)
+
+	completions, _ = complete(&cobra.Command{}, []string{"user:alice"}, "")
+	assert.Empty(t, completions)
+}
+
+func TestCompletionCmd(t *testing.T) {
+	t.Run("prints the script of a shell", func(t *testing.T) {
+		stdout, _, err := executeCommandForTest(t, newCompletionCmd(), "completion", "bash")
+		assert.NoError(t, err)
+		assert.Contains(t, stdout, "bash completion V2 for conjur")
+
+		stdout, _, err = executeCommandForTest(t, newCompletionCmd(), "completion", "fish", "--no-descriptions")
+		assert.NoError(t, err)
+		assert.Contains(t, stdout, "fish completion for conjur")
+	})
+
+	t.Run("installs the script of a shell", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		t.Setenv("XDG_DATA_HOME", "")
+
+		stdout, _, err := executeCommandForTest(t, newCompletionCmd(), "completion", "install", "bash")
+		assert.NoError(t, err)
+		path := filepath.Join(home, ".local", "share", "bash-completion", "completions", "conjur")
+		assert.Contains(t, stdout, "Installed bash completion in "+path+"\n")
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), "bash completion V2 for conjur")
+
+		t.Setenv("SHELL", "/usr/bin/zsh")
+		stdout, _, err = executeCommandForTest(t, newCompletionCmd(), "completion", "install")
+		assert.NoError(t, err)
+		assert.Contains(t, stdout, "Installed zsh completion in "+filepath.Join(home, ".zfunc", "_conjur")+"\n")
+		assert.Contains(t, stdout, "fpath=(~/.zfunc $fpath)")
+
+		path = filepath.Join(t.TempDir(), "conjur.fish")
+		stdout, _, err = executeCommandForTest(t, newCompletionCmd(), "completion", "install", "fish", "--path", path)
+		assert.NoError(t, err)
+		assert.Contains(t, stdout, "Installed fish completion in "+path+"\n")
+		assert.FileExists(t, path)
+	})
+
+	t.Run("rejects unknown shells", func(t *testing.T) {
+		_, _, err := executeCommandForTest(t, newCompletionCmd(), "completion", "install", "tcsh")
+		assert.EqualError(t, err, "Shell must be one of bash, zsh, fish, powershell")
+	})
+}
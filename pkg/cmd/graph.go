@@ -0,0 +1,434 @@
+package cmd
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-cli-go/pkg/clients"
+	"github.com/cyberark/conjur-cli-go/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+type graphClient interface {
+	Resource(resourceID string) (map[string]interface{}, error)
+	Resources(filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error)
+	PermittedRoles(resourceID, privilege string) ([]string, error)
+	Role(roleID string) (map[string]interface{}, error)
+	RoleMembers(roleID string) ([]map[string]interface{}, error)
+	RoleMembershipsAll(roleID string) ([]string, error)
+}
+
+type graphClientFactoryFunc func(*cobra.Command) (graphClient, error)
+
+func graphClientFactory(cmd *cobra.Command) (graphClient, error) {
+	return clients.AuthenticatedConjurClientForCommand(cmd)
+}
+
+// graphGrant is an edge between a role and the role or resource it is granted
+type graphGrant struct {
+	target string
+	label  string
+}
+
+// graphBuilder collects the nodes and edges found while walking the roles and resources from a
+// root node. Each node remembers the nodes it was reached from, so that the graph can be pruned
+// to the paths leading to the kinds of nodes requested.
+type graphBuilder struct {
+	maxDepth int
+	nodes    map[string]*utils.GraphNode
+	parents  map[string][]string
+	edges    map[[2]string][]string
+}
+
+func newGraphBuilder(maxDepth int) *graphBuilder {
+	return &graphBuilder{
+		maxDepth: maxDepth,
+		nodes:    map[string]*utils.GraphNode{},
+		parents:  map[string][]string{},
+		edges:    map[[2]string][]string{},
+	}
+}
+
+func (b *graphBuilder) withinDepth(depth int) bool {
+	return b.maxDepth == 0 || depth <= b.maxDepth
+}
+
+// addNode records a node reached from parent, and returns whether it was seen for the first time
+func (b *graphBuilder) addNode(id string, depth int, parent string) bool {
+	if parent != "" {
+		b.parents[id] = append(b.parents[id], parent)
+	}
+	if _, ok := b.nodes[id]; ok {
+		return false
+	}
+	b.nodes[id] = &utils.GraphNode{ID: id, Kind: utils.KindFromID(id), Depth: depth}
+	return true
+}
+
+func (b *graphBuilder) addEdge(from string, to string, label string) {
+	key := [2]string{from, to}
+	for _, existing := range b.edges[key] {
+		if existing == label {
+			return
+		}
+	}
+	b.edges[key] = append(b.edges[key], label)
+}
+
+// graph returns the nodes on a path from the root to a node of one of the kinds, or every node
+// when no kind is given
+func (b *graphBuilder) graph(root string, kinds []string) utils.Graph {
+	keep := map[string]bool{}
+	var mark func(id string)
+	mark = func(id string) {
+		if keep[id] {
+			return
+		}
+		keep[id] = true
+		for _, parent := range b.parents[id] {
+			mark(parent)
+		}
+	}
+
+	mark(root)
+	for id, node := range b.nodes {
+		if len(kinds) == 0 || containsString(kinds, node.Kind) {
+			mark(id)
+		}
+	}
+
+	g := utils.Graph{Root: root, Nodes: []utils.GraphNode{}, Edges: []utils.GraphEdge{}}
+	for id, node := range b.nodes {
+		if keep[id] {
+			g.Nodes = append(g.Nodes, *node)
+		}
+	}
+	for key, labels := range b.edges {
+		if keep[key[0]] && keep[key[1]] {
+			sort.Strings(labels)
+			g.Edges = append(g.Edges, utils.GraphEdge{From: key[0], To: key[1], Label: strings.Join(labels, ", ")})
+		}
+	}
+	g.Sort()
+	return g
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceGrants returns the roles holding privileges on a resource: its owner and the roles
+// listed in its permissions, limited to the given privileges when there are any
+func resourceGrants(resource map[string]interface{}, privileges []string) map[string][]string {
+	grants := map[string][]string{}
+	if owner, ok := resource["owner"].(string); ok && owner != "" {
+		grants[owner] = append(grants[owner], "owner")
+	}
+
+	permissions, _ := resource["permissions"].([]interface{})
+	for _, p := range permissions {
+		permission, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		privilege, _ := permission["privilege"].(string)
+		role, _ := permission["role"].(string)
+		if role == "" || (len(privileges) > 0 && !containsString(privileges, privilege)) {
+			continue
+		}
+		grants[role] = append(grants[role], privilege)
+	}
+	return grants
+}
+
+// membershipLabel describes how a member belongs to a role
+func membershipLabel(member map[string]interface{}) string {
+	if ownership, _ := member["ownership"].(bool); ownership {
+		return "owner"
+	}
+	if admin, _ := member["admin_option"].(bool); admin {
+		return "member (admin)"
+	}
+	return "member"
+}
+
+// buildResourceGraph walks from a resource to the roles holding privileges on it, then to their
+// members, answering "who can reach this resource and how"
+func buildResourceGraph(client graphClient, resourceID string, maxDepth int, kinds []string, privileges []string) (utils.Graph, error) {
+	resource, err := client.Resource(resourceID)
+	if err != nil {
+		return utils.Graph{}, err
+	}
+	root, _ := resource["id"].(string)
+	if root == "" {
+		root = resourceID
+	}
+
+	b := newGraphBuilder(maxDepth)
+	b.addNode(root, 0, "")
+
+	queue := []string{}
+	if b.withinDepth(1) {
+		grants := resourceGrants(resource, privileges)
+		roles := make([]string, 0, len(grants))
+		for role := range grants {
+			roles = append(roles, role)
+		}
+		sort.Strings(roles)
+
+		for _, role := range roles {
+			for _, label := range grants[role] {
+				b.addEdge(role, root, label)
+			}
+			if b.addNode(role, 1, root) {
+				queue = append(queue, role)
+			}
+		}
+	}
+
+	for len(queue) > 0 {
+		role := queue[0]
+		queue = queue[1:]
+
+		depth := b.nodes[role].Depth + 1
+		if !b.withinDepth(depth) {
+			continue
+		}
+
+		members, err := client.RoleMembers(role)
+		if err != nil {
+			return utils.Graph{}, err
+		}
+		for _, m := range members {
+			member, _ := m["member"].(string)
+			if member == "" {
+				continue
+			}
+			b.addEdge(member, role, membershipLabel(m))
+			if b.addNode(member, depth, role) {
+				queue = append(queue, member)
+			}
+		}
+	}
+
+	// Record the privileges each role effectively holds, through any of its memberships
+	if len(privileges) == 0 {
+		seen := map[string]bool{}
+		for _, labels := range b.edges {
+			for _, label := range labels {
+				if !seen[label] && label != "owner" && !strings.HasPrefix(label, "member") {
+					seen[label] = true
+					privileges = append(privileges, label)
+				}
+			}
+		}
+		sort.Strings(privileges)
+	}
+	for _, privilege := range privileges {
+		roles, err := client.PermittedRoles(root, privilege)
+		if err != nil {
+			return utils.Graph{}, err
+		}
+		for _, role := range roles {
+			if node, ok := b.nodes[role]; ok {
+				node.Privileges = append(node.Privileges, privilege)
+			}
+		}
+	}
+
+	return b.graph(root, kinds), nil
+}
+
+// buildRoleGraph walks from a role to the roles it is a member of, then to the resources they
+// hold privileges on, answering "what can this role reach and how"
+func buildRoleGraph(client graphClient, roleID string, maxDepth int, kinds []string, privileges []string) (utils.Graph, error) {
+	role, err := client.Role(roleID)
+	if err != nil {
+		return utils.Graph{}, err
+	}
+	root, _ := role["id"].(string)
+	if root == "" {
+		root = roleID
+	}
+
+	memberships, err := client.RoleMembershipsAll(root)
+	if err != nil {
+		return utils.Graph{}, err
+	}
+	reachable := map[string]bool{root: true}
+	for _, membership := range memberships {
+		reachable[membership] = true
+	}
+
+	// RoleMembershipsAll is transitive, so the direct memberships between the reachable roles
+	// are found from the members of each of them
+	direct := map[string][]graphGrant{}
+	for _, membership := range memberships {
+		if membership == root {
+			continue
+		}
+		members, err := client.RoleMembers(membership)
+		if err != nil {
+			return utils.Graph{}, err
+		}
+		for _, m := range members {
+			member, _ := m["member"].(string)
+			if reachable[member] {
+				direct[member] = append(direct[member], graphGrant{target: membership, label: membershipLabel(m)})
+			}
+		}
+	}
+
+	b := newGraphBuilder(maxDepth)
+	b.addNode(root, 0, "")
+	queue := []string{root}
+	for len(queue) > 0 {
+		member := queue[0]
+		queue = queue[1:]
+
+		depth := b.nodes[member].Depth + 1
+		if !b.withinDepth(depth) {
+			continue
+		}
+		for _, grant := range direct[member] {
+			b.addEdge(member, grant.target, grant.label)
+			if b.addNode(grant.target, depth, member) {
+				queue = append(queue, grant.target)
+			}
+		}
+	}
+
+	resourceKinds := kinds
+	if len(resourceKinds) == 0 {
+		resourceKinds = []string{""}
+	}
+	for _, kind := range resourceKinds {
+		filter := conjurapi.ResourceFilter{Kind: kind, Role: root}
+		err := forEachResourcePage(client, filter, 1000, func(page []map[string]interface{}) error {
+			for _, resource := range page {
+				resourceID, _ := resource["id"].(string)
+				if resourceID == "" || resourceID == root {
+					continue
+				}
+				for grantee, labels := range resourceGrants(resource, privileges) {
+					node, ok := b.nodes[grantee]
+					if !ok || !b.withinDepth(node.Depth+1) {
+						continue
+					}
+					for _, label := range labels {
+						b.addEdge(grantee, resourceID, label)
+					}
+					b.addNode(resourceID, node.Depth+1, grantee)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return utils.Graph{}, err
+		}
+	}
+
+	return b.graph(root, kinds), nil
+}
+
+func newGraphCommand(clientFactory graphClientFactoryFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Export the roles and resources related to a role or resource as a graph",
+		Long: `Export the roles and resources related to a role or resource as a graph.
+
+With --resource, the graph shows who can reach the resource and how: the roles holding privileges on it, then their members, recursively. Each role is annotated with the privileges it effectively holds on the resource in the JSON format.
+
+With --role, the graph shows what the role can reach and how: the roles it is a member of, then the resources they hold privileges on.
+
+Edges are labeled with the privileges, "owner", "member" or "member (admin)". --depth limits the number of edges from the starting node, --kind keeps only the paths leading to the given kinds and --privilege keeps only the given privileges.
+
+Examples:
+- conjur graph --resource variable:prod/db/password --privilege execute | dot -Tsvg > access.svg
+- conjur graph --role host:apps/billing --kind variable --format mermaid
+- conjur graph --resource variable:prod/db/password --depth 2 --format json`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			roleID, err := cmd.Flags().GetString("role")
+			if err != nil {
+				return err
+			}
+
+			resourceID, err := cmd.Flags().GetString("resource")
+			if err != nil {
+				return err
+			}
+
+			format, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return err
+			}
+			if !containsString(utils.GraphFormats, format) {
+				return errors.New("Format must be one of " + strings.Join(utils.GraphFormats, ", "))
+			}
+
+			depth, err := cmd.Flags().GetInt("depth")
+			if err != nil {
+				return err
+			}
+			if depth < 0 {
+				return errors.New("Depth must be greater than or equal to 0")
+			}
+
+			kinds, err := cmd.Flags().GetStringSlice("kind")
+			if err != nil {
+				return err
+			}
+
+			privileges, err := cmd.Flags().GetStringSlice("privilege")
+			if err != nil {
+				return err
+			}
+
+			client, err := clientFactory(cmd)
+			if err != nil {
+				return err
+			}
+
+			var g utils.Graph
+			if resourceID != "" {
+				g, err = buildResourceGraph(client, resourceID, depth, kinds, privileges)
+			} else {
+				g, err = buildRoleGraph(client, roleID, depth, kinds, privileges)
+			}
+			if err != nil {
+				return err
+			}
+
+			out, err := utils.FormatGraph(g, format)
+			if err != nil {
+				return err
+			}
+			cmd.Println(out)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("role", "", "Fully qualified ID of the role to start from")
+	cmd.Flags().String("resource", "", "Fully qualified ID of the resource to start from")
+	cmd.Flags().StringP("format", "f", utils.GraphFormatDOT, "Output format: "+strings.Join(utils.GraphFormats, ", "))
+	cmd.Flags().Int("depth", 0, "Maximum number of edges from the starting node (0 for no limit)")
+	cmd.Flags().StringSliceP("kind", "k", nil, "Keep only the paths leading to roles or resources of these kinds")
+	cmd.Flags().StringSliceP("privilege", "p", nil, "Keep only these privileges")
+	cmd.MarkFlagsMutuallyExclusive("role", "resource")
+	cmd.MarkFlagsOneRequired("role", "resource")
+
+	return cmd
+}
+
+func init() {
+	graphCmd := newGraphCommand(graphClientFactory)
+	rootCmd.AddCommand(graphCmd)
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-cli-go/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockGraphClient struct {
+	t *testing.T
+}
+
+var mockGraphVariable = map[string]interface{}{
+	"id":    "dev:variable:db/pass",
+	"owner": "dev:policy:db",
+	"permissions": []interface{}{
+		map[string]interface{}{"privilege": "execute", "role": "dev:group:secrets-users"},
+		map[string]interface{}{"privilege": "read", "role": "dev:group:secrets-users"},
+		map[string]interface{}{"privilege": "read", "role": "dev:host:auditor"},
+	},
+}
+
+var mockGraphMembers = map[string][]map[string]interface{}{
+	"dev:group:secrets-users": {
+		{"member": "dev:host:app", "admin_option": false, "ownership": false},
+		{"member": "dev:group:admins", "admin_option": true, "ownership": false},
+	},
+	"dev:group:admins": {
+		{"member": "dev:user:alice", "admin_option": false, "ownership": false},
+	},
+	"dev:policy:db": {
+		{"member": "dev:user:admin", "admin_option": true, "ownership": true},
+	},
+}
+
+func (m mockGraphClient) Resource(resourceID string) (map[string]interface{}, error) {
+	assert.Equal(m.t, "variable:db/pass", resourceID)
+	return mockGraphVariable, nil
+}
+
+func (m mockGraphClient) Resources(filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error) {
+	assert.Equal(m.t, "dev:user:alice", filter.Role)
+	if filter.Offset > 0 {
+		return []map[string]interface{}{}, nil
+	}
+	resources := []map[string]interface{}{
+		mockGraphVariable,
+		{"id": "dev:group:admins", "owner": "dev:user:admin"},
+		{"id": "dev:webservice:api", "owner": "dev:group:admins"},
+	}
+	if filter.Kind == "" {
+		return resources, nil
+	}
+	filtered := []map[string]interface{}{}
+	for _, resource := range resources {
+		if utils.KindFromID(resource["id"].(string)) == filter.Kind {
+			filtered = append(filtered, resource)
+		}
+	}
+	return filtered, nil
+}
+
+func (m mockGraphClient) PermittedRoles(resourceID, privilege string) ([]string, error) {
+	roles := []string{"dev:group:secrets-users", "dev:host:app", "dev:group:admins", "dev:user:alice", "dev:policy:db", "dev:user:admin"}
+	if privilege == "read" {
+		roles = append(roles, "dev:host:auditor")
+	}
+	return roles, nil
+}
+
+func (m mockGraphClient) Role(roleID string) (map[string]interface{}, error) {
+	assert.Equal(m.t, "user:alice", roleID)
+	return map[string]interface{}{"id": "dev:user:alice"}, nil
+}
+
+func (m mockGraphClient) RoleMembers(roleID string) ([]map[string]interface{}, error) {
+	return mockGraphMembers[roleID], nil
+}
+
+func (m mockGraphClient) RoleMembershipsAll(roleID string) ([]string, error) {
+	return []string{"dev:user:alice", "dev:group:admins", "dev:group:secrets-users"}, nil
+}
+
+func newGraphTestCmd(t *testing.T) *cobra.Command {
+	return newGraphCommand(func(cmd *cobra.Command) (graphClient, error) {
+		return mockGraphClient{t: t}, nil
+	})
+}
+
+func TestGraphCmd(t *testing.T) {
+	t.Run("resource graph as dot", func(t *testing.T) {
+		stdout, _, err := executeCommandForTest(t, newGraphTestCmd(t), "graph", "--resource", "variable:db/pass", "--depth", "1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, `digraph conjur {
+  rankdir=LR;
+  "dev:variable:db/pass" [shape=box, style=bold];
+  "dev:group:secrets-users" [shape=ellipse];
+  "dev:host:auditor" [shape=ellipse];
+  "dev:policy:db" [shape=ellipse];
+  "dev:group:secrets-users" -> "dev:variable:db/pass" [label="execute, read"];
+  "dev:host:auditor" -> "dev:variable:db/pass" [label="read"];
+  "dev:policy:db" -> "dev:variable:db/pass" [label="owner"];
+}
+`, stdout)
+	})
+
+	t.Run("resource graph as json with privileges", func(t *testing.T) {
+		stdout, _, err := executeCommandForTest(t, newGraphTestCmd(t), "graph", "--resource", "variable:db/pass", "--kind", "user", "--privilege", "execute", "--format", "json")
+		assert.NoError(t, err)
+
+		var g utils.Graph
+		assert.NoError(t, json.Unmarshal([]byte(stdout), &g))
+		assert.Equal(t, "dev:variable:db/pass", g.Root)
+		assert.Equal(t, []utils.GraphNode{
+			{ID: "dev:variable:db/pass", Kind: "variable", Depth: 0},
+			{ID: "dev:group:secrets-users", Kind: "group", Depth: 1, Privileges: []string{"execute"}},
+			{ID: "dev:policy:db", Kind: "policy", Depth: 1, Privileges: []string{"execute"}},
+			{ID: "dev:group:admins", Kind: "group", Depth: 2, Privileges: []string{"execute"}},
+			{ID: "dev:user:admin", Kind: "user", Depth: 2, Privileges: []string{"execute"}},
+			{ID: "dev:user:alice", Kind: "user", Depth: 3, Privileges: []string{"execute"}},
+		}, g.Nodes)
+		assert.Equal(t, []utils.GraphEdge{
+			{From: "dev:group:admins", To: "dev:group:secrets-users", Label: "member (admin)"},
+			{From: "dev:group:secrets-users", To: "dev:variable:db/pass", Label: "execute"},
+			{From: "dev:policy:db", To: "dev:variable:db/pass", Label: "owner"},
+			{From: "dev:user:admin", To: "dev:policy:db", Label: "owner"},
+			{From: "dev:user:alice", To: "dev:group:admins", Label: "member"},
+		}, g.Edges)
+	})
+
+	t.Run("role graph as mermaid", func(t *testing.T) {
+		stdout, _, err := executeCommandForTest(t, newGraphTestCmd(t), "graph", "--role", "user:alice", "--kind", "variable", "--format", "mermaid")
+
+		assert.NoError(t, err)
+		assert.Equal(t, `flowchart LR
+  n0(["dev:user:alice"])
+  n1(["dev:group:admins"])
+  n2(["dev:group:secrets-users"])
+  n3["dev:variable:db/pass"]
+  n1 -->|member (admin)| n2
+  n2 -->|execute, read| n3
+  n0 -->|member| n1
+`, stdout)
+	})
+
+	t.Run("role graph with depth limit", func(t *testing.T) {
+		stdout, _, err := executeCommandForTest(t, newGraphTestCmd(t), "graph", "--role", "user:alice", "--depth", "2", "--format", "json")
+		assert.NoError(t, err)
+
+		var g utils.Graph
+		assert.NoError(t, json.Unmarshal([]byte(stdout), &g))
+		ids := []string{}
+		for _, node := range g.Nodes {
+			ids = append(ids, node.ID)
+		}
+		assert.Equal(t, []string{"dev:user:alice", "dev:group:admins", "dev:group:secrets-users", "dev:webservice:api"}, ids)
+	})
+
+	t.Run("requires a role or a resource", func(t *testing.T) {
+		_, stderr, _ := executeCommandForTest(t, newGraphTestCmd(t), "graph")
+		assert.Contains(t, stderr, "at least one of the flags in the group [role resource] is required")
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		_, stderr, _ := executeCommandForTest(t, newGraphTestCmd(t), "graph", "--role", "user:alice", "--format", "svg")
+		assert.Contains(t, stderr, "Error: Format must be one of dot, mermaid, json\n")
+	})
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net/url"
@@ -13,14 +14,17 @@ import (
 	"github.com/cyberark/conjur-cli-go/pkg/utils"
 
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 type initCmdFuncs struct {
 	JWTAuthenticate func(conjurClient clients.ConjurClient) error
+	ProfileStore    func() (clients.ProfileStore, error)
 }
 
 var defaultInitCmdFuncs = initCmdFuncs{
 	JWTAuthenticate: clients.JWTAuthenticate,
+	ProfileStore:    clients.DefaultProfileStore,
 }
 
 type initCmdFlagValues struct {
@@ -30,9 +34,17 @@ type initCmdFlagValues struct {
 	serviceID          string
 	conjurrcFilePath   string
 	certFilePath       string
+	netrcFilePath      string
 	caCert             string
 	jwtFilePath        string
 	jwtHostID          string
+	clientCertFile     string
+	clientKeyFile      string
+	certHostID         string
+	hostID             string
+	azureClientID      string
+	metadataURL        string
+	profile            string
 	forceFileOverwrite bool
 	insecure           bool
 	selfSigned         bool
@@ -76,6 +88,30 @@ func getInitCmdFlagValues(cmd *cobra.Command) (initCmdFlagValues, error) {
 	if err != nil {
 		return initCmdFlagValues{}, err
 	}
+	clientCertFile, err := cmd.Flags().GetString("client-cert")
+	if err != nil {
+		return initCmdFlagValues{}, err
+	}
+	clientKeyFile, err := cmd.Flags().GetString("client-key")
+	if err != nil {
+		return initCmdFlagValues{}, err
+	}
+	certHostID, err := cmd.Flags().GetString("cert-host-id")
+	if err != nil {
+		return initCmdFlagValues{}, err
+	}
+	hostID, err := cmd.Flags().GetString("host-id")
+	if err != nil {
+		return initCmdFlagValues{}, err
+	}
+	azureClientID, err := cmd.Flags().GetString("azure-client-id")
+	if err != nil {
+		return initCmdFlagValues{}, err
+	}
+	metadataURL, err := cmd.Flags().GetString("metadata-url")
+	if err != nil {
+		return initCmdFlagValues{}, err
+	}
 	selfSigned, err := cmd.Flags().GetBool("self-signed")
 	if err != nil {
 		return initCmdFlagValues{}, err
@@ -92,6 +128,10 @@ func getInitCmdFlagValues(cmd *cobra.Command) (initCmdFlagValues, error) {
 	if err != nil {
 		return initCmdFlagValues{}, err
 	}
+	profile, err := cmd.Flags().GetString("profile")
+	if err != nil {
+		return initCmdFlagValues{}, err
+	}
 
 	return initCmdFlagValues{
 		account:            account,
@@ -103,6 +143,13 @@ func getInitCmdFlagValues(cmd *cobra.Command) (initCmdFlagValues, error) {
 		caCert:             caCert,
 		jwtFilePath:        jwtFilePath,
 		jwtHostID:          jwtHostID,
+		clientCertFile:     clientCertFile,
+		clientKeyFile:      clientKeyFile,
+		certHostID:         certHostID,
+		hostID:             hostID,
+		azureClientID:      azureClientID,
+		metadataURL:        metadataURL,
+		profile:            profile,
 		selfSigned:         selfSigned,
 		insecure:           insecure,
 		forceFileOverwrite: forceFileOverwrite,
@@ -117,6 +164,23 @@ func validateCmdFlags(cmdFlagVals initCmdFlagValues, cmd *cobra.Command) error {
 	if (cmdFlagVals.insecure || cmdFlagVals.selfSigned) && cmdFlagVals.caCert != "" {
 		return fmt.Errorf("Cannot specify --ca-cert when using --insecure or --self-signed")
 	}
+	if (cmdFlagVals.clientCertFile == "") != (cmdFlagVals.clientKeyFile == "") {
+		return fmt.Errorf("Must specify both --client-cert and --client-key")
+	}
+	if cmdFlagVals.hostID != "" && cmdFlagVals.jwtHostID != "" {
+		return fmt.Errorf("Cannot specify both --host-id and --jwt-host-id")
+	}
+	if cmdFlagVals.metadataURL != "" {
+		if !clients.IsCloudAuthnType(cmdFlagVals.authnType) {
+			return fmt.Errorf("Cannot specify --metadata-url unless the authentication type is iam, azure or gcp")
+		}
+		if err := clients.ValidateMetadataURL(cmdFlagVals.metadataURL); err != nil {
+			return err
+		}
+	}
+	if cmdFlagVals.azureClientID != "" && cmdFlagVals.authnType != "azure" {
+		return fmt.Errorf("Cannot specify --azure-client-id unless the authentication type is azure")
+	}
 
 	if cmdFlagVals.selfSigned {
 		cmd.PrintErrln("Warning: Using self-signed certificates is not recommended and could lead to exposure of sensitive data")
@@ -141,6 +205,13 @@ func runInitCommand(cmd *cobra.Command, funcs initCmdFuncs) error {
 		return err
 	}
 
+	if cmdFlagVals.profile != "" {
+		err = useProfilePaths(cmd, &cmdFlagVals, funcs)
+		if err != nil {
+			return err
+		}
+	}
+
 	account, applianceURL, err := prompts.MaybeAskForConnectionDetails(
 		cmdFlagVals.account,
 		cmdFlagVals.applianceURL,
@@ -157,6 +228,20 @@ func runInitCommand(cmd *cobra.Command, funcs initCmdFuncs) error {
 		ServiceID:    cmdFlagVals.serviceID,
 		JWTFilePath:  cmdFlagVals.jwtFilePath,
 		JWTHostID:    cmdFlagVals.jwtHostID,
+		CertHostID:   cmdFlagVals.certHostID,
+	}
+
+	// The cloud authenticators identify the host with the same setting as authn-jwt
+	if cmdFlagVals.hostID != "" {
+		config.JWTHostID = cmdFlagVals.hostID
+	}
+	config.AzureClientID = cmdFlagVals.azureClientID
+
+	if cmdFlagVals.clientCertFile != "" {
+		err = setClientCert(&config, cmdFlagVals.clientCertFile, cmdFlagVals.clientKeyFile)
+		if err != nil {
+			return err
+		}
 	}
 
 	// If using JWT auth, we need to ensure that the JWT file exists and
@@ -172,6 +257,14 @@ func runInitCommand(cmd *cobra.Command, funcs initCmdFuncs) error {
 		}
 	}
 
+	// Keep the credentials of each profile apart in the native keychain, and in a .netrc file of
+	// its own when they are stored in a file
+	if cmdFlagVals.profile != "" {
+		config.KeychainNamespace = cmdFlagVals.profile
+		config.SetKeychainNamespaceResolved(true)
+		config.NetRCPath = cmdFlagVals.netrcFilePath
+	}
+
 	// If the user has specified the --force-netrc flag, don't try to use the native keychain
 	if cmdFlagVals.forceNetrc {
 		config.CredentialStorage = conjurapi.CredentialStorageFile
@@ -211,6 +304,53 @@ func runInitCommand(cmd *cobra.Command, funcs initCmdFuncs) error {
 	return nil
 }
 
+// setClientCert sets the client certificate presented to Conjur on the config, after checking that
+// the certificate and key can be loaded. The paths are made absolute as the configuration is used
+// from any directory.
+func setClientCert(config *conjurapi.Config, certFile string, keyFile string) error {
+	certPath, err := filepath.Abs(certFile)
+	if err != nil {
+		return err
+	}
+	keyPath, err := filepath.Abs(keyFile)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tls.LoadX509KeyPair(certPath, keyPath); err != nil {
+		return fmt.Errorf("Unable to load the client certificate: %s", err)
+	}
+
+	config.ClientCertFile = certPath
+	config.ClientCertKeyFile = keyPath
+	return nil
+}
+
+// useProfilePaths writes the configuration and certificate to the profile store when initializing
+// a profile, unless the user explicitly provided other paths
+func useProfilePaths(cmd *cobra.Command, cmdFlagVals *initCmdFlagValues, funcs initCmdFuncs) error {
+	if err := clients.ValidateProfileName(cmdFlagVals.profile); err != nil {
+		return err
+	}
+
+	store, err := funcs.ProfileStore()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(store.Dir, 0700); err != nil {
+		return err
+	}
+
+	if !cmd.Flags().Changed("file") {
+		cmdFlagVals.conjurrcFilePath = store.Path(cmdFlagVals.profile)
+	}
+	if !cmd.Flags().Changed("cert-file") {
+		cmdFlagVals.certFilePath = filepath.Join(store.Dir, cmdFlagVals.profile+".pem")
+	}
+	cmdFlagVals.netrcFilePath = store.NetRCPath(cmdFlagVals.profile)
+	return nil
+}
+
 func fetchCertIfNeeded(config *conjurapi.Config, cmdFlagVals initCmdFlagValues) error {
 	// If user has specified a cert file, don't fetch it from the server
 	if config.SSLCertPath != "" {
@@ -263,6 +403,15 @@ func writeConjurrc(config conjurapi.Config, cmdFlagVals initCmdFlagValues) error
 	filePath := cmdFlagVals.conjurrcFilePath
 	fileContents := config.Conjurrc()
 
+	// Settings the Conjur API doesn't know about are appended to the same file
+	if cmdFlagVals.metadataURL != "" {
+		cloudConfig, err := yaml.Marshal(clients.CloudAuthnConfig{MetadataURL: cmdFlagVals.metadataURL})
+		if err != nil {
+			return err
+		}
+		fileContents = append(fileContents, cloudConfig...)
+	}
+
 	return writeFile(filePath, fileContents, cmdFlagVals.forceFileOverwrite)
 }
 
@@ -283,7 +432,13 @@ func newInitCommand(funcs initCmdFuncs) *cobra.Command {
 		Short: "Initialize the Conjur CLI with a Conjur server",
 		Long: `Initialize the Conjur CLI with a Conjur server.
 
-The init command creates a configuration file (.conjurrc) that contains the details for connecting to Conjur. This file is located under the user's root directory.`,
+The init command creates a configuration file (.conjurrc) that contains the details for connecting to Conjur. This file is located under the user's root directory.
+
+The iam, azure and gcp authentication types authenticate with the identity of the workload on its cloud, as the host given with --host-id. The identity is obtained from the metadata service of the cloud, or from the endpoint given with --metadata-url, such as a local stand-in or a proxy. The endpoint can also be set with the CONJUR_AUTHN_METADATA_URL environment variable.
+
+With --client-cert and --client-key, the CLI presents the client certificate to the Conjur server when asked to, for servers or proxies requiring mutual TLS. With --authn-type cert, the certificate is also used to authenticate with the authn-cert authenticator, as the host it was issued to or as --cert-host-id.
+
+When the global --profile flag is provided, the configuration is written to the named connection profile instead. See 'conjur profile --help' for details.`,
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runInitCommand(cmd, funcs)
@@ -306,6 +461,12 @@ The init command creates a configuration file (.conjurrc) that contains the deta
 	cmd.Flags().String("service-id", "", "Service ID if using alternative authentication type")
 	cmd.Flags().String("jwt-file", "", "Path to the JWT file if using authn-jwt")
 	cmd.Flags().String("jwt-host-id", "", "Host ID for authn-jwt (not required if JWT contains host ID)")
+	cmd.Flags().String("client-cert", "", "Path to the PEM client certificate presented to Conjur for mutual TLS")
+	cmd.Flags().String("client-key", "", "Path to the PEM private key of the client certificate")
+	cmd.Flags().String("cert-host-id", "", "Host ID for authn-cert (not required if the certificate identifies the host)")
+	cmd.Flags().String("host-id", "", "Host ID for authn-iam, authn-azure and authn-gcp")
+	cmd.Flags().String("azure-client-id", "", "Client ID of the user-assigned managed identity if using authn-azure")
+	cmd.Flags().String("metadata-url", "", "Endpoint to obtain the cloud identity from if using authn-iam, authn-azure or authn-gcp (defaults to the cloud's metadata service)")
 	cmd.Flags().BoolP("self-signed", "s", false, "Allow self-signed certificates (insecure)")
 	cmd.Flags().BoolP("insecure", "i", false, "Allow non-HTTPS connections (insecure)")
 	cmd.Flags().Bool("force-netrc", false, "Use a file-based credential storage rather than OS-native keystore (for compatibility with Summon)")
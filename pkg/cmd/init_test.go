@@ -1,14 +1,23 @@
 package cmd
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/cyberark/conjur-api-go/conjurapi"
 	"github.com/cyberark/conjur-cli-go/pkg/clients"
 	"github.com/stretchr/testify/assert"
 )
@@ -339,6 +348,46 @@ appliance_url: http://host
 			assertFetchCertFailed(t, conjurrcInTmpDir)
 		},
 	},
+	{
+		name: "fails if --client-cert is specified without --client-key",
+		args: []string{"init", "-u=https://example.com", "-a=test-account", "--client-cert=client.pem"},
+		assert: func(t *testing.T, conjurrcInTmpDir string, stdout string) {
+			assert.Contains(t, stdout, "Must specify both --client-cert and --client-key")
+			assertFetchCertFailed(t, conjurrcInTmpDir)
+		},
+	},
+	{
+		name: "fails if the client certificate cannot be loaded",
+		args: []string{"init", "-u=https://example.com", "-a=test-account", "--client-cert=missing.pem", "--client-key=missing-key.pem"},
+		assert: func(t *testing.T, conjurrcInTmpDir string, stdout string) {
+			assert.Contains(t, stdout, "Unable to load the client certificate")
+			assertFetchCertFailed(t, conjurrcInTmpDir)
+		},
+	},
+	{
+		name: "fails if --metadata-url is specified for other authentication types",
+		args: []string{"init", "-u=https://example.com", "-a=test-account", "-t=jwt", "--metadata-url=http://localhost:8080"},
+		assert: func(t *testing.T, conjurrcInTmpDir string, stdout string) {
+			assert.Contains(t, stdout, "Cannot specify --metadata-url unless the authentication type is iam, azure or gcp")
+			assertFetchCertFailed(t, conjurrcInTmpDir)
+		},
+	},
+	{
+		name: "fails for an invalid --metadata-url",
+		args: []string{"init", "-u=https://example.com", "-a=test-account", "-t=gcp", "--metadata-url=metadata.internal"},
+		assert: func(t *testing.T, conjurrcInTmpDir string, stdout string) {
+			assert.Contains(t, stdout, `Invalid metadata URL "metadata.internal": must be an http or https URL`)
+			assertFetchCertFailed(t, conjurrcInTmpDir)
+		},
+	},
+	{
+		name: "fails if both --host-id and --jwt-host-id are specified",
+		args: []string{"init", "-u=https://example.com", "-a=test-account", "-t=iam", "--host-id=myapp", "--jwt-host-id=myapp"},
+		assert: func(t *testing.T, conjurrcInTmpDir string, stdout string) {
+			assert.Contains(t, stdout, "Cannot specify both --host-id and --jwt-host-id")
+			assertFetchCertFailed(t, conjurrcInTmpDir)
+		},
+	},
 	{
 		name: "allows cert specified by --ca-cert",
 		args: []string{"init", "-u=https://example.com", "-a=test-account", "--ca-cert=custom-cert.pem"},
@@ -431,6 +480,98 @@ func TestInitCmd(t *testing.T) {
 	})
 }
 
+func TestSetClientCert(t *testing.T) {
+	t.Run("sets the absolute paths of a valid client certificate", func(t *testing.T) {
+		certFile, keyFile := writeTestClientCert(t, t.TempDir())
+		t.Chdir(filepath.Dir(certFile))
+
+		config := conjurapi.Config{Account: "test-account", ApplianceURL: "https://example.com"}
+		err := setClientCert(&config, filepath.Base(certFile), filepath.Base(keyFile))
+		assert.NoError(t, err)
+		assert.Equal(t, certFile, config.ClientCertFile)
+		assert.Equal(t, keyFile, config.ClientCertKeyFile)
+		assert.Equal(t, `account: test-account
+appliance_url: https://example.com
+client_cert_file: `+certFile+`
+client_cert_key_file: `+keyFile+`
+`, string(config.Conjurrc()))
+	})
+
+	t.Run("fails if the key does not match the certificate", func(t *testing.T) {
+		certFile, _ := writeTestClientCert(t, t.TempDir())
+		_, otherKeyFile := writeTestClientCert(t, t.TempDir())
+
+		config := conjurapi.Config{}
+		err := setClientCert(&config, certFile, otherKeyFile)
+		assert.ErrorContains(t, err, "Unable to load the client certificate")
+		assert.Empty(t, config.ClientCertFile)
+	})
+}
+
+func TestWriteConjurrcWithMetadataURL(t *testing.T) {
+	conjurrc := filepath.Join(t.TempDir(), ".conjurrc")
+	config := conjurapi.Config{
+		Account:       "test-account",
+		ApplianceURL:  "https://example.com",
+		AuthnType:     "azure",
+		ServiceID:     "prod",
+		JWTHostID:     "myapp",
+		AzureClientID: "client-id",
+	}
+
+	err := writeConjurrc(config, initCmdFlagValues{
+		conjurrcFilePath:   conjurrc,
+		metadataURL:        "http://localhost:8080/metadata/identity/oauth2/token",
+		forceFileOverwrite: true,
+	})
+	assert.NoError(t, err)
+
+	data, _ := os.ReadFile(conjurrc)
+	assert.Equal(t, `account: test-account
+appliance_url: https://example.com
+authn_type: azure
+service_id: prod
+jwt_host_id: myapp
+azure_client_id: client-id
+authn_metadata_url: http://localhost:8080/metadata/identity/oauth2/token
+`, string(data))
+
+	// Both the Conjur API and the CLI read their settings back from the file
+	t.Setenv("CONJURRC", conjurrc)
+	t.Setenv(clients.ProfileEnvVar, "")
+	t.Setenv(clients.MetadataURLEnvVar, "")
+	cloudConfig, err := clients.LoadCloudAuthnConfig("")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/metadata/identity/oauth2/token", cloudConfig.MetadataURL)
+	loaded, err := conjurapi.LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "client-id", loaded.AzureClientID)
+}
+
+// writeTestClientCert writes a self-signed client certificate and its key to a directory
+func writeTestClientCert(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "host/myapp"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client-key.pem")
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certFile, keyFile
+}
+
 func assertFetchCertFailed(t *testing.T, conjurrcInTmpDir string) {
 	// Assert that conjurrc and certificate were not written
 	expectedCertPath := filepath.Dir(conjurrcInTmpDir) + "/conjur-server.pem"
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
 
 	"github.com/cyberark/conjur-api-go/conjurapi"
@@ -50,6 +51,45 @@ func getResourceFilterObject(cmd *cobra.Command) (*conjurapi.ResourceFilter, err
 	}, nil
 }
 
+// forEachResourcePage pages through the resources matching the filter, calling fn with each
+// non-empty page, until the server returns an empty page. The server may return fewer resources
+// than pageSize when it caps the limit, so a short page does not mean the last one. The filter's
+// offset is used as the starting point and its limit is ignored.
+func forEachResourcePage(
+	client listClient,
+	filter conjurapi.ResourceFilter,
+	pageSize int,
+	fn func(page []map[string]interface{}) error,
+) error {
+	if pageSize <= 0 {
+		return errors.New("Page size must be greater than 0")
+	}
+
+	filter.Limit = pageSize
+	for {
+		page, err := client.Resources(&filter)
+		if err != nil {
+			return err
+		}
+
+		if len(page) == 0 {
+			return nil
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		filter.Offset += len(page)
+	}
+}
+
+// listResourceItem returns the representation of a resource printed by the list command
+func listResourceItem(resource map[string]interface{}, inspect bool) interface{} {
+	if inspect {
+		return resource
+	}
+	return resource["id"]
+}
+
 func newListCmd(clientFactory listClientFactoryFunc, roleClientFactory roleClientFactoryFunc, resourceClientFactory resourceClientFactoryFunc) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list",
@@ -64,7 +104,10 @@ Examples:
 - List first 5 users      : conjur list -k user -l 5
 - List next 5 users       : conjur list -k user -l 5 -o 5
 - List staging hosts      : conjur list -k host -s staging
-- List resources for role : conjur list -r dev:group:somegroup`,
+- List resources for role : conjur list -r dev:group:somegroup
+- List owners of variables: conjur list -k variable -i --output table --query '.[].owner'
+- List all variables      : conjur list -k variable --all
+- Stream all resources    : conjur list --stream --inspect > resources.ndjson`,
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := clientFactory(cmd)
@@ -110,37 +153,84 @@ Examples:
 				return err
 			}
 
-			resources, err := client.Resources(rf)
+			inspect, err := cmd.Flags().GetBool("inspect")
 			if err != nil {
 				return err
 			}
 
-			inspect, err := cmd.Flags().GetBool("inspect")
+			all, err := cmd.Flags().GetBool("all")
+			if err != nil {
+				return err
+			}
+
+			stream, err := cmd.Flags().GetBool("stream")
+			if err != nil {
+				return err
+			}
+
+			pageSize, err := cmd.Flags().GetInt("page-size")
 			if err != nil {
 				return err
 			}
 
-			var prettyResult string
+			if (all || stream) && cmd.Flags().Changed("limit") {
+				return errors.New("Cannot specify --limit when using --all or --stream")
+			}
+
+			if stream {
+				if format, query := getOutputFlags(cmd); format != "" || query != "" {
+					return errors.New("Cannot specify --output or --query when using --stream")
+				}
+
+				// Print each resource as soon as its page is received, one JSON document per line
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetEscapeHTML(false)
+				return forEachResourcePage(client, *rf, pageSize, func(page []map[string]interface{}) error {
+					for _, resource := range page {
+						if err := encoder.Encode(listResourceItem(resource, inspect)); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+			}
 
-			if inspect {
-				prettyResult, err = utils.PrettyPrintToJSON(resources)
+			var resources []map[string]interface{}
+			if all {
+				resources = make([]map[string]interface{}, 0)
+				err = forEachResourcePage(client, *rf, pageSize, func(page []map[string]interface{}) error {
+					resources = append(resources, page...)
+					return nil
+				})
 			} else {
+				resources, err = client.Resources(rf)
+			}
+			if err != nil {
+				return err
+			}
+
+			var result interface{} = resources
+
+			if !inspect {
 				resourceIDs := make([]string, 0)
 
 				for _, element := range resources {
 					resourceIDs = append(resourceIDs, element["id"].(string))
 				}
 
-				prettyResult, err = utils.PrettyPrintToJSON(resourceIDs)
+				result = resourceIDs
 			}
 
-			if err != nil {
-				return err
-			}
+			return printResult(cmd, result, func() error {
+				prettyResult, err := utils.PrettyPrintToJSON(result)
+				if err != nil {
+					return err
+				}
 
-			cmd.Println(prettyResult)
+				cmd.Println(prettyResult)
 
-			return nil
+				return nil
+			})
 		},
 	}
 
@@ -150,6 +240,9 @@ Examples:
 	cmd.Flags().IntP("offset", "o", 0, "Offset to start from")
 	cmd.Flags().StringP("role", "r", "", "Role whose resource list you want to view")
 	cmd.Flags().BoolP("inspect", "i", false, "Show resource details")
+	cmd.Flags().Bool("all", false, "Page through all matching resources instead of returning a single page")
+	cmd.Flags().Bool("stream", false, "Page through all matching resources and print them as newline-delimited JSON as they are received")
+	cmd.Flags().Int("page-size", 1000, "Number of resources requested per page when using --all or --stream")
 
 	// BEGIN COMPATIBILITY WITH PYTHON CLI
 	cmd.Flags().StringP("members-of", "m", "", "List members within a role")
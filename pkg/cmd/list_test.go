@@ -40,6 +40,15 @@ var clientResponseIDsStr = `[
 var clientResponse = make([]map[string]interface{}, 1)
 var _ = json.Unmarshal([]byte(clientResponseStr), &clientResponse)
 
+// pagedResources serves 5 resources in pages, as the server does with limit and offset
+func pagedResources(t *testing.T, filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error) {
+	resources := make([]map[string]interface{}, 0)
+	for i := filter.Offset; i < 5 && i < filter.Offset+filter.Limit; i++ {
+		resources = append(resources, map[string]interface{}{"id": fmt.Sprintf("dev:variable:var%d", i)})
+	}
+	return resources, nil
+}
+
 type mockListClient struct {
 	t             *testing.T
 	listResources func(t *testing.T, filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error)
@@ -135,6 +144,142 @@ var listCmdTestCases = []struct {
 			assert.Equal(t, stdout, clientResponseStr)
 		},
 	},
+	{
+		name: "list output table",
+		args: []string{"list", "--output", "table"},
+		listResources: func(t *testing.T, filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error) {
+			return clientResponse, nil
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Equal(t, "VALUE\ndev:host:test-host\ndev:layer:test-layer\n", stdout)
+		},
+	},
+	{
+		name: "list inspect with query",
+		args: []string{"list", "--inspect", "--output", "tsv", "--query", ".[].policy"},
+		listResources: func(t *testing.T, filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error) {
+			return clientResponse, nil
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Equal(t, "dev:policy:root\ndev:policy:root\n", stdout)
+		},
+	},
+	{
+		name: "list unsupported output format",
+		args: []string{"list", "--output", "xml"},
+		listResources: func(t *testing.T, filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error) {
+			return clientResponse, nil
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Contains(t, stderr, "Error: output format must be one of json, yaml, table, tsv\n")
+		},
+	},
+	{
+		name: "list all pages",
+		args: []string{"list", "--all", "--page-size", "2", "-k", "variable"},
+		listResources: func(t *testing.T, filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error) {
+			assert.Equal(t, "variable", filter.Kind)
+			assert.Equal(t, 2, filter.Limit)
+
+			return pagedResources(t, filter)
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, `[
+  "dev:variable:var0",
+  "dev:variable:var1",
+  "dev:variable:var2",
+  "dev:variable:var3",
+  "dev:variable:var4"
+]
+`, stdout)
+		},
+	},
+	{
+		name: "list all pages when the server caps the limit",
+		args: []string{"list", "--all", "--page-size", "1000"},
+		listResources: func(t *testing.T, filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error) {
+			capped := *filter
+			capped.Limit = 2
+			return pagedResources(t, &capped)
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, `[
+  "dev:variable:var0",
+  "dev:variable:var1",
+  "dev:variable:var2",
+  "dev:variable:var3",
+  "dev:variable:var4"
+]
+`, stdout)
+		},
+	},
+	{
+		name:          "list all pages from offset",
+		args:          []string{"list", "--all", "--page-size", "3", "-o", "1"},
+		listResources: pagedResources,
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.NotContains(t, stdout, "var0")
+			assert.Contains(t, stdout, "var1")
+			assert.Contains(t, stdout, "var4")
+		},
+	},
+	{
+		name:          "list stream",
+		args:          []string{"list", "--stream", "--page-size", "2"},
+		listResources: pagedResources,
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, "\"dev:variable:var0\"\n\"dev:variable:var1\"\n\"dev:variable:var2\"\n"+
+				"\"dev:variable:var3\"\n\"dev:variable:var4\"\n", stdout)
+		},
+	},
+	{
+		name:          "list stream inspect",
+		args:          []string{"list", "--stream", "--inspect", "--page-size", "4"},
+		listResources: pagedResources,
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Contains(t, stdout, "{\"id\":\"dev:variable:var0\"}\n{\"id\":\"dev:variable:var1\"}\n")
+			assert.Contains(t, stdout, "{\"id\":\"dev:variable:var4\"}\n")
+		},
+	},
+	{
+		name: "list all page error",
+		args: []string{"list", "--all", "--page-size", "2"},
+		listResources: func(t *testing.T, filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error) {
+			if filter.Offset > 0 {
+				return nil, fmt.Errorf("%s", "page error")
+			}
+			return pagedResources(t, filter)
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Contains(t, stderr, "Error: page error\n")
+		},
+	},
+	{
+		name: "list all with limit",
+		args: []string{"list", "--all", "-l", "5"},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Contains(t, stderr, "Error: Cannot specify --limit when using --all or --stream\n")
+		},
+	},
+	{
+		name: "list stream with output",
+		args: []string{"list", "--stream", "--output", "yaml"},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Contains(t, stderr, "Error: Cannot specify --output or --query when using --stream\n")
+		},
+	},
+	{
+		name: "list all with invalid page size",
+		args: []string{"list", "--all", "--page-size", "0"},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Contains(t, stderr, "Error: Page size must be greater than 0\n")
+		},
+	},
 	// BEGIN COMPATIBILITY WITH PYTHON CLI
 	{
 		name: "list members",
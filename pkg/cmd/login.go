@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/cyberark/conjur-api-go/conjurapi"
@@ -12,23 +16,85 @@ import (
 )
 
 type loginCmdFuncs struct {
-	LoadAndValidateConjurConfig func(timeout time.Duration) (conjurapi.Config, error)
+	LoadAndValidateConjurConfig func(profile string, timeout time.Duration) (conjurapi.Config, error)
 	LoginWithPromptFallback     func(client clients.ConjurClient, username string, password string) (*authn.LoginPair, error)
+	LoginWithAPIKey             func(client clients.ConjurClient, login string, apiKey string, host bool) (*authn.LoginPair, error)
 	OidcLogin                   func(conjurClient clients.ConjurClient, username string, password string) (clients.ConjurClient, error)
 	JWTAuthenticate             func(conjurClient clients.ConjurClient) error
+	CertAuthenticate            func(conjurClient clients.ConjurClient) error
+	CloudLogin                  func(config conjurapi.Config, profile string) (clients.ConjurClient, error)
 }
 
 var defaultLoginCmdFuncs = loginCmdFuncs{
-	LoadAndValidateConjurConfig: clients.LoadAndValidateConjurConfig,
+	LoadAndValidateConjurConfig: clients.LoadAndValidateConjurConfigForProfile,
 	LoginWithPromptFallback:     clients.LoginWithPromptFallback,
+	LoginWithAPIKey:             clients.LoginWithAPIKey,
 	OidcLogin:                   clients.OidcLogin,
 	JWTAuthenticate:             clients.JWTAuthenticate,
+	CertAuthenticate:            clients.CertAuthenticate,
+	CloudLogin:                  clients.CloudLogin,
 }
 
+const (
+	// apiKeyEnv holds the API key to log in with, as read by the Conjur API
+	apiKeyEnv = "CONJUR_AUTHN_API_KEY"
+	// apiKeyFileEnv names a file holding the API key to log in with, as mounted on CI runners
+	apiKeyFileEnv = "CONJUR_AUTHN_API_KEY_FILE"
+)
+
 type loginCmdFlagValues struct {
-	identity string
-	password string
-	debug    bool
+	identity   string
+	password   string
+	host       bool
+	apiKey     string
+	apiKeyFile string
+	debug      bool
+}
+
+// useAPIKey reports whether to log in with an API key rather than a password
+func (v loginCmdFlagValues) useAPIKey() bool {
+	return v.host || v.apiKey != "" || v.apiKeyFile != ""
+}
+
+// readAPIKey returns the API key from --api-key, stdin when it is "-", --api-key-file,
+// CONJUR_AUTHN_API_KEY or the file named by CONJUR_AUTHN_API_KEY_FILE. It returns an empty key when
+// none of them is set, for the login to prompt for it.
+func readAPIKey(cmd *cobra.Command, cmdFlagVals loginCmdFlagValues) (string, error) {
+	if cmdFlagVals.apiKey != "" && cmdFlagVals.apiKeyFile != "" {
+		return "", errors.New("Cannot specify both --api-key and --api-key-file")
+	}
+
+	var data []byte
+	var source string
+	var err error
+	switch {
+	case cmdFlagVals.apiKey == "-":
+		data, err = io.ReadAll(cmd.InOrStdin())
+		source = "stdin"
+	case cmdFlagVals.apiKey != "":
+		data = []byte(cmdFlagVals.apiKey)
+		source = "--api-key"
+	case cmdFlagVals.apiKeyFile != "":
+		data, err = os.ReadFile(cmdFlagVals.apiKeyFile)
+		source = cmdFlagVals.apiKeyFile
+	case os.Getenv(apiKeyEnv) != "":
+		data = []byte(os.Getenv(apiKeyEnv))
+		source = apiKeyEnv
+	case os.Getenv(apiKeyFileEnv) != "":
+		data, err = os.ReadFile(os.Getenv(apiKeyFileEnv))
+		source = os.Getenv(apiKeyFileEnv)
+	default:
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	apiKey := strings.TrimSpace(string(data))
+	if apiKey == "" {
+		return "", fmt.Errorf("The API key read from %s is empty", source)
+	}
+	return apiKey, nil
 }
 
 func getLoginCmdFlagValues(cmd *cobra.Command) (loginCmdFlagValues, error) {
@@ -44,15 +110,33 @@ func getLoginCmdFlagValues(cmd *cobra.Command) (loginCmdFlagValues, error) {
 		return loginCmdFlagValues{}, err
 	}
 
+	host, err := cmd.Flags().GetBool("host")
+	if err != nil {
+		return loginCmdFlagValues{}, err
+	}
+
+	apiKey, err := cmd.Flags().GetString("api-key")
+	if err != nil {
+		return loginCmdFlagValues{}, err
+	}
+
+	apiKeyFile, err := cmd.Flags().GetString("api-key-file")
+	if err != nil {
+		return loginCmdFlagValues{}, err
+	}
+
 	debug, err := cmd.Flags().GetBool("debug")
 	if err != nil {
 		return loginCmdFlagValues{}, err
 	}
 
 	return loginCmdFlagValues{
-		identity: identity,
-		password: password,
-		debug:    debug,
+		identity:   identity,
+		password:   password,
+		host:       host,
+		apiKey:     apiKey,
+		apiKeyFile: apiKeyFile,
+		debug:      debug,
 	}, nil
 }
 
@@ -64,12 +148,20 @@ func newLoginCmd(funcs loginCmdFuncs) *cobra.Command {
 
 The command will prompt for identity and password if they are not provided via flags.
 
+When the CLI is initialized for certificate authentication (see 'conjur init --authn-type cert'), the command instead checks that Conjur accepts the configured client certificate, and no credentials are stored.
+
+With the iam, azure and gcp authentication types, the command authenticates with the identity of the workload, obtained from the metadata service of its cloud or from the endpoint set with 'conjur init --metadata-url', and caches the Conjur access token.
+
 On successful login, the password is exchanged for the user's API key, which is cached in the operating system user's credential storage or .netrc file. Subsequent commands will authenticate using the cached credentials. To switch users, login again using new credentials. To erase credentials, use the 'logout' command.
 
+Machine identities log in with their API key instead, with --api-key (or "-" to read it from stdin), --api-key-file, the CONJUR_AUTHN_API_KEY environment variable or the file named by the CONJUR_AUTHN_API_KEY_FILE environment variable, in that order. The key is exchanged with Conjur and cached like a password login. With --host, the identity, including one entered at the prompt, is prefixed with host/, and the API key is prompted for, without being echoed, when none of these sources is set.
+
 Examples:
 
 - conjur login -i alice -p My$ecretPass
-- conjur login`,
+- conjur login
+- conjur login --host -i myapp/runner --api-key-file /run/secrets/conjur-api-key
+- echo "$API_KEY" | conjur login --host -i myapp/runner --api-key -`,
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var err error
@@ -84,7 +176,8 @@ Examples:
 				return err
 			}
 
-			config, err := funcs.LoadAndValidateConjurConfig(timeout)
+			profile := clients.SelectedProfile(cmd)
+			config, err := funcs.LoadAndValidateConjurConfig(profile, timeout)
 			if err != nil {
 				return err
 			}
@@ -95,11 +188,29 @@ Examples:
 				return err
 			}
 
+			clients.MaybeClientCertForClient(conjurClient)
 			if cmdFlagVals.debug {
 				clients.MaybeDebugLoggingForClient(cmdFlagVals.debug, cmd, conjurClient)
 			}
 
-			if config.AuthnType == "" || config.AuthnType == "authn" || config.AuthnType == "ldap" {
+			if cmdFlagVals.useAPIKey() {
+				// API keys are only accepted by the default authenticator
+				if config.AuthnType != "" && config.AuthnType != "authn" {
+					return fmt.Errorf("Cannot log in with an API key when using %s authentication", config.AuthnType)
+				}
+				if cmdFlagVals.password != "" {
+					return errors.New("Cannot specify both --password and an API key")
+				}
+
+				apiKey, err := readAPIKey(cmd, cmdFlagVals)
+				if err != nil {
+					return err
+				}
+				_, err = funcs.LoginWithAPIKey(conjurClient, cmdFlagVals.identity, apiKey, cmdFlagVals.host)
+				if err != nil {
+					return err
+				}
+			} else if config.AuthnType == "" || config.AuthnType == "authn" || config.AuthnType == "ldap" {
 				_, err = funcs.LoginWithPromptFallback(conjurClient, cmdFlagVals.identity, cmdFlagVals.password)
 			} else if config.AuthnType == "oidc" {
 				_, err = funcs.OidcLogin(conjurClient, cmdFlagVals.identity, cmdFlagVals.password)
@@ -119,6 +230,22 @@ Examples:
 				if err != nil {
 					err = fmt.Errorf("Unable to authenticate with Conjur using the provided JWT file: %s", err)
 				}
+			} else if config.AuthnType == "cert" {
+				// As with JWT, there are no credentials to store: the client certificate is
+				// presented on every authentication. Authenticate once to check that Conjur
+				// accepts it.
+				conjurClient, err = conjurapi.NewClientFromCertificate(config)
+				if err != nil {
+					return err
+				}
+				err = funcs.CertAuthenticate(conjurClient)
+				if err != nil {
+					err = fmt.Errorf("Unable to authenticate with Conjur using the client certificate %s: %s", config.ClientCertFile, err)
+				}
+			} else if clients.IsCloudAuthnType(config.AuthnType) {
+				// The identity of the workload is obtained from the metadata service of its
+				// cloud, and the Conjur access token it is exchanged for is cached
+				_, err = funcs.CloudLogin(config, profile)
 			} else {
 				return fmt.Errorf("unsupported authentication type: %s", config.AuthnType)
 			}
@@ -133,6 +260,9 @@ Examples:
 
 	cmd.Flags().StringP("id", "i", "", "")
 	cmd.Flags().StringP("password", "p", "", "")
+	cmd.Flags().Bool("host", false, "Log in as a host with its API key (prefixes the ID with host/)")
+	cmd.Flags().String("api-key", "", "Log in with this API key rather than a password (\"-\" reads it from stdin)")
+	cmd.Flags().String("api-key-file", "", "Log in with the API key read from this file")
 
 	return cmd
 }
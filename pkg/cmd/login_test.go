@@ -2,26 +2,40 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/cyberark/conjur-api-go/conjurapi"
 	"github.com/cyberark/conjur-api-go/conjurapi/authn"
 	"github.com/cyberark/conjur-cli-go/pkg/clients"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 )
 
 type mockLoginClient struct {
 	t                       *testing.T
 	loginWithPromptFallback func(t *testing.T, client clients.ConjurClient, username string, password string) (*authn.LoginPair, error)
+	loginWithAPIKey         func(t *testing.T, client clients.ConjurClient, login string, apiKey string, host bool) (*authn.LoginPair, error)
 	oidcLogin               func(t *testing.T, client clients.ConjurClient, username string, password string) (clients.ConjurClient, error)
 	jwtAuthenticate         func(t *testing.T, client clients.ConjurClient) error
+	cloudLogin              func(t *testing.T, config conjurapi.Config) (clients.ConjurClient, error)
+}
+
+func (m mockLoginClient) CloudLogin(config conjurapi.Config, profile string) (clients.ConjurClient, error) {
+	return m.cloudLogin(m.t, config)
 }
 
 func (m mockLoginClient) LoginWithPromptFallback(client clients.ConjurClient, username string, password string) (*authn.LoginPair, error) {
 	return m.loginWithPromptFallback(m.t, client, username, password)
 }
 
+func (m mockLoginClient) LoginWithAPIKey(client clients.ConjurClient, login string, apiKey string, host bool) (*authn.LoginPair, error) {
+	return m.loginWithAPIKey(m.t, client, login, apiKey, host)
+}
+
 func (m mockLoginClient) OidcLogin(client clients.ConjurClient, username string, password string) (clients.ConjurClient, error) {
 	return m.oidcLogin(m.t, client, username, password)
 }
@@ -50,13 +64,23 @@ var jwtConjurConfig = conjurapi.Config{
 	JWTFilePath:  "jwt-file",
 }
 
+var azureConjurConfig = conjurapi.Config{
+	Account:      "dev",
+	ApplianceURL: "https://conjur",
+	AuthnType:    "azure",
+	ServiceID:    "test-service",
+	JWTHostID:    "myapp",
+}
+
 var loginTestCases = []struct {
 	name                    string
 	args                    []string
 	conjurConfig            conjurapi.Config
 	oidcLogin               func(t *testing.T, client clients.ConjurClient, username string, password string) (clients.ConjurClient, error)
 	jwtAuthenticate         func(t *testing.T, client clients.ConjurClient) error
+	cloudLogin              func(t *testing.T, config conjurapi.Config) (clients.ConjurClient, error)
 	loginWithPromptFallback func(t *testing.T, client clients.ConjurClient, username string, password string) (*authn.LoginPair, error)
+	loginWithAPIKey         func(t *testing.T, client clients.ConjurClient, login string, apiKey string, host bool) (*authn.LoginPair, error)
 	assert                  func(t *testing.T, stdout string, stderr string, err error)
 }{
 	{
@@ -94,6 +118,66 @@ var loginTestCases = []struct {
 			assert.EqualError(t, err, assert.AnError.Error())
 		},
 	},
+	{
+		name:         "login as a host with an api key",
+		args:         []string{"login", "--host", "-i", "myapp/runner", "--api-key", "api-key\n"},
+		conjurConfig: defaultConjurConfig,
+		loginWithAPIKey: func(t *testing.T, client clients.ConjurClient, login string, apiKey string, host bool) (*authn.LoginPair, error) {
+			assert.Equal(t, "myapp/runner", login)
+			assert.Equal(t, "api-key", apiKey)
+			assert.True(t, host)
+
+			return &authn.LoginPair{Login: login, APIKey: apiKey}, nil
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Empty(t, stderr)
+			assert.Contains(t, stdout, "Logged in")
+		},
+	},
+	{
+		name:         "login with an api key does not prefix the id without --host",
+		args:         []string{"login", "-i", "host/myapp/runner", "--api-key", "api-key"},
+		conjurConfig: defaultConjurConfig,
+		loginWithAPIKey: func(t *testing.T, client clients.ConjurClient, login string, apiKey string, host bool) (*authn.LoginPair, error) {
+			assert.Equal(t, "host/myapp/runner", login)
+			assert.False(t, host)
+
+			return &authn.LoginPair{Login: login, APIKey: apiKey}, nil
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Contains(t, stdout, "Logged in")
+		},
+	},
+	{
+		name:         "login with an api key returns error",
+		args:         []string{"login", "--host", "-i", "myapp/runner", "--api-key", "wrong"},
+		conjurConfig: defaultConjurConfig,
+		loginWithAPIKey: func(t *testing.T, client clients.ConjurClient, login string, apiKey string, host bool) (*authn.LoginPair, error) {
+			return nil, assert.AnError
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.EqualError(t, err, assert.AnError.Error())
+			assert.NotContains(t, stdout, "Logged in")
+		},
+	},
+	{
+		name:         "login with an api key fails with a password",
+		args:         []string{"login", "-i", "alice", "-p", "secret", "--api-key", "api-key"},
+		conjurConfig: defaultConjurConfig,
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.EqualError(t, err, "Cannot specify both --password and an API key")
+		},
+	},
+	{
+		name:         "login with an api key fails for other authenticators",
+		args:         []string{"login", "--host", "-i", "myapp/runner", "--api-key", "api-key"},
+		conjurConfig: oidcConjurConfig,
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.EqualError(t, err, "Cannot log in with an API key when using oidc authentication")
+		},
+	},
 	{
 		name:         "login with debug flag",
 		args:         []string{"--debug", "login", "-i", "alice", "-p", "secret"},
@@ -154,6 +238,77 @@ var loginTestCases = []struct {
 			assert.Contains(t, stderr, "Unable to authenticate with Conjur using the provided JWT file: jwt authentication failed")
 		},
 	},
+	{
+		name:         "login with a cloud identity",
+		args:         []string{"login"},
+		conjurConfig: azureConjurConfig,
+		cloudLogin: func(t *testing.T, config conjurapi.Config) (clients.ConjurClient, error) {
+			assert.Equal(t, "azure", config.AuthnType)
+			return nil, nil
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Empty(t, stderr)
+			assert.Contains(t, stdout, "Logged in")
+		},
+	},
+	{
+		name:         "login with a cloud identity fails",
+		args:         []string{"login"},
+		conjurConfig: azureConjurConfig,
+		cloudLogin: func(t *testing.T, config conjurapi.Config) (clients.ConjurClient, error) {
+			return nil, fmt.Errorf("Unable to authenticate with Conjur using the azure identity: 401 Unauthorized")
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Error(t, err)
+			assert.Empty(t, stdout)
+			assert.Contains(t, stderr, "Unable to authenticate with Conjur using the azure identity: 401 Unauthorized")
+		},
+	},
+}
+
+func TestLoginCmdWithClientCert(t *testing.T) {
+	certFile, keyFile := writeTestClientCert(t, t.TempDir())
+	certConfig := conjurapi.Config{
+		Account:           "dev",
+		ApplianceURL:      "https://conjur",
+		AuthnType:         "cert",
+		ServiceID:         "test-service",
+		ClientCertFile:    certFile,
+		ClientCertKeyFile: keyFile,
+	}
+
+	newCmd := func(certAuthenticate func(clients.ConjurClient) error) *cobra.Command {
+		return newLoginCmd(loginCmdFuncs{
+			CertAuthenticate: certAuthenticate,
+			LoadAndValidateConjurConfig: func(string, time.Duration) (conjurapi.Config, error) {
+				return certConfig, nil
+			},
+		})
+	}
+
+	t.Run("authenticates with the client certificate", func(t *testing.T) {
+		cmd := newCmd(func(client clients.ConjurClient) error {
+			assert.IsType(t, &authn.CertAuthenticator{}, client.GetAuthenticator())
+			return nil
+		})
+
+		stdout, stderr, err := executeCommandForTest(t, cmd, "login")
+		assert.NoError(t, err)
+		assert.Empty(t, stderr)
+		assert.Contains(t, stdout, "Logged in")
+	})
+
+	t.Run("fails when the certificate is rejected", func(t *testing.T) {
+		cmd := newCmd(func(client clients.ConjurClient) error {
+			return fmt.Errorf("401 Unauthorized")
+		})
+
+		stdout, stderr, err := executeCommandForTest(t, cmd, "login")
+		assert.Error(t, err)
+		assert.Empty(t, stdout)
+		assert.Contains(t, stderr, "Unable to authenticate with Conjur using the client certificate "+certFile+": 401 Unauthorized")
+	})
 }
 
 func TestLoginCmd(t *testing.T) {
@@ -164,16 +319,20 @@ func TestLoginCmd(t *testing.T) {
 			mockClient := mockLoginClient{
 				t:                       t,
 				loginWithPromptFallback: tc.loginWithPromptFallback,
+				loginWithAPIKey:         tc.loginWithAPIKey,
 				oidcLogin:               tc.oidcLogin,
 				jwtAuthenticate:         tc.jwtAuthenticate,
+				cloudLogin:              tc.cloudLogin,
 			}
 
 			cmd := newLoginCmd(
 				loginCmdFuncs{
 					LoginWithPromptFallback: mockClient.LoginWithPromptFallback,
+					LoginWithAPIKey:         mockClient.LoginWithAPIKey,
 					OidcLogin:               mockClient.OidcLogin,
 					JWTAuthenticate:         mockClient.JWTAuthenticate,
-					LoadAndValidateConjurConfig: func(time.Duration) (conjurapi.Config, error) {
+					CloudLogin:              mockClient.CloudLogin,
+					LoadAndValidateConjurConfig: func(string, time.Duration) (conjurapi.Config, error) {
 						return tc.conjurConfig, nil
 					},
 				},
@@ -184,3 +343,63 @@ func TestLoginCmd(t *testing.T) {
 		})
 	}
 }
+
+func TestReadAPIKey(t *testing.T) {
+	newCmd := func(stdin string) *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.SetIn(strings.NewReader(stdin))
+		return cmd
+	}
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "api-key")
+	assert.NoError(t, os.WriteFile(keyFile, []byte("file-key\n"), 0600))
+	envKeyFile := filepath.Join(dir, "env-api-key")
+	assert.NoError(t, os.WriteFile(envKeyFile, []byte("env-key\n"), 0600))
+
+	t.Run("reads the api key from stdin", func(t *testing.T) {
+		apiKey, err := readAPIKey(newCmd("stdin-key\n"), loginCmdFlagValues{apiKey: "-"})
+		assert.NoError(t, err)
+		assert.Equal(t, "stdin-key", apiKey)
+	})
+
+	t.Run("reads the api key from --api-key-file", func(t *testing.T) {
+		t.Setenv(apiKeyFileEnv, envKeyFile)
+		apiKey, err := readAPIKey(newCmd(""), loginCmdFlagValues{host: true, apiKeyFile: keyFile})
+		assert.NoError(t, err)
+		assert.Equal(t, "file-key", apiKey)
+	})
+
+	t.Run("reads the api key from the environment", func(t *testing.T) {
+		t.Setenv(apiKeyEnv, "env-var-key")
+		t.Setenv(apiKeyFileEnv, envKeyFile)
+		apiKey, err := readAPIKey(newCmd(""), loginCmdFlagValues{host: true})
+		assert.NoError(t, err)
+		assert.Equal(t, "env-var-key", apiKey)
+	})
+
+	t.Run("reads the api key from the file named by the environment", func(t *testing.T) {
+		t.Setenv(apiKeyEnv, "")
+		t.Setenv(apiKeyFileEnv, envKeyFile)
+		apiKey, err := readAPIKey(newCmd(""), loginCmdFlagValues{host: true})
+		assert.NoError(t, err)
+		assert.Equal(t, "env-key", apiKey)
+	})
+
+	t.Run("returns an empty api key to prompt for without a source", func(t *testing.T) {
+		t.Setenv(apiKeyEnv, "")
+		t.Setenv(apiKeyFileEnv, "")
+		apiKey, err := readAPIKey(newCmd(""), loginCmdFlagValues{host: true})
+		assert.NoError(t, err)
+		assert.Empty(t, apiKey)
+	})
+
+	t.Run("fails with an empty api key", func(t *testing.T) {
+		_, err := readAPIKey(newCmd(""), loginCmdFlagValues{apiKey: "-"})
+		assert.EqualError(t, err, "The API key read from stdin is empty")
+	})
+
+	t.Run("fails with both --api-key and --api-key-file", func(t *testing.T) {
+		_, err := readAPIKey(newCmd(""), loginCmdFlagValues{apiKey: "key", apiKeyFile: keyFile})
+		assert.EqualError(t, err, "Cannot specify both --api-key and --api-key-file")
+	})
+}
@@ -2,11 +2,12 @@ package cmd
 
 import (
 	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-cli-go/pkg/clients"
 
 	"github.com/spf13/cobra"
 )
 
-type configLoaderFn func() (conjurapi.Config, error)
+type configLoaderFn func(profile string) (conjurapi.Config, error)
 
 func newLogoutCmd(loadConfig configLoaderFn) *cobra.Command {
 	cmd := &cobra.Command{
@@ -15,7 +16,7 @@ func newLogoutCmd(loadConfig configLoaderFn) *cobra.Command {
 		Long:         `Log out the user and delete the credentials cached in the operating system user's credential storage or .netrc file.`,
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			config, err := loadConfig()
+			config, err := loadConfig(clients.SelectedProfile(cmd))
 			if err != nil {
 				return err
 			}
@@ -34,6 +35,6 @@ func newLogoutCmd(loadConfig configLoaderFn) *cobra.Command {
 }
 
 func init() {
-	logoutCmd := newLogoutCmd(conjurapi.LoadConfig)
+	logoutCmd := newLogoutCmd(clients.LoadConfigForProfile)
 	rootCmd.AddCommand(logoutCmd)
 }
@@ -49,7 +49,7 @@ var logoutTestCases = []struct {
 func TestLogoutCmd(t *testing.T) {
 	for _, tc := range logoutTestCases {
 		// Mock out the configuration loader
-		configLoader := func() (conjurapi.Config, error) {
+		configLoader := func(string) (conjurapi.Config, error) {
 			if tc.configErr != nil {
 				return conjurapi.Config{}, tc.configErr
 			}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"github.com/cyberark/conjur-cli-go/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// getOutputFlags returns the values of the global --output and --query flags. A --json flag of
+// the command is an alias of --output json.
+func getOutputFlags(cmd *cobra.Command) (format string, query string) {
+	if flag := cmd.Flags().Lookup("output"); flag != nil {
+		format = flag.Value.String()
+	}
+	if flag := cmd.Flags().Lookup("json"); flag != nil && format == "" && flag.Value.String() == "true" {
+		format = utils.OutputFormatJSON
+	}
+	if flag := cmd.Flags().Lookup("query"); flag != nil {
+		query = flag.Value.String()
+	}
+	return format, query
+}
+
+// printResult prints the result of a read command in the format selected with the global
+// --output and --query flags. When neither flag is set, legacyPrint is called so that the
+// command keeps its historical output.
+func printResult(cmd *cobra.Command, result interface{}, legacyPrint func() error) error {
+	format, query := getOutputFlags(cmd)
+	if format == "" && query == "" {
+		return legacyPrint()
+	}
+	if format == "" {
+		format = utils.OutputFormatJSON
+	}
+
+	out, err := utils.FormatOutput(result, format, query)
+	if err != nil {
+		return err
+	}
+
+	cmd.Println(out)
+	return nil
+}
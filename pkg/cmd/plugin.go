@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-cli-go/pkg/clients"
+	"github.com/spf13/cobra"
+)
+
+type pluginClient interface {
+	GetConfig() conjurapi.Config
+	InternalAuthenticate() ([]byte, error)
+}
+
+type pluginClientFactoryFunc func(*cobra.Command) (pluginClient, error)
+
+func pluginClientFactory(cmd *cobra.Command) (pluginClient, error) {
+	return clients.AuthenticatedConjurClientForCommand(cmd)
+}
+
+// pluginPrefix is the prefix of the executables run as subcommands of the CLI
+const pluginPrefix = "conjur-"
+
+// conjurPlugin is an executable named conjur-<name> found on the PATH
+type conjurPlugin struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// Shadowed lists the executables of the same name found later on the PATH, which are not run
+	Shadowed []string `json:"shadowed,omitempty"`
+}
+
+// pluginName returns the name of the plugin of an executable, or "" if it is not a plugin
+func pluginName(file string) string {
+	name, ok := strings.CutPrefix(file, pluginPrefix)
+	if !ok {
+		return ""
+	}
+	if runtime.GOOS == "windows" {
+		if !strings.EqualFold(filepath.Ext(name), ".exe") {
+			return ""
+		}
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return name
+}
+
+// isExecutableFile reports whether a file can be run as a plugin
+func isExecutableFile(info os.FileInfo) bool {
+	if info.IsDir() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0111 != 0
+}
+
+// findPlugins returns the plugins found in the directories of a PATH. Like the shell, the first
+// executable of a name on the PATH is the one that runs.
+func findPlugins(pathList string) []conjurPlugin {
+	plugins := []conjurPlugin{}
+	index := map[string]int{}
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := pluginName(entry.Name())
+			if name == "" {
+				continue
+			}
+			// Follow symbolic links to check the executable they point to
+			info, err := os.Stat(filepath.Join(dir, entry.Name()))
+			if err != nil || !isExecutableFile(info) {
+				continue
+			}
+
+			path := filepath.Join(dir, entry.Name())
+			if i, ok := index[name]; ok {
+				plugins[i].Shadowed = append(plugins[i].Shadowed, path)
+				continue
+			}
+			index[name] = len(plugins)
+			plugins = append(plugins, conjurPlugin{Name: name, Path: path})
+		}
+	}
+	return plugins
+}
+
+// pluginEnv returns the environment of a plugin: the environment of the CLI, with the connection
+// details of the current profile and a fresh access token for the current user
+func pluginEnv(client pluginClient) ([]string, error) {
+	token, err := client.InternalAuthenticate()
+	if err != nil {
+		return nil, err
+	}
+	config := client.GetConfig()
+
+	vars := map[string]string{
+		"CONJUR_APPLIANCE_URL": config.ApplianceURL,
+		"CONJUR_ACCOUNT":       config.Account,
+		"CONJUR_CERT_FILE":     config.SSLCertPath,
+		"CONJUR_AUTHN_TOKEN":   string(token),
+	}
+
+	env := []string{}
+	for _, entry := range os.Environ() {
+		key, _, _ := strings.Cut(entry, "=")
+		// A token file would take precedence over the token given to the plugin
+		if _, ok := vars[key]; ok || key == "CONJUR_AUTHN_TOKEN_FILE" {
+			continue
+		}
+		env = append(env, entry)
+	}
+	for _, key := range []string{"CONJUR_APPLIANCE_URL", "CONJUR_ACCOUNT", "CONJUR_CERT_FILE", "CONJUR_AUTHN_TOKEN"} {
+		if vars[key] != "" {
+			env = append(env, key+"="+vars[key])
+		}
+	}
+	return env, nil
+}
+
+// newPluginRunCmd returns a command running a plugin with the arguments following its name. The
+// first globalArgs arguments are global flags given before the name, which apply to the CLI.
+func newPluginRunCmd(name string, path string, globalArgs int, clientFactory pluginClientFactoryFunc) *cobra.Command {
+	return &cobra.Command{
+		Use:                name,
+		Short:              "Run the " + path + " plugin",
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cmd.InheritedFlags().Parse(args[:globalArgs]); err != nil {
+				return err
+			}
+			args = args[globalArgs:]
+
+			client, err := clientFactory(cmd)
+			if err != nil {
+				return err
+			}
+
+			env, err := pluginEnv(client)
+			if err != nil {
+				return err
+			}
+
+			plugin := exec.Command(path, args...)
+			plugin.Env = env
+			plugin.Stdin = cmd.InOrStdin()
+			plugin.Stdout = cmd.OutOrStdout()
+			plugin.Stderr = cmd.ErrOrStderr()
+			err = plugin.Run()
+
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				// The plugin reported its own error, exit with its status
+				cmd.SilenceErrors = true
+			}
+			return err
+		},
+	}
+}
+
+// pluginNameIndex returns the index of the first argument of the CLI which is neither a global flag
+// nor the value of one, as cobra finds the name of a command, or -1 when there is none
+func pluginNameIndex(root *cobra.Command, args []string) int {
+	flags := root.PersistentFlags()
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return -1
+		case !strings.HasPrefix(arg, "-"):
+			return i
+		case strings.Contains(arg, "="):
+			// The value is part of the argument
+		case strings.HasPrefix(arg, "--"):
+			if flag := flags.Lookup(arg[2:]); flag == nil || flag.NoOptDefVal == "" {
+				i++
+			}
+		case len(arg) == 2:
+			if flag := flags.ShorthandLookup(arg[1:]); flag == nil || flag.NoOptDefVal == "" {
+				i++
+			}
+		}
+	}
+	return -1
+}
+
+// addPluginCommand adds a command running the plugin named by the first argument of the CLI,
+// after any global flags, when it is not a built-in command. Built-in commands always take
+// precedence over plugins.
+func addPluginCommand(root *cobra.Command, args []string, clientFactory pluginClientFactoryFunc) bool {
+	index := pluginNameIndex(root, args)
+	if index < 0 {
+		return false
+	}
+	name := args[index]
+	if strings.HasPrefix(name, "__") || name == "help" {
+		return false
+	}
+	if _, _, err := root.Find(args); err == nil {
+		return false
+	}
+
+	path, err := exec.LookPath(pluginPrefix + name)
+	if err != nil {
+		return false
+	}
+	root.AddCommand(newPluginRunCmd(name, path, index, clientFactory))
+	return true
+}
+
+func newPluginListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the plugins found on the PATH",
+		Long: `List the plugins found on the PATH.
+
+Examples:
+- conjur plugin list
+- conjur plugin list --output json`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plugins := findPlugins(os.Getenv("PATH"))
+
+			for _, plugin := range plugins {
+				if builtin, _, err := cmd.Root().Find([]string{plugin.Name}); err == nil && builtin != cmd.Root() {
+					cmd.PrintErrf("Warning: %s is ignored, %s is a built-in command\n", plugin.Path, plugin.Name)
+				}
+				for _, shadowed := range plugin.Shadowed {
+					cmd.PrintErrf("Warning: %s is shadowed by %s\n", shadowed, plugin.Path)
+				}
+			}
+
+			return printResult(cmd, plugins, func() error {
+				if len(plugins) == 0 {
+					cmd.Printf("No plugins found, plugins are executables named %s<name> on the PATH\n", pluginPrefix)
+					return nil
+				}
+				for _, plugin := range plugins {
+					cmd.Printf("%s\t%s\n", plugin.Name, plugin.Path)
+				}
+				return nil
+			})
+		},
+	}
+}
+
+func newPluginCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plugin",
+		Short: "Manage plugins",
+		Long: fmt.Sprintf(`Manage plugins.
+
+A plugin is an executable named %[1]s<name> on the PATH, which runs as 'conjur <name>' with the arguments that follow. Global flags such as --profile or --debug may precede the name, and apply to the CLI rather than the plugin. Built-in commands take precedence over plugins of the same name.
+
+Plugins inherit the environment of the CLI, with the connection details of the current profile and an access token for the current user:
+
+- CONJUR_APPLIANCE_URL: the URL of the Conjur server
+- CONJUR_ACCOUNT:       the Conjur account
+- CONJUR_CERT_FILE:     the CA certificate of the server, if any
+- CONJUR_AUTHN_TOKEN:   a fresh access token, as used by the Conjur API clients
+
+Examples:
+- conjur plugin list
+- conjur mytool --flag arg   (runs %[1]smytool --flag arg)
+- conjur --profile staging mytool arg   (runs %[1]smytool arg with the staging profile)`, pluginPrefix),
+		Run: func(cmd *cobra.Command, args []string) {
+			// Print --help if called without subcommand
+			cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newPluginListCmd())
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(newPluginCmd())
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-cli-go/pkg/clients"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPluginClient struct{}
+
+func (m mockPluginClient) GetConfig() conjurapi.Config {
+	return conjurapi.Config{Account: "dev", ApplianceURL: "https://conjur", SSLCertPath: "/etc/conjur.pem"}
+}
+
+func (m mockPluginClient) InternalAuthenticate() ([]byte, error) {
+	return []byte(`{"protected":"x","payload":"y","signature":"z"}`), nil
+}
+
+func mockPluginClientFactory(cmd *cobra.Command) (pluginClient, error) {
+	return mockPluginClient{}, nil
+}
+
+func writePlugin(t *testing.T, dir string, name string, script string, mode os.FileMode) string {
+	path := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), mode))
+	return path
+}
+
+func TestFindPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are .exe files on Windows")
+	}
+
+	first, second := t.TempDir(), t.TempDir()
+	hello := writePlugin(t, first, "conjur-hello", "", 0755)
+	shadowed := writePlugin(t, second, "conjur-hello", "", 0755)
+	audit := writePlugin(t, second, "conjur-audit", "", 0755)
+	writePlugin(t, second, "conjur-notes", "", 0644)
+	writePlugin(t, second, "kubectl-hello", "", 0755)
+	assert.NoError(t, os.Mkdir(filepath.Join(second, "conjur-dir"), 0755))
+
+	plugins := findPlugins(first + string(os.PathListSeparator) + "/does/not/exist" + string(os.PathListSeparator) + second)
+	assert.Equal(t, []conjurPlugin{
+		{Name: "hello", Path: hello, Shadowed: []string{shadowed}},
+		{Name: "audit", Path: audit},
+	}, plugins)
+}
+
+func TestPluginEnv(t *testing.T) {
+	t.Setenv("CONJUR_APPLIANCE_URL", "https://other")
+	t.Setenv("CONJUR_AUTHN_TOKEN_FILE", "/run/conjur/token")
+	t.Setenv("PLUGIN_TEST_VAR", "kept")
+
+	env, err := pluginEnv(mockPluginClient{})
+	assert.NoError(t, err)
+	assert.Contains(t, env, "PLUGIN_TEST_VAR=kept")
+	assert.Contains(t, env, "CONJUR_APPLIANCE_URL=https://conjur")
+	assert.Contains(t, env, "CONJUR_ACCOUNT=dev")
+	assert.Contains(t, env, "CONJUR_CERT_FILE=/etc/conjur.pem")
+	assert.Contains(t, env, `CONJUR_AUTHN_TOKEN={"protected":"x","payload":"y","signature":"z"}`)
+	assert.NotContains(t, env, "CONJUR_APPLIANCE_URL=https://other")
+	assert.NotContains(t, env, "CONJUR_AUTHN_TOKEN_FILE=/run/conjur/token")
+}
+
+func TestPluginDispatch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are .exe files on Windows")
+	}
+
+	dir := t.TempDir()
+	writePlugin(t, dir, "conjur-hello", `echo "hello $* from $CONJUR_ACCOUNT at $CONJUR_APPLIANCE_URL"; exit 3`+"\n", 0755)
+	writePlugin(t, dir, "conjur-variable", "echo shadowed\n", 0755)
+	t.Setenv("PATH", dir)
+
+	newRoot := func() *cobra.Command {
+		root := newRootCommand()
+		root.AddCommand(&cobra.Command{Use: "variable", Run: func(cmd *cobra.Command, args []string) { cmd.Print("built-in") }})
+		return root
+	}
+
+	t.Run("runs the plugin with its arguments", func(t *testing.T) {
+		root := newRoot()
+		args := []string{"hello", "--name", "world"}
+		assert.True(t, addPluginCommand(root, args, mockPluginClientFactory))
+
+		stdout := &bytes.Buffer{}
+		root.SetOut(stdout)
+		root.SetErr(&bytes.Buffer{})
+		root.SetArgs(args)
+		err := root.Execute()
+
+		var exitErr *exec.ExitError
+		assert.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 3, exitErr.ExitCode())
+		assert.Equal(t, "hello --name world from dev at https://conjur\n", stdout.String())
+	})
+
+	t.Run("applies the global flags preceding the plugin to the CLI", func(t *testing.T) {
+		root := newRoot()
+		args := []string{"--profile", "staging", "-d", "--timeout=5s", "hello", "--profile", "other"}
+		profile := ""
+		assert.True(t, addPluginCommand(root, args, func(cmd *cobra.Command) (pluginClient, error) {
+			profile = clients.SelectedProfile(cmd)
+			return mockPluginClient{}, nil
+		}))
+
+		stdout := &bytes.Buffer{}
+		root.SetOut(stdout)
+		root.SetErr(&bytes.Buffer{})
+		root.SetArgs(args)
+		root.Execute()
+
+		assert.Equal(t, "staging", profile)
+		assert.Equal(t, "hello --profile other from dev at https://conjur\n", stdout.String())
+	})
+
+	t.Run("built-in commands take precedence", func(t *testing.T) {
+		assert.False(t, addPluginCommand(newRoot(), []string{"variable", "get"}, mockPluginClientFactory))
+		assert.False(t, addPluginCommand(newRoot(), []string{"--profile", "staging", "variable", "get"}, mockPluginClientFactory))
+		assert.False(t, addPluginCommand(newRoot(), []string{"missing"}, mockPluginClientFactory))
+		assert.False(t, addPluginCommand(newRoot(), []string{"--profile", "hello"}, mockPluginClientFactory))
+		assert.False(t, addPluginCommand(newRoot(), []string{}, mockPluginClientFactory))
+	})
+
+	t.Run("lists the plugins", func(t *testing.T) {
+		stdout, stderr, err := executeCommandForTest(t, newPluginCmd(), "plugin", "list")
+		assert.NoError(t, err)
+		assert.Equal(t, "hello\t"+filepath.Join(dir, "conjur-hello")+"\nvariable\t"+filepath.Join(dir, "conjur-variable")+"\n", stdout)
+		assert.Equal(t, "", stderr)
+
+		stdout, _, err = executeCommandForTest(t, newPluginCmd(), "plugin", "list", "--output", "json", "--query", "[0].name")
+		assert.NoError(t, err)
+		assert.Equal(t, "\"hello\"\n", stdout)
+
+		root := newRoot()
+		root.AddCommand(newPluginCmd())
+		stderrBuf := &bytes.Buffer{}
+		root.SetOut(&bytes.Buffer{})
+		root.SetErr(stderrBuf)
+		root.SetArgs([]string{"plugin", "list"})
+		assert.NoError(t, root.Execute())
+		assert.Equal(t, "Warning: "+filepath.Join(dir, "conjur-variable")+" is ignored, variable is a built-in command\n", stderrBuf.String())
+	})
+}
@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -13,6 +15,19 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// policyLoadResult is the outcome of loading one of the policy files of a directory or manifest
+type policyLoadResult struct {
+	Branch   string          `json:"branch"`
+	File     string          `json:"file"`
+	Response json.RawMessage `json:"response"`
+}
+
+// policyLoadSummary combines the outcomes of loading the policy files of a directory or manifest
+type policyLoadSummary struct {
+	Policies     []policyLoadResult               `json:"policies"`
+	CreatedRoles map[string]conjurapi.CreatedRole `json:"created_roles,omitempty"`
+}
+
 func loadPolicyCommandRunner(
 	clientFactory policyClientFactoryFunc,
 	policyMode conjurapi.PolicyMode,
@@ -33,14 +48,49 @@ func loadPolicyCommandRunner(
 			return err
 		}
 
-		var inputReader io.Reader = cmd.InOrStdin()
-		// the argument received looks like a file, we try to open it
+		varFiles, err := cmd.Flags().GetStringArray("var-file")
+		if err != nil {
+			return err
+		}
+
+		varPairs, err := cmd.Flags().GetStringArray("var")
+		if err != nil {
+			return err
+		}
+
+		vars, err := utils.ParseTemplateVars(varFiles, varPairs)
+		if err != nil {
+			return err
+		}
+
+		sources := []utils.PolicySource{{Branch: branch, File: file}}
+		// the argument received looks like a file, directory or manifest, we try to open it
 		if file != "-" {
-			file, err := os.Open(file)
+			sources, err = utils.DiscoverPolicySources(file, branch)
 			if err != nil {
 				return err
 			}
-			inputReader = file
+		}
+
+		if len(sources) > 1 || sources[0].File != file {
+			return loadPolicySources(cmd, clientFactory, policyMode, dryrun, sources, vars)
+		}
+
+		var policy []byte
+		dir := "."
+		if file == "-" {
+			policy, err = io.ReadAll(cmd.InOrStdin())
+		} else {
+			policy, err = os.ReadFile(file)
+			dir = filepath.Dir(file)
+		}
+		if err != nil {
+			return err
+		}
+
+		policy, err = utils.RenderPolicy(policy, file, dir, vars)
+		if err != nil {
+			return err
 		}
 
 		conjurClient, err := clientFactory(cmd)
@@ -48,7 +98,7 @@ func loadPolicyCommandRunner(
 			return err
 		}
 
-		data, err := DryRunOrLoadPolicy(conjurClient, dryrun, policyMode, branch, inputReader)
+		data, err := DryRunOrLoadPolicy(conjurClient, dryrun, policyMode, branch, bytes.NewReader(policy))
 		if err != nil {
 			return err
 		}
@@ -64,6 +114,93 @@ func loadPolicyCommandRunner(
 	}
 }
 
+// loadPolicySources loads the policy files of a directory or manifest in order, then prints a
+// combined summary. Loading stops at the first failure, and the summary of the policies loaded
+// until then is printed.
+func loadPolicySources(
+	cmd *cobra.Command,
+	clientFactory policyClientFactoryFunc,
+	policyMode conjurapi.PolicyMode,
+	dryrun bool,
+	sources []utils.PolicySource,
+	vars map[string]interface{},
+) error {
+	if policyMode == conjurapi.PolicyModePut {
+		seen := map[string]string{}
+		for _, source := range sources {
+			if other, ok := seen[source.Branch]; ok {
+				return fmt.Errorf("%s and %s would replace the same policy '%s', combine them or use !include", other, source.File, source.Branch)
+			}
+			seen[source.Branch] = source.File
+		}
+	}
+
+	// Render every policy before loading any of them, so that template errors don't leave
+	// the policies partially loaded
+	policies := make([][]byte, 0, len(sources))
+	for _, source := range sources {
+		policy, err := os.ReadFile(source.File)
+		if err != nil {
+			return err
+		}
+		policy, err = utils.RenderPolicy(policy, source.File, filepath.Dir(source.File), vars)
+		if err != nil {
+			return err
+		}
+		policies = append(policies, policy)
+	}
+
+	conjurClient, err := clientFactory(cmd)
+	if err != nil {
+		return err
+	}
+
+	summary := policyLoadSummary{Policies: []policyLoadResult{}}
+	printSummary := func() error {
+		data, err := json.Marshal(summary)
+		if err != nil {
+			return err
+		}
+		if prettyData, err := utils.PrettyPrintJSON(data); err == nil {
+			data = prettyData
+		}
+		cmd.Println(string(data))
+		return nil
+	}
+
+	for i, source := range sources {
+		data, err := DryRunOrLoadPolicy(conjurClient, dryrun, policyMode, source.Branch, bytes.NewReader(policies[i]))
+		if err != nil {
+			if len(summary.Policies) > 0 {
+				printSummary()
+			}
+			return fmt.Errorf("policy '%s' from %s: %s", source.Branch, source.File, err)
+		}
+		cmd.PrintErrf("%s policy '%s' from %s\n", cmdMessage(dryrun), source.Branch, source.File)
+
+		summary.Policies = append(summary.Policies, policyLoadResult{
+			Branch:   source.Branch,
+			File:     source.File,
+			Response: data,
+		})
+
+		if !dryrun {
+			var response conjurapi.PolicyResponse
+			if err := json.Unmarshal(data, &response); err != nil {
+				return err
+			}
+			for id, role := range response.CreatedRoles {
+				if summary.CreatedRoles == nil {
+					summary.CreatedRoles = map[string]conjurapi.CreatedRole{}
+				}
+				summary.CreatedRoles[id] = role
+			}
+		}
+	}
+
+	return printSummary()
+}
+
 func fetchPolicyCommandRunner(
 	clientFactory policyClientFactoryFunc,
 ) func(*cobra.Command, []string) error {
@@ -229,23 +366,40 @@ func newPolicyCommand(clientFactory policyClientFactoryFunc) *cobra.Command {
 	policyCmd.AddCommand(newPolicyLoadCommand(clientFactory))
 	policyCmd.AddCommand(newPolicyUpdateCommand(clientFactory))
 	policyCmd.AddCommand(newPolicyReplaceCommand(clientFactory))
+	policyCmd.AddCommand(newPolicyDiffCommand(clientFactory))
+	policyCmd.AddCommand(newPolicyValidateCommand())
 
 	return policyCmd
 }
 
+// policySourcesHelp describes the inputs accepted by the policy load, update and replace commands
+const policySourcesHelp = `The --file flag accepts a policy file, "-" for stdin, a directory or a manifest:
+
+- Every YAML file of a directory tree is loaded into the branch matching its directory, relative to --branch. Files whose name starts with "_" are skipped.
+- A manifest is a YAML file with a "policies" list, whose entries have a "file", relative to the manifest, a "branch", relative to --branch, and optional "depends_on" branches.
+
+Policies of parent branches and dependencies are loaded first, and a combined summary is printed at the end.
+
+Policy files are rendered as Go templates when --var or --var-file is given, e.g. "id: {{ .env }}-db". The "- !include path/to/file.yml" statement is replaced with the statements of the included file, relative to the including file.`
+
 func newPolicyLoadCommand(clientFactory policyClientFactoryFunc) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "load",
 		Short: "Load a policy and create resources",
 		Long: `Load a policy and create resources.
 
+` + policySourcesHelp + `
+
 Examples:
-- conjur policy load -b staging -f /policy/staging.yml`,
+- conjur policy load -b staging -f /policy/staging.yml
+- conjur policy load -b root -f /policy --var env=staging`,
 		SilenceUsage: true,
 		RunE:         loadPolicyCommandRunner(clientFactory, conjurapi.PolicyModePost),
 	}
-	cmd.PersistentFlags().StringP("file", "f", "", "The policy file to load")
+	cmd.PersistentFlags().StringP("file", "f", "", "The policy file, directory or manifest to load")
 	cmd.PersistentFlags().BoolP("dry-run", "", false, "Dry run mode (input policy will be validated without applying the changes)")
+	cmd.PersistentFlags().StringArray("var", []string{}, "A template variable, as key=value (can be repeated)")
+	cmd.PersistentFlags().StringArray("var-file", []string{}, "A YAML or JSON file of template variables (can be repeated)")
 
 	cmd.MarkPersistentFlagRequired("file")
 
@@ -278,14 +432,19 @@ func newPolicyUpdateCommand(clientFactory policyClientFactoryFunc) *cobra.Comman
 		Short: "Update existing resources in the policy or create new resources",
 		Long: `Update existing resources in the policy or create new resources.
 
+` + policySourcesHelp + `
+
 Examples:
-- conjur policy update -b staging -f /policy/staging.yml`,
+- conjur policy update -b staging -f /policy/staging.yml
+- conjur policy update -b root -f /policy/manifest.yml --var-file staging.yml`,
 		SilenceUsage: true,
 		RunE:         loadPolicyCommandRunner(clientFactory, conjurapi.PolicyModePatch),
 	}
 
-	cmd.PersistentFlags().StringP("file", "f", "", "The policy file to load")
+	cmd.PersistentFlags().StringP("file", "f", "", "The policy file, directory or manifest to load")
 	cmd.PersistentFlags().BoolP("dry-run", "", false, "Dry run mode (input policy will be validated without applying the changes)")
+	cmd.PersistentFlags().StringArray("var", []string{}, "A template variable, as key=value (can be repeated)")
+	cmd.PersistentFlags().StringArray("var-file", []string{}, "A YAML or JSON file of template variables (can be repeated)")
 
 	cmd.MarkPersistentFlagRequired("file")
 
@@ -298,14 +457,19 @@ func newPolicyReplaceCommand(clientFactory policyClientFactoryFunc) *cobra.Comma
 		Short: "Fully replace an existing policy",
 		Long: `Fully replace an existing policy.
 
+` + policySourcesHelp + `
+
 Examples:
-- conjur policy replace -b staging -f /policy/staging.yml`,
+- conjur policy replace -b staging -f /policy/staging.yml
+- conjur policy replace -b staging -f /policy/staging.yml --var env=staging`,
 		SilenceUsage: true,
 		RunE:         loadPolicyCommandRunner(clientFactory, conjurapi.PolicyModePut),
 	}
 
-	cmd.PersistentFlags().StringP("file", "f", "", "The policy file to load")
+	cmd.PersistentFlags().StringP("file", "f", "", "The policy file, directory or manifest to load")
 	cmd.PersistentFlags().BoolP("dry-run", "", false, "Dry run mode (input policy will be validated without applying the changes)")
+	cmd.PersistentFlags().StringArray("var", []string{}, "A template variable, as key=value (can be repeated)")
+	cmd.PersistentFlags().StringArray("var-file", []string{}, "A YAML or JSON file of template variables (can be repeated)")
 
 	cmd.MarkPersistentFlagRequired("file")
 
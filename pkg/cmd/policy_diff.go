@@ -0,0 +1,287 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+const (
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiReset  = "\033[0m"
+)
+
+// policyDiffModes maps the --mode values of 'policy diff' to the policy commands they preview
+var policyDiffModes = map[string]conjurapi.PolicyMode{
+	"load":    conjurapi.PolicyModePost,
+	"update":  conjurapi.PolicyModePatch,
+	"replace": conjurapi.PolicyModePut,
+}
+
+// policyChangesError is returned by 'policy diff' when the policy would change resources, so
+// that the CLI exits with a dedicated status code
+type policyChangesError struct {
+	changes int
+}
+
+func (e policyChangesError) Error() string {
+	return fmt.Sprintf("policy would change %d resource(s)", e.changes)
+}
+
+func (e policyChangesError) ExitCode() int {
+	return 2
+}
+
+// resourceDiffLines describes a resource as a sorted list of lines, so that two versions of a
+// resource can be compared line by line
+func resourceDiffLines(resource conjurapi.Resource) []string {
+	lines := []string{}
+	if resource.Owner != "" {
+		lines = append(lines, "owner: "+resource.Owner)
+	}
+	for key, value := range resource.Annotations {
+		lines = append(lines, fmt.Sprintf("annotation %s: %s", key, value))
+	}
+	if resource.Permissions != nil {
+		for privilege, roles := range *resource.Permissions {
+			for _, role := range roles {
+				lines = append(lines, fmt.Sprintf("permission %s: %s", privilege, role))
+			}
+		}
+	}
+	if resource.Permitted != nil {
+		for privilege, resources := range *resource.Permitted {
+			for _, permitted := range resources {
+				lines = append(lines, fmt.Sprintf("permitted %s: %s", privilege, permitted))
+			}
+		}
+	}
+	if resource.Members != nil {
+		for _, member := range *resource.Members {
+			lines = append(lines, "member: "+member)
+		}
+	}
+	if resource.Memberships != nil {
+		for _, membership := range *resource.Memberships {
+			lines = append(lines, "membership: "+membership)
+		}
+	}
+	if resource.RestrictedTo != nil {
+		for _, cidr := range *resource.RestrictedTo {
+			lines = append(lines, "restricted to: "+cidr)
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// diffLines returns the lines only present in before and the lines only present in after
+func diffLines(before []string, after []string) (removed []string, added []string) {
+	beforeSet := make(map[string]struct{}, len(before))
+	for _, line := range before {
+		beforeSet[line] = struct{}{}
+	}
+	afterSet := make(map[string]struct{}, len(after))
+	for _, line := range after {
+		afterSet[line] = struct{}{}
+		if _, exists := beforeSet[line]; !exists {
+			added = append(added, line)
+		}
+	}
+	for _, line := range before {
+		if _, exists := afterSet[line]; !exists {
+			removed = append(removed, line)
+		}
+	}
+	return removed, added
+}
+
+func sortedResources(resources []conjurapi.Resource) []conjurapi.Resource {
+	sorted := append([]conjurapi.Resource{}, resources...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Id < sorted[j].Id })
+	return sorted
+}
+
+// policyDiffPrinter writes diff lines, colourised according to their sign
+type policyDiffPrinter struct {
+	out   io.Writer
+	color bool
+}
+
+func (p policyDiffPrinter) line(sign string, indent string, text string) {
+	color := ""
+	switch sign {
+	case "+":
+		color = ansiGreen
+	case "-":
+		color = ansiRed
+	case "~":
+		color = ansiYellow
+	}
+
+	if p.color && color != "" {
+		fmt.Fprintf(p.out, "%s%s %s%s%s\n", color, sign, indent, text, ansiReset)
+		return
+	}
+	fmt.Fprintf(p.out, "%s %s%s\n", sign, indent, text)
+}
+
+// printPolicyDiff renders the result of a policy dry run and returns the number of changed resources
+func printPolicyDiff(p policyDiffPrinter, response *conjurapi.DryRunPolicyResponse) int {
+	for _, resource := range sortedResources(response.Created.Items) {
+		p.line("+", "", resource.Id)
+		for _, line := range resourceDiffLines(resource) {
+			p.line("+", "    ", line)
+		}
+	}
+
+	before := make(map[string]conjurapi.Resource, len(response.Updated.Before.Items))
+	for _, resource := range response.Updated.Before.Items {
+		before[resource.Id] = resource
+	}
+	updated := 0
+	for _, resource := range sortedResources(response.Updated.After.Items) {
+		removed, added := diffLines(resourceDiffLines(before[resource.Id]), resourceDiffLines(resource))
+		if len(removed) == 0 && len(added) == 0 {
+			continue
+		}
+		updated++
+		p.line("~", "", resource.Id)
+		for _, line := range removed {
+			p.line("-", "    ", line)
+		}
+		for _, line := range added {
+			p.line("+", "    ", line)
+		}
+	}
+
+	for _, resource := range sortedResources(response.Deleted.Items) {
+		p.line("-", "", resource.Id)
+		for _, line := range resourceDiffLines(resource) {
+			p.line("-", "    ", line)
+		}
+	}
+
+	created, deleted := len(response.Created.Items), len(response.Deleted.Items)
+	if created+updated+deleted == 0 {
+		fmt.Fprintln(p.out, "No changes")
+	} else {
+		fmt.Fprintf(p.out, "\n%d to create, %d to update, %d to delete\n", created, updated, deleted)
+	}
+	return created + updated + deleted
+}
+
+func useColor(cmd *cobra.Command, colorMode string) (bool, error) {
+	switch colorMode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		file, ok := cmd.OutOrStdout().(*os.File)
+		return ok && isatty.IsTerminal(file.Fd()), nil
+	}
+	return false, errors.New("color must be one of auto, always, never")
+}
+
+func newPolicyDiffCommand(clientFactory policyClientFactoryFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Preview the changes a policy would make",
+		Long: `Preview the changes a policy would make.
+
+The policy is validated by the server in dry run mode and the resources, permissions and grants it would create, update or delete are displayed. Nothing is changed in Conjur.
+
+The --mode flag selects the command to preview: load (default), update or replace.
+
+The command exits with status 0 when the policy makes no changes, 2 when it makes changes and 1 on error, so that it can be used to gate changes in CI pipelines.
+
+Examples:
+- conjur policy diff -b staging -f /policy/staging.yml
+- conjur policy diff -b staging -f /policy/staging.yml --mode replace --color never`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			branch, err := cmd.Flags().GetString("branch")
+			if err != nil {
+				return err
+			}
+
+			file, err := cmd.Flags().GetString("file")
+			if err != nil {
+				return err
+			}
+
+			modeName, err := cmd.Flags().GetString("mode")
+			if err != nil {
+				return err
+			}
+			policyMode, ok := policyDiffModes[modeName]
+			if !ok {
+				return errors.New("mode must be one of load, update, replace")
+			}
+
+			colorMode, err := cmd.Flags().GetString("color")
+			if err != nil {
+				return err
+			}
+			color, err := useColor(cmd, colorMode)
+			if err != nil {
+				return err
+			}
+
+			var inputReader io.Reader = cmd.InOrStdin()
+			if file != "-" {
+				file, err := os.Open(file)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				inputReader = file
+			}
+
+			conjurClient, err := clientFactory(cmd)
+			if err != nil {
+				return err
+			}
+
+			response, err := conjurClient.DryRunPolicy(policyMode, branch, inputReader)
+			if err != nil {
+				return err
+			}
+
+			if len(response.Errors) > 0 {
+				for _, dryRunErr := range response.Errors {
+					cmd.PrintErrf("%s:%d:%d: %s\n", file, dryRunErr.Line, dryRunErr.Column, dryRunErr.Message)
+				}
+				return fmt.Errorf("Policy is invalid: %s", response.Status)
+			}
+
+			changes := printPolicyDiff(policyDiffPrinter{out: cmd.OutOrStdout(), color: color}, response)
+			if changes > 0 {
+				// The diff already describes the changes
+				cmd.SilenceErrors = true
+				return policyChangesError{changes: changes}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("file", "f", "", "The policy file to compare")
+	cmd.Flags().String("mode", "load", "The policy command to preview: load, update or replace")
+	cmd.Flags().String("color", "auto", "Colorize the output: auto, always or never")
+	cmd.MarkFlagRequired("file")
+
+	return cmd
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func dryRunPolicyResponse(response conjurapi.DryRunPolicyResponse) dryRunPolicyTestFunc {
+	return func(
+		t *testing.T,
+		mode conjurapi.PolicyMode,
+		policyBranch string,
+		policySrc io.Reader,
+	) (*conjurapi.DryRunPolicyResponse, error) {
+		return &response, nil
+	}
+}
+
+var policyDiffCmdTestCases = []policyCmdTestCase{
+	{
+		name: "diff subcommand help",
+		args: []string{"policy", "diff", "--help"},
+		assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+			assert.Contains(t, stdout, "HELP LONG")
+		},
+	},
+	{
+		name: "diff subcommand missing file",
+		args: []string{"policy", "diff", "-b", "meow"},
+		assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+			assert.Contains(t, stderr, "Error: required flag(s) \"file\" not set\n")
+		},
+	},
+	{
+		name: "diff subcommand invalid mode",
+		args: []string{"policy", "diff", "-b", "meow", "-f", "-", "--mode", "delete"},
+		assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+			assert.Contains(t, stderr, "Error: mode must be one of load, update, replace\n")
+		},
+	},
+	{
+		name: "diff subcommand invalid color",
+		args: []string{"policy", "diff", "-b", "meow", "-f", "-", "--color", "rainbow"},
+		assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+			assert.Contains(t, stderr, "Error: color must be one of auto, always, never\n")
+		},
+	},
+	{
+		name: "diff subcommand policy mode",
+		args: []string{"policy", "diff", "-b", "meow", "-f", "-", "--mode", "replace"},
+		dryRunPolicy: func(
+			t *testing.T,
+			mode conjurapi.PolicyMode,
+			policyBranch string,
+			policySrc io.Reader,
+		) (*conjurapi.DryRunPolicyResponse, error) {
+			assert.Equal(t, conjurapi.PolicyModePut, mode)
+			assert.Equal(t, "meow", policyBranch)
+			return &conjurapi.DryRunPolicyResponse{Status: "Valid YAML"}, nil
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+			assert.NoError(t, err)
+			assert.Equal(t, "No changes\n", stdout)
+		},
+	},
+	{
+		name: "diff subcommand with changes",
+		args: []string{"policy", "diff", "-b", "meow", "-f", "-", "--color", "never"},
+		dryRunPolicy: dryRunPolicyResponse(conjurapi.DryRunPolicyResponse{
+			Status: "Valid YAML",
+			Created: conjurapi.DryRunPolicyResponseItems{Items: []conjurapi.Resource{
+				{
+					Id:          "dev:variable:meow/password",
+					Owner:       "dev:policy:meow",
+					Annotations: map[string]string{"description": "A password"},
+				},
+			}},
+			Updated: conjurapi.DryRunPolicyUpdates{
+				Before: conjurapi.DryRunPolicyResponseItems{Items: []conjurapi.Resource{
+					{
+						Id:          "dev:host:meow/app",
+						Owner:       "dev:policy:meow",
+						Memberships: &[]string{"dev:layer:meow/old"},
+					},
+					{
+						Id:    "dev:user:alice",
+						Owner: "dev:user:admin",
+					},
+				}},
+				After: conjurapi.DryRunPolicyResponseItems{Items: []conjurapi.Resource{
+					{
+						Id:          "dev:host:meow/app",
+						Owner:       "dev:policy:meow",
+						Memberships: &[]string{"dev:layer:meow/new"},
+					},
+					{
+						Id:    "dev:user:alice",
+						Owner: "dev:user:admin",
+					},
+				}},
+			},
+			Deleted: conjurapi.DryRunPolicyResponseItems{Items: []conjurapi.Resource{
+				{
+					Id:          "dev:variable:meow/old",
+					Permissions: &map[string][]string{"read": {"dev:host:meow/app"}},
+				},
+			}},
+		}),
+		assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+			assert.Equal(t, `+ dev:variable:meow/password
++     annotation description: A password
++     owner: dev:policy:meow
+~ dev:host:meow/app
+-     membership: dev:layer:meow/old
++     membership: dev:layer:meow/new
+- dev:variable:meow/old
+-     permission read: dev:host:meow/app
+
+1 to create, 1 to update, 1 to delete
+`, stdout)
+			assert.Equal(t, 2, err.(exitCodeError).ExitCode())
+			assert.Empty(t, stderr)
+		},
+	},
+	{
+		name: "diff subcommand with invalid policy",
+		args: []string{"policy", "diff", "-b", "meow", "-f", "-"},
+		dryRunPolicy: dryRunPolicyResponse(conjurapi.DryRunPolicyResponse{
+			Status: "Invalid YAML",
+			Errors: []conjurapi.DryRunError{
+				{Line: 3, Column: 5, Message: "Unrecognized data type '!vriable'"},
+			},
+		}),
+		assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+			assert.Contains(t, stderr, "-:3:5: Unrecognized data type '!vriable'\n")
+			assert.Contains(t, stderr, "Error: Policy is invalid: Invalid YAML\n")
+			assert.Empty(t, stdout)
+		},
+	},
+	{
+		name: "diff subcommand with client error",
+		args: []string{"policy", "diff", "-b", "meow", "-f", "-"},
+		dryRunPolicy: func(
+			t *testing.T,
+			mode conjurapi.PolicyMode,
+			policyBranch string,
+			policySrc io.Reader,
+		) (*conjurapi.DryRunPolicyResponse, error) {
+			return nil, errors.New("dry run failed")
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+			assert.Contains(t, stderr, "Error: dry run failed\n")
+		},
+	},
+}
+
+func TestPolicyDiffPrinterColor(t *testing.T) {
+	out := new(bytes.Buffer)
+	changes := printPolicyDiff(policyDiffPrinter{out: out, color: true}, &conjurapi.DryRunPolicyResponse{
+		Created: conjurapi.DryRunPolicyResponseItems{Items: []conjurapi.Resource{
+			{Id: "dev:variable:meow/password"},
+		}},
+		Deleted: conjurapi.DryRunPolicyResponseItems{Items: []conjurapi.Resource{
+			{Id: "dev:variable:meow/old"},
+		}},
+	})
+
+	assert.Equal(t, 2, changes)
+	assert.Contains(t, out.String(), "\033[32m+ dev:variable:meow/password\033[0m\n")
+	assert.Contains(t, out.String(), "\033[31m- dev:variable:meow/old\033[0m\n")
+}
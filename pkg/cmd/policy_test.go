@@ -5,6 +5,7 @@ import (
 	"github.com/cyberark/conjur-cli-go/pkg/utils"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -80,6 +81,19 @@ type policyCmdTestCase struct {
 }
 
 var policyCmdTestCases = []policyCmdTestCase{
+	{
+		name: "replace subcommand with several files for a branch",
+		args: []string{"policy", "replace", "-b", "root", "-f", "$TMPDIR/policies"},
+		beforeTest: func(t *testing.T, pathToTmpfile string) {
+			writePolicyFiles(t, filepath.Join(filepath.Dir(pathToTmpfile), "policies"), map[string]string{
+				"a.yml": "- !host a\n",
+				"b.yml": "- !host b\n",
+			})
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+			assert.Contains(t, stderr, "b.yml would replace the same policy 'root', combine them or use !include\n")
+		},
+	},
 	{
 		name: "policy command help",
 		args: []string{"policy", "--help"},
@@ -238,6 +252,15 @@ var policyCmdTestCases = []policyCmdTestCase{
 	},
 }
 
+func writePolicyFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
 func sharedLoadPolicyCmdTestCases(
 	subcommand string,
 	expectedMode conjurapi.PolicyMode,
@@ -338,6 +361,102 @@ func sharedLoadPolicyCmdTestCases(
 				assert.NoError(t, err)
 			},
 		},
+		{
+			name: fmt.Sprintf("%s subcommand with template variables and includes", subcommand),
+			args: []string{"policy", subcommand, "-b", "meow", "-f", "$TMPFILE", "--var", "env=staging"},
+			beforeTest: func(t *testing.T, pathToTmpfile string) {
+				writePolicyFiles(t, filepath.Dir(pathToTmpfile), map[string]string{
+					"file":        "- !variable {{ .env }}/password\n- !include _common.yml\n",
+					"_common.yml": "- !group {{ .env }}-admins\n",
+				})
+			},
+			loadPolicy: func(
+				t *testing.T,
+				mode conjurapi.PolicyMode,
+				policyBranch string,
+				policySrc io.Reader,
+			) (*conjurapi.PolicyResponse, error) {
+				policyContents, err := io.ReadAll(policySrc)
+				assert.NoError(t, err)
+				assert.Equal(t, "- !variable staging/password\n- !group staging-admins\n", string(policyContents))
+
+				return &conjurapi.PolicyResponse{}, nil
+			},
+			assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+				assert.NoError(t, err)
+				assert.Contains(t, stderr, "Loaded policy 'meow'")
+			},
+		},
+		{
+			name: fmt.Sprintf("%s subcommand with invalid template variable", subcommand),
+			args: []string{"policy", subcommand, "-b", "meow", "-f", "-", "--var", "env"},
+			assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+				assert.Contains(t, stderr, "Error: invalid variable \"env\", must be key=value\n")
+			},
+		},
+		{
+			name: fmt.Sprintf("%s subcommand from directory", subcommand),
+			args: []string{"policy", subcommand, "-b", "root", "-f", "$TMPDIR/policies"},
+			beforeTest: func(t *testing.T, pathToTmpfile string) {
+				writePolicyFiles(t, filepath.Join(filepath.Dir(pathToTmpfile), "policies"), map[string]string{
+					"apps/web/web.yml": "- !host web\n",
+					"root.yml":         "- !policy apps\n",
+					"apps/apps.yml":    "- !policy web\n",
+				})
+			},
+			loadPolicy: func(
+				t *testing.T,
+				mode conjurapi.PolicyMode,
+				policyBranch string,
+				policySrc io.Reader,
+			) (*conjurapi.PolicyResponse, error) {
+				assert.Equal(t, expectedMode, mode)
+				return &conjurapi.PolicyResponse{
+					CreatedRoles: map[string]conjurapi.CreatedRole{
+						"dev:policy:" + policyBranch: {ID: "dev:policy:" + policyBranch, APIKey: "key"},
+					},
+					Version: 1,
+				}, nil
+			},
+			assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+				assert.NoError(t, err)
+				dir := filepath.Join(pathToTmpDir, "policies")
+				assert.Equal(t, "Loaded policy 'root' from "+filepath.Join(dir, "root.yml")+"\n"+
+					"Loaded policy 'apps' from "+filepath.Join(dir, "apps", "apps.yml")+"\n"+
+					"Loaded policy 'apps/web' from "+filepath.Join(dir, "apps", "web", "web.yml")+"\n", stderr)
+				assert.Contains(t, stdout, `"branch": "apps/web"`)
+				assert.Contains(t, stdout, `"dev:policy:apps/web": {`)
+				assert.Contains(t, stdout, `"dev:policy:root": {`)
+			},
+		},
+		{
+			name: fmt.Sprintf("%s subcommand from manifest with failure", subcommand),
+			args: []string{"policy", subcommand, "-b", "staging", "-f", "$TMPFILE"},
+			beforeTest: func(t *testing.T, pathToTmpfile string) {
+				writePolicyFiles(t, filepath.Dir(pathToTmpfile), map[string]string{
+					"file":    "policies:\n  - branch: web\n    file: web.yml\n    depends_on: [db]\n  - branch: db\n    file: db.yml\n",
+					"web.yml": "- !host web\n",
+					"db.yml":  "- !variable password\n",
+				})
+			},
+			loadPolicy: func(
+				t *testing.T,
+				mode conjurapi.PolicyMode,
+				policyBranch string,
+				policySrc io.Reader,
+			) (*conjurapi.PolicyResponse, error) {
+				if policyBranch == "staging/web" {
+					return nil, fmt.Errorf("%s", "some error")
+				}
+				assert.Equal(t, "staging/db", policyBranch)
+				return &conjurapi.PolicyResponse{Version: 2}, nil
+			},
+			assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+				assert.Contains(t, stdout, `"branch": "staging/db"`)
+				assert.NotContains(t, stdout, `"branch": "staging/web"`)
+				assert.Contains(t, stderr, "Error: policy 'staging/web' from "+filepath.Join(pathToTmpDir, "web.yml")+": some error\n")
+			},
+		},
 		{
 			name: fmt.Sprintf("%s subcommand response error", subcommand),
 			args: []string{"policy", subcommand, "-b", "meow", "-f", "-"},
@@ -432,6 +551,8 @@ func TestPolicyCmd(t *testing.T) {
 	var allTests []policyCmdTestCase
 	for _, cases := range [][]policyCmdTestCase{
 		policyCmdTestCases,
+		policyDiffCmdTestCases,
+		policyValidateCmdTestCases,
 		sharedLoadPolicyCmdTestCases(
 			"load",
 			conjurapi.PolicyModePost,
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cyberark/conjur-cli-go/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+func newPolicyValidateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check a policy file locally",
+		Long: `Check a policy file locally, without connecting to Conjur.
+
+The policy is checked for unknown tags, missing or unknown fields, duplicate IDs and invalid privileges. Problems are reported with their position in the file.
+
+With --check-references, references to resources which are not defined in the file are also reported, which suits files defining everything they use rather than policies updating a branch. Relative references are then always checked, and absolute references when they point into the policy branch given with --branch.
+
+Examples:
+- conjur policy validate -f /policy/staging.yml
+- conjur policy validate -b staging -f /policy/staging.yml --check-references`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			branch, err := cmd.Flags().GetString("branch")
+			if err != nil {
+				return err
+			}
+
+			file, err := cmd.Flags().GetString("file")
+			if err != nil {
+				return err
+			}
+
+			checkReferences, err := cmd.Flags().GetBool("check-references")
+			if err != nil {
+				return err
+			}
+
+			var data []byte
+			if file == "-" {
+				data, err = io.ReadAll(cmd.InOrStdin())
+			} else {
+				data, err = os.ReadFile(file)
+			}
+			if err != nil {
+				return err
+			}
+
+			issues, err := utils.ValidatePolicy(data, branch, checkReferences)
+			if err != nil {
+				return fmt.Errorf("%s: %s", file, err)
+			}
+
+			if len(issues) > 0 {
+				for _, issue := range issues {
+					cmd.PrintErrf("%s:%s\n", file, issue)
+				}
+				return fmt.Errorf("Policy is invalid: %d problem(s) found", len(issues))
+			}
+
+			cmd.Println("Policy is valid")
+			return nil
+		},
+	}
+
+	// Shadows the required --branch flag of the policy command, as the branch is
+	// optional when checking a policy locally
+	cmd.Flags().StringP("branch", "b", "", "The policy branch the file will be loaded into")
+	cmd.Flags().StringP("file", "f", "", "The policy file to check")
+	cmd.Flags().Bool("check-references", false, "Also report references to resources which are not defined in the file")
+	cmd.MarkFlagRequired("file")
+
+	return cmd
+}
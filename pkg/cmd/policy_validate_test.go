@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writePolicyFile(content string) func(t *testing.T, pathToTmpfile string) {
+	return func(t *testing.T, pathToTmpfile string) {
+		err := os.WriteFile(pathToTmpfile, []byte(content), 0644)
+		assert.NoError(t, err)
+	}
+}
+
+var policyValidateCmdTestCases = []policyCmdTestCase{
+	{
+		name: "validate subcommand help",
+		args: []string{"policy", "validate", "--help"},
+		assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+			assert.Contains(t, stdout, "HELP LONG")
+		},
+	},
+	{
+		name: "validate subcommand missing file",
+		args: []string{"policy", "validate"},
+		assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+			assert.Contains(t, stderr, "Error: required flag(s) \"file\" not set\n")
+		},
+	},
+	{
+		name:       "validate subcommand with valid policy",
+		args:       []string{"policy", "validate", "-f", "$TMPFILE"},
+		beforeTest: writePolicyFile("- !variable password\n- !permit\n  role: !group admins\n  privileges: [ read ]\n  resource: !variable password\n- !group admins\n"),
+		assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+			assert.NoError(t, err)
+			assert.Equal(t, "Policy is valid\n", stdout)
+		},
+	},
+	{
+		name:       "validate subcommand with invalid policy",
+		args:       []string{"policy", "validate", "-b", "root", "-f", "$TMPFILE", "--check-references"},
+		beforeTest: writePolicyFile("- !variable password\n- !variable password\n- !permit\n  role: !group /admins\n  privileges: [ write ]\n  resource: !variable password\n"),
+		assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+			file := pathToTmpDir + "/file"
+			assert.Contains(t, stderr, file+":2:3: duplicate ID !variable password, first defined on line 1\n")
+			assert.Contains(t, stderr, file+":4:9: !group admins is not defined in this policy\n")
+			assert.Contains(t, stderr, file+":5:17: invalid privilege \"write\"")
+			assert.Contains(t, stderr, "Error: Policy is invalid: 3 problem(s) found\n")
+		},
+	},
+	{
+		name:       "validate subcommand with references to existing resources",
+		args:       []string{"policy", "validate", "-b", "root", "-f", "$TMPFILE"},
+		beforeTest: writePolicyFile("- !permit\n  role: !group /admins\n  privileges: [ read ]\n  resource: !variable password\n"),
+		assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+			assert.NoError(t, err)
+			assert.Equal(t, "Policy is valid\n", stdout)
+		},
+	},
+	{
+		name:       "validate subcommand with invalid YAML",
+		args:       []string{"policy", "validate", "-f", "$TMPFILE"},
+		beforeTest: writePolicyFile("- !variable\n  id: ["),
+		assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+			assert.Contains(t, stderr, "Error: "+pathToTmpDir+"/file: yaml: line 2")
+		},
+	},
+	{
+		name: "validate subcommand missing policy file",
+		args: []string{"policy", "validate", "-f", "$TMPDIR/missing.yml"},
+		assert: func(t *testing.T, stdout, stderr string, err error, pathToTmpDir string) {
+			assert.Contains(t, stderr, "no such file or directory")
+		},
+	},
+}
@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-cli-go/pkg/clients"
+	"github.com/spf13/cobra"
+)
+
+type profileStoreFactoryFunc func() (clients.ProfileStore, error)
+
+func newProfileCmd(storeFactory profileStoreFactoryFunc) *cobra.Command {
+	profileCmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage named connection profiles",
+		Long: `Manage named connection profiles.
+
+A profile holds the details for connecting to a Conjur server, like the configuration file written by the init command. Use the global --profile flag or the CONJUR_PROFILE environment variable to run a single command against a profile, or 'conjur profile use' to switch profiles for all subsequent commands.
+
+Profiles are stored in ~/.conjur/profiles, or in the directory set in the CONJUR_PROFILES_DIR environment variable.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Print --help if called without subcommand
+			cmd.Help()
+		},
+	}
+
+	profileCmd.AddCommand(newProfileAddCmd(storeFactory))
+	profileCmd.AddCommand(newProfileListCmd(storeFactory))
+	profileCmd.AddCommand(newProfileUseCmd(storeFactory))
+	profileCmd.AddCommand(newProfileRemoveCmd(storeFactory))
+
+	return profileCmd
+}
+
+func newProfileAddCmd(storeFactory profileStoreFactoryFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "Add a connection profile",
+		Long: `Add a connection profile
+
+This command requires a [name], and the account and URL of the Conjur server. Credentials cached by 'conjur login' are stored under a keychain namespace, which defaults to the profile name, or in a <name>.netrc file next to the profile when they are stored in a file, so that each profile keeps its own credentials.
+
+To fetch and trust the server's certificate interactively, use 'conjur init --profile [name]' instead.
+
+Examples:
+
+- conjur profile add dev -a dev -u https://conjur-dev.example.com -c /path/to/dev.pem
+- conjur profile add prod -a prod -u https://conjur.example.com -t ldap --service-id corp --use`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				cmd.Help()
+				return nil
+			}
+
+			name := args[0]
+			if err := clients.ValidateProfileName(name); err != nil {
+				return err
+			}
+
+			account, err := cmd.Flags().GetString("account")
+			if err != nil {
+				return err
+			}
+			applianceURL, err := cmd.Flags().GetString("url")
+			if err != nil {
+				return err
+			}
+			authnType, err := cmd.Flags().GetString("authn-type")
+			if err != nil {
+				return err
+			}
+			serviceID, err := cmd.Flags().GetString("service-id")
+			if err != nil {
+				return err
+			}
+			caCert, err := cmd.Flags().GetString("ca-cert")
+			if err != nil {
+				return err
+			}
+			keychainNamespace, err := cmd.Flags().GetString("keychain-namespace")
+			if err != nil {
+				return err
+			}
+			forceNetrc, err := cmd.Flags().GetBool("force-netrc")
+			if err != nil {
+				return err
+			}
+			force, err := cmd.Flags().GetBool("force")
+			if err != nil {
+				return err
+			}
+			use, err := cmd.Flags().GetBool("use")
+			if err != nil {
+				return err
+			}
+
+			if keychainNamespace == "" {
+				keychainNamespace = name
+			}
+
+			config := conjurapi.Config{
+				Account:           account,
+				ApplianceURL:      applianceURL,
+				AuthnType:         authnType,
+				ServiceID:         serviceID,
+				KeychainNamespace: keychainNamespace,
+			}
+			config.SetKeychainNamespaceResolved(true)
+
+			if forceNetrc {
+				config.CredentialStorage = conjurapi.CredentialStorageFile
+			}
+
+			if caCert != "" {
+				path, err := filepath.Abs(caCert)
+				if err != nil {
+					return err
+				}
+				config.SSLCertPath = path
+			}
+
+			store, err := storeFactory()
+			if err != nil {
+				return err
+			}
+			// Profiles of the same Conjur server would otherwise share the credentials of the
+			// default .netrc file
+			config.NetRCPath = store.NetRCPath(name)
+
+			if err := config.Validate(); err != nil {
+				return err
+			}
+
+			if store.Exists(name) && !force {
+				return fmt.Errorf("Profile %q already exists. Use --force to overwrite it", name)
+			}
+
+			if err := store.Save(name, config); err != nil {
+				return err
+			}
+			cmd.Printf("Added profile %s\n", name)
+
+			if use {
+				if err := store.SetCurrent(name); err != nil {
+					return err
+				}
+				cmd.Printf("Using profile %s\n", name)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("account", "a", "", "Conjur organization account name")
+	cmd.Flags().StringP("url", "u", "", "URL of the Conjur service")
+	cmd.Flags().StringP("authn-type", "t", "", "Authentication type to use")
+	cmd.Flags().String("service-id", "", "Service ID if using alternative authentication type")
+	cmd.Flags().StringP("ca-cert", "c", "", "Conjur SSL certificate")
+	cmd.Flags().String("keychain-namespace", "", "Namespace of the credentials cached in the OS-native keystore (defaults to the profile name)")
+	cmd.Flags().Bool("force-netrc", false, "Use a file-based credential storage rather than OS-native keystore (for compatibility with Summon)")
+	cmd.Flags().Bool("force", false, "Overwrite an existing profile with the same name")
+	cmd.Flags().Bool("use", false, "Use the profile for subsequent commands")
+	cmd.MarkFlagRequired("account")
+	cmd.MarkFlagRequired("url")
+
+	return cmd
+}
+
+func newProfileListCmd(storeFactory profileStoreFactoryFunc) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List connection profiles",
+		Long: `List connection profiles
+
+The profile in use is marked with an asterisk.
+
+Examples:
+
+- conjur profile list
+- conjur profile list --output table`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := storeFactory()
+			if err != nil {
+				return err
+			}
+
+			names, err := store.Names()
+			if err != nil {
+				return err
+			}
+
+			current, err := store.Current()
+			if err != nil {
+				return err
+			}
+
+			profiles := make([]map[string]interface{}, 0, len(names))
+			for _, name := range names {
+				config, err := store.Load(name)
+				if err != nil {
+					return err
+				}
+				profiles = append(profiles, map[string]interface{}{
+					"name":          name,
+					"current":       name == current,
+					"account":       config.Account,
+					"appliance_url": config.ApplianceURL,
+					"authn_type":    config.AuthnType,
+				})
+			}
+
+			return printResult(cmd, profiles, func() error {
+				for _, name := range names {
+					marker := " "
+					if name == current {
+						marker = "*"
+					}
+					cmd.Printf("%s %s\n", marker, name)
+				}
+				return nil
+			})
+		},
+	}
+}
+
+func newProfileUseCmd(storeFactory profileStoreFactoryFunc) *cobra.Command {
+	return &cobra.Command{
+		Use:   "use",
+		Short: "Use a connection profile for subsequent commands",
+		Long: `Use a connection profile for subsequent commands
+
+This command requires a [name]. The selected profile is ignored when the CONJURRC environment variable is set.
+
+Examples:
+
+- conjur profile use staging`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				cmd.Help()
+				return nil
+			}
+
+			name := args[0]
+
+			store, err := storeFactory()
+			if err != nil {
+				return err
+			}
+
+			if err := store.SetCurrent(name); err != nil {
+				return err
+			}
+
+			cmd.Printf("Using profile %s\n", name)
+			return nil
+		},
+	}
+}
+
+func newProfileRemoveCmd(storeFactory profileStoreFactoryFunc) *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove",
+		Short: "Remove a connection profile",
+		Long: `Remove a connection profile
+
+This command requires a [name]. Credentials cached for the profile are not deleted, run 'conjur logout --profile [name]' first to delete them.
+
+Examples:
+
+- conjur profile remove dev`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				cmd.Help()
+				return nil
+			}
+
+			name := args[0]
+
+			store, err := storeFactory()
+			if err != nil {
+				return err
+			}
+
+			if err := store.Remove(name); err != nil {
+				return err
+			}
+
+			cmd.Printf("Removed profile %s\n", name)
+			return nil
+		},
+	}
+}
+
+func init() {
+	profileCmd := newProfileCmd(clients.DefaultProfileStore)
+	rootCmd.AddCommand(profileCmd)
+}
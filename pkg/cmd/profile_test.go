@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-cli-go/pkg/clients"
+	"github.com/stretchr/testify/assert"
+)
+
+var profileCmdTestCases = []struct {
+	name       string
+	args       []string
+	beforeTest func(t *testing.T, store clients.ProfileStore)
+	assert     func(t *testing.T, store clients.ProfileStore, stdout string, stderr string, err error)
+}{
+	{
+		name: "profile command help",
+		args: []string{"profile", "--help"},
+		assert: func(t *testing.T, store clients.ProfileStore, stdout, stderr string, err error) {
+			assert.Contains(t, stdout, "HELP LONG")
+		},
+	},
+	{
+		name: "add profile",
+		args: []string{"profile", "add", "dev", "-a", "dev", "-u", "https://conjur-dev", "-t", "ldap", "--service-id", "corp"},
+		assert: func(t *testing.T, store clients.ProfileStore, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, "Added profile dev\n", stdout)
+
+			config, err := store.Load("dev")
+			assert.NoError(t, err)
+			assert.Equal(t, "dev", config.Account)
+			assert.Equal(t, "https://conjur-dev", config.ApplianceURL)
+			assert.Equal(t, "ldap", config.AuthnType)
+			assert.Equal(t, "corp", config.ServiceID)
+			assert.Equal(t, "dev", config.KeychainNamespace)
+			assert.Equal(t, store.NetRCPath("dev"), config.NetRCPath)
+
+			current, _ := store.Current()
+			assert.Empty(t, current)
+		},
+	},
+	{
+		name: "add profile and use it",
+		args: []string{"profile", "add", "prod", "-a", "prod", "-u", "https://conjur-prod", "--keychain-namespace", "production", "--force-netrc", "--use"},
+		assert: func(t *testing.T, store clients.ProfileStore, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, "Added profile prod\nUsing profile prod\n", stdout)
+
+			config, err := store.Load("prod")
+			assert.NoError(t, err)
+			assert.Equal(t, "production", config.KeychainNamespace)
+			assert.Equal(t, conjurapi.CredentialStorageFile, config.CredentialStorage)
+
+			current, _ := store.Current()
+			assert.Equal(t, "prod", current)
+		},
+	},
+	{
+		name: "add existing profile",
+		args: []string{"profile", "add", "dev", "-a", "dev2", "-u", "https://conjur-dev2"},
+		beforeTest: func(t *testing.T, store clients.ProfileStore) {
+			assert.NoError(t, store.Save("dev", conjurapi.Config{Account: "dev", ApplianceURL: "https://conjur-dev"}))
+		},
+		assert: func(t *testing.T, store clients.ProfileStore, stdout, stderr string, err error) {
+			assert.Contains(t, stderr, "Error: Profile \"dev\" already exists. Use --force to overwrite it\n")
+		},
+	},
+	{
+		name: "add existing profile with force",
+		args: []string{"profile", "add", "dev", "-a", "dev2", "-u", "https://conjur-dev2", "--force"},
+		beforeTest: func(t *testing.T, store clients.ProfileStore) {
+			assert.NoError(t, store.Save("dev", conjurapi.Config{Account: "dev", ApplianceURL: "https://conjur-dev"}))
+		},
+		assert: func(t *testing.T, store clients.ProfileStore, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			config, _ := store.Load("dev")
+			assert.Equal(t, "dev2", config.Account)
+		},
+	},
+	{
+		name: "add profile with invalid name",
+		args: []string{"profile", "add", "../dev", "-a", "dev", "-u", "https://conjur-dev"},
+		assert: func(t *testing.T, store clients.ProfileStore, stdout, stderr string, err error) {
+			assert.Contains(t, stderr, "Error: Invalid profile name \"../dev\"")
+		},
+	},
+	{
+		name: "add profile with invalid configuration",
+		args: []string{"profile", "add", "dev", "-a", "dev", "-u", "https://conjur-dev", "-t", "ldap"},
+		assert: func(t *testing.T, store clients.ProfileStore, stdout, stderr string, err error) {
+			assert.Contains(t, stderr, "Must specify a ServiceID when using ldap")
+			assert.False(t, store.Exists("dev"))
+		},
+	},
+	{
+		name: "add profile missing required flags",
+		args: []string{"profile", "add", "dev"},
+		assert: func(t *testing.T, store clients.ProfileStore, stdout, stderr string, err error) {
+			assert.Contains(t, stderr, "Error: required flag(s) \"account\", \"url\" not set\n")
+		},
+	},
+	{
+		name: "list profiles",
+		args: []string{"profile", "list"},
+		beforeTest: func(t *testing.T, store clients.ProfileStore) {
+			assert.NoError(t, store.Save("dev", conjurapi.Config{Account: "dev", ApplianceURL: "https://conjur-dev"}))
+			assert.NoError(t, store.Save("prod", conjurapi.Config{Account: "prod", ApplianceURL: "https://conjur-prod"}))
+			assert.NoError(t, store.SetCurrent("prod"))
+		},
+		assert: func(t *testing.T, store clients.ProfileStore, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, "  dev\n* prod\n", stdout)
+		},
+	},
+	{
+		name: "list profiles as table",
+		args: []string{"profile", "list", "--output", "table"},
+		beforeTest: func(t *testing.T, store clients.ProfileStore) {
+			assert.NoError(t, store.Save("dev", conjurapi.Config{Account: "dev", ApplianceURL: "https://conjur-dev"}))
+			assert.NoError(t, store.SetCurrent("dev"))
+		},
+		assert: func(t *testing.T, store clients.ProfileStore, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, "ACCOUNT  APPLIANCE_URL       AUTHN_TYPE  CURRENT  NAME\n"+
+				"dev      https://conjur-dev              true     dev\n", stdout)
+		},
+	},
+	{
+		name: "use profile",
+		args: []string{"profile", "use", "dev"},
+		beforeTest: func(t *testing.T, store clients.ProfileStore) {
+			assert.NoError(t, store.Save("dev", conjurapi.Config{Account: "dev", ApplianceURL: "https://conjur-dev"}))
+		},
+		assert: func(t *testing.T, store clients.ProfileStore, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, "Using profile dev\n", stdout)
+			current, _ := store.Current()
+			assert.Equal(t, "dev", current)
+		},
+	},
+	{
+		name: "use missing profile",
+		args: []string{"profile", "use", "dev"},
+		assert: func(t *testing.T, store clients.ProfileStore, stdout, stderr string, err error) {
+			assert.Contains(t, stderr, "Error: Profile \"dev\" does not exist\n")
+		},
+	},
+	{
+		name: "use missing name",
+		args: []string{"profile", "use"},
+		assert: func(t *testing.T, store clients.ProfileStore, stdout, stderr string, err error) {
+			assert.Contains(t, stdout, "HELP LONG")
+		},
+	},
+	{
+		name: "remove profile",
+		args: []string{"profile", "remove", "dev"},
+		beforeTest: func(t *testing.T, store clients.ProfileStore) {
+			assert.NoError(t, store.Save("dev", conjurapi.Config{Account: "dev", ApplianceURL: "https://conjur-dev"}))
+			assert.NoError(t, store.SetCurrent("dev"))
+		},
+		assert: func(t *testing.T, store clients.ProfileStore, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, "Removed profile dev\n", stdout)
+			assert.False(t, store.Exists("dev"))
+			current, _ := store.Current()
+			assert.Empty(t, current)
+		},
+	},
+	{
+		name: "remove missing profile",
+		args: []string{"profile", "remove", "dev"},
+		assert: func(t *testing.T, store clients.ProfileStore, stdout, stderr string, err error) {
+			assert.Contains(t, stderr, "Error: Profile \"dev\" does not exist\n")
+		},
+	},
+}
+
+func TestProfileCmd(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range profileCmdTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := clients.ProfileStore{Dir: filepath.Join(t.TempDir(), "profiles")}
+			if tc.beforeTest != nil {
+				tc.beforeTest(t, store)
+			}
+
+			cmd := newProfileCmd(func() (clients.ProfileStore, error) {
+				return store, nil
+			})
+
+			stdout, stderr, err := executeCommandForTest(t, cmd, tc.args...)
+			tc.assert(t, store, stdout, stderr, err)
+		})
+	}
+}
+
+func TestInitCmdWithProfile(t *testing.T) {
+	t.Setenv(clients.ProfileEnvVar, "")
+	store := clients.ProfileStore{Dir: filepath.Join(t.TempDir(), "profiles")}
+
+	cmd := newInitCommand(initCmdFuncs{
+		ProfileStore: func() (clients.ProfileStore, error) {
+			return store, nil
+		},
+	})
+
+	stdout, _, err := executeCommandForTest(t, cmd, "init", "--profile", "staging", "-u=http://host", "-a=test-account", "-i")
+	assert.NoError(t, err)
+	assert.Contains(t, stdout, "Wrote configuration to "+store.Path("staging"))
+
+	config, err := store.Load("staging")
+	assert.NoError(t, err)
+	assert.Equal(t, "test-account", config.Account)
+	assert.Equal(t, "http://host", config.ApplianceURL)
+	assert.Equal(t, "staging", config.KeychainNamespace)
+	assert.Equal(t, store.NetRCPath("staging"), config.NetRCPath)
+
+	_, err = os.Stat(filepath.Join(store.Dir, "staging.pem"))
+	assert.True(t, os.IsNotExist(err))
+}
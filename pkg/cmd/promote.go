@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-cli-go/pkg/clients"
+	"github.com/cyberark/conjur-cli-go/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+type promoteClient interface {
+	policyClient
+	GetConfig() conjurapi.Config
+	Resources(filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error)
+	RetrieveBatchSecretsSafe(variableIDs []string) (map[string][]byte, error)
+	AddSecret(variableID string, secretValue string) error
+}
+
+type promoteClientFactoryFunc func(cmd *cobra.Command, profile string) (promoteClient, error)
+
+func promoteClientFactory(cmd *cobra.Command, profile string) (promoteClient, error) {
+	return clients.AuthenticatedConjurClientForProfile(cmd, profile)
+}
+
+// promoteChunkSize is the number of variable values fetched per request when promoting values
+const promoteChunkSize = 100
+
+// parentBranch returns the branch into which the effective policy of a branch is loaded, as the
+// effective policy declares the branch itself
+func parentBranch(branch string) string {
+	parent := path.Dir(strings.Trim(branch, "/"))
+	if parent == "." {
+		return "root"
+	}
+	return parent
+}
+
+// promoteVariables copies the values of the variables of a policy branch to the target, renaming
+// them with the rewrite rules, and returns the number of values copied
+func promoteVariables(cmd *cobra.Command, source promoteClient, target promoteClient, branch string, rules []utils.RewriteRule) (int, error) {
+	ids := []string{}
+	filter := conjurapi.ResourceFilter{Kind: "variable"}
+	err := forEachResourcePage(source, filter, 1000, func(page []map[string]interface{}) error {
+		for _, resource := range page {
+			if id, _ := resource["id"].(string); id != "" && variableInBranch(id, branch) {
+				ids = append(ids, variableIDFromResourceID(id))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	sort.Strings(ids)
+
+	copied, failed := 0, 0
+	for start := 0; start < len(ids); start += promoteChunkSize {
+		end := start + promoteChunkSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		values, err := retrieveVariableChunk(cmd, source, ids[start:end])
+		if err != nil {
+			return copied, err
+		}
+
+		chunk := make([]string, 0, len(values))
+		for fullID := range values {
+			chunk = append(chunk, fullID)
+		}
+		sort.Strings(chunk)
+
+		for _, fullID := range chunk {
+			id := variableIDFromResourceID(fullID)
+			targetID := utils.RewriteID(id, rules)
+			if err := target.AddSecret(targetID, string(values[fullID])); err != nil {
+				failed++
+				cmd.PrintErrf("Failed %s: %s\n", targetID, err)
+				continue
+			}
+			copied++
+		}
+	}
+
+	if failed > 0 {
+		return copied, fmt.Errorf("%d of %d variable(s) failed", failed, copied+failed)
+	}
+	return copied, nil
+}
+
+func newPromoteCmd(clientFactory promoteClientFactoryFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "promote",
+		Short: "Copy a policy branch and its variable values from one Conjur server to another",
+		Long: `Copy a policy branch and its variable values from one Conjur server to another.
+
+The effective policy of --branch is fetched from the server of --from-profile and loaded into the parent of the branch, or into --target-branch, on the server of --to-profile. Before loading, each --rewrite rule is applied, in order, to the IDs of the policy, replacing whole path segments only, and fully-qualified IDs are moved from the source account to the target account. Annotations and other values of the policy are left unchanged. The policy is first validated by the target server in dry run mode, and the changes it would make are displayed. With --dry-run, nothing is changed.
+
+With --secrets, the values of the variables of the branch are then copied, under IDs renamed with the same rules. Variables without a value are skipped.
+
+The --mode flag selects how the policy is loaded: load (default), update or replace.
+
+Both profiles are read from the profile store (see 'conjur profile'). Connection environment variables such as CONJUR_APPLIANCE_URL apply to both, so they should not be set.
+
+Examples:
+- conjur promote --from-profile staging --to-profile prod -b apps/myapp --dry-run
+- conjur promote --from-profile staging --to-profile prod -b apps/myapp --secrets
+- conjur promote --from-profile staging --to-profile prod -b apps/myapp --rewrite staging-db=prod-db`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fromProfile, err := cmd.Flags().GetString("from-profile")
+			if err != nil {
+				return err
+			}
+
+			toProfile, err := cmd.Flags().GetString("to-profile")
+			if err != nil {
+				return err
+			}
+
+			branch, err := cmd.Flags().GetString("branch")
+			if err != nil {
+				return err
+			}
+
+			targetBranch, err := cmd.Flags().GetString("target-branch")
+			if err != nil {
+				return err
+			}
+			if targetBranch == "" {
+				targetBranch = parentBranch(branch)
+			}
+
+			rewrites, err := cmd.Flags().GetStringArray("rewrite")
+			if err != nil {
+				return err
+			}
+			rules, err := utils.ParseRewriteRules(rewrites)
+			if err != nil {
+				return err
+			}
+
+			modeName, err := cmd.Flags().GetString("mode")
+			if err != nil {
+				return err
+			}
+			policyMode, ok := policyDiffModes[modeName]
+			if !ok {
+				return errors.New("mode must be one of load, update, replace")
+			}
+
+			withSecrets, err := cmd.Flags().GetBool("secrets")
+			if err != nil {
+				return err
+			}
+
+			dryrun, err := cmd.Flags().GetBool("dry-run")
+			if err != nil {
+				return err
+			}
+
+			colorMode, err := cmd.Flags().GetString("color")
+			if err != nil {
+				return err
+			}
+			color, err := useColor(cmd, colorMode)
+			if err != nil {
+				return err
+			}
+
+			if fromProfile == toProfile {
+				return errors.New("The source and target profiles must be different")
+			}
+
+			source, err := clientFactory(cmd, fromProfile)
+			if err != nil {
+				return fmt.Errorf("%s: %s", fromProfile, err)
+			}
+			target, err := clientFactory(cmd, toProfile)
+			if err != nil {
+				return fmt.Errorf("%s: %s", toProfile, err)
+			}
+
+			policy, err := fetchPolicy(source, branch, false, 64, 100000)
+			if err != nil {
+				return err
+			}
+			policy, err = utils.RewritePolicy(policy, rules, source.GetConfig().Account, target.GetConfig().Account)
+			if err != nil {
+				return err
+			}
+
+			response, err := target.DryRunPolicy(policyMode, targetBranch, bytes.NewReader(policy))
+			if err != nil {
+				return err
+			}
+			if len(response.Errors) > 0 {
+				for _, dryRunErr := range response.Errors {
+					cmd.PrintErrf("%s:%d:%d: %s\n", branch, dryRunErr.Line, dryRunErr.Column, dryRunErr.Message)
+				}
+				return fmt.Errorf("Policy is invalid: %s", response.Status)
+			}
+
+			cmd.Printf("Changes to %s on %s:\n\n", targetBranch, toProfile)
+			printPolicyDiff(policyDiffPrinter{out: cmd.OutOrStdout(), color: color}, response)
+			if dryrun {
+				return nil
+			}
+
+			data, err := LoadPolicy(target, policyMode, targetBranch, bytes.NewReader(policy))
+			if err != nil {
+				return err
+			}
+			if prettyData, err := utils.PrettyPrintJSON(data); err == nil {
+				data = prettyData
+			}
+			cmd.Printf("\nLoaded %s into %s on %s\n", branch, targetBranch, toProfile)
+			cmd.Println(string(data))
+
+			if withSecrets {
+				copied, err := promoteVariables(cmd, source, target, branch, rules)
+				if err != nil {
+					return err
+				}
+				cmd.Printf("Copied %d variable value(s)\n", copied)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("from-profile", "", "The profile of the Conjur server to promote from")
+	cmd.Flags().String("to-profile", "", "The profile of the Conjur server to promote to")
+	cmd.Flags().StringP("branch", "b", "", "The policy branch to promote")
+	cmd.Flags().String("target-branch", "", "The branch to load the policy into on the target (defaults to the parent of --branch)")
+	cmd.Flags().StringArray("rewrite", []string{}, "Replace path segments in the promoted IDs, as from=to (can be repeated)")
+	cmd.Flags().String("mode", "load", "How the policy is loaded: load, update or replace")
+	cmd.Flags().Bool("secrets", false, "Also copy the values of the variables of the branch")
+	cmd.Flags().Bool("dry-run", false, "Only display the changes the policy would make on the target")
+	cmd.Flags().String("color", "auto", "Colorize the output: auto, always or never")
+	cmd.MarkFlagRequired("from-profile")
+	cmd.MarkFlagRequired("to-profile")
+	cmd.MarkFlagRequired("branch")
+
+	return cmd
+}
+
+func init() {
+	promoteCmd := newPromoteCmd(promoteClientFactory)
+	rootCmd.AddCommand(promoteCmd)
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPromoteClient struct {
+	mockBackupClient
+	account string
+}
+
+func (m mockPromoteClient) GetConfig() conjurapi.Config {
+	return conjurapi.Config{Account: m.account}
+}
+
+type promoteTestClients struct {
+	source  mockPromoteClient
+	target  mockPromoteClient
+	loaded  map[string]string
+	dryRuns map[string]string
+}
+
+func newPromoteTestClients(t *testing.T) promoteTestClients {
+	clients := promoteTestClients{loaded: map[string]string{}, dryRuns: map[string]string{}}
+
+	source := newMockBackupClient(t, nil)
+	source.resources = []map[string]interface{}{
+		{"id": "staging:variable:apps/myapp/staging-db/password"},
+		{"id": "staging:variable:apps/myapp/empty"},
+		{"id": "staging:variable:apps/other/password"},
+	}
+	source.values = map[string][]byte{
+		"apps/myapp/staging-db/password": []byte("secret"),
+		"apps/other/password":            []byte("other"),
+	}
+	source.fetchPolicy = func(t *testing.T, policyBranch string, returnJSON bool, policyTreeDepth uint, sizeLimit uint) ([]byte, error) {
+		assert.Equal(t, "apps/myapp", policyBranch)
+		assert.False(t, returnJSON)
+		return []byte("- !policy\n  id: myapp\n  body:\n  - !variable staging-db/password\n  - !grant\n    role: !group /staging:group:ops\n    member: !user alice\n"), nil
+	}
+	clients.source = mockPromoteClient{mockBackupClient: source, account: "staging"}
+
+	target := newMockBackupClient(t, nil)
+	target.dryRunPolicy = func(t *testing.T, mode conjurapi.PolicyMode, policyBranch string, policySrc io.Reader) (*conjurapi.DryRunPolicyResponse, error) {
+		data, err := io.ReadAll(policySrc)
+		assert.NoError(t, err)
+		clients.dryRuns[policyBranch] = string(data)
+		return &conjurapi.DryRunPolicyResponse{
+			Status:  "Valid YAML",
+			Created: conjurapi.DryRunPolicyResponseItems{Items: []conjurapi.Resource{{Id: "prod:variable:apps/myapp/prod-db/password"}}},
+		}, nil
+	}
+	target.loadPolicy = func(t *testing.T, mode conjurapi.PolicyMode, policyBranch string, policySrc io.Reader) (*conjurapi.PolicyResponse, error) {
+		assert.Equal(t, conjurapi.PolicyModePost, mode)
+		data, err := io.ReadAll(policySrc)
+		assert.NoError(t, err)
+		clients.loaded[policyBranch] = string(data)
+		return &conjurapi.PolicyResponse{Version: 2}, nil
+	}
+	clients.target = mockPromoteClient{mockBackupClient: target, account: "prod"}
+
+	return clients
+}
+
+func newPromoteTestCmd(clients promoteTestClients) *cobra.Command {
+	return newPromoteCmd(func(cmd *cobra.Command, profile string) (promoteClient, error) {
+		switch profile {
+		case "staging":
+			return clients.source, nil
+		case "prod":
+			return clients.target, nil
+		}
+		return nil, errors.New("Profile \"" + profile + "\" does not exist")
+	})
+}
+
+func TestParentBranch(t *testing.T) {
+	assert.Equal(t, "root", parentBranch("myapp"))
+	assert.Equal(t, "apps", parentBranch("apps/myapp"))
+	assert.Equal(t, "apps/team", parentBranch("/apps/team/myapp/"))
+}
+
+func TestPromoteCmd(t *testing.T) {
+	promotedPolicy := "- !policy\n  id: myapp\n  body:\n  - !variable prod-db/password\n  - !grant\n    role: !group /prod:group:ops\n    member: !user alice\n"
+
+	t.Run("dry run", func(t *testing.T) {
+		clients := newPromoteTestClients(t)
+		stdout, _, err := executeCommandForTest(t, newPromoteTestCmd(clients),
+			"promote", "--from-profile", "staging", "--to-profile", "prod", "-b", "apps/myapp", "--rewrite", "staging-db=prod-db", "--dry-run")
+		assert.NoError(t, err)
+		assert.Equal(t, "Changes to apps on prod:\n\n+ prod:variable:apps/myapp/prod-db/password\n\n1 to create, 0 to update, 0 to delete\n", stdout)
+		assert.Equal(t, map[string]string{"apps": promotedPolicy}, clients.dryRuns)
+		assert.Empty(t, clients.loaded)
+	})
+
+	t.Run("with secrets", func(t *testing.T) {
+		clients := newPromoteTestClients(t)
+		stdout, stderr, err := executeCommandForTest(t, newPromoteTestCmd(clients),
+			"promote", "--from-profile", "staging", "--to-profile", "prod", "-b", "apps/myapp", "--rewrite", "staging-db=prod-db", "--secrets")
+		assert.NoError(t, err)
+		assert.Contains(t, stdout, "Loaded apps/myapp into apps on prod\n")
+		assert.Contains(t, stdout, "Copied 1 variable value(s)\n")
+		assert.Contains(t, stderr, "Skipped apps/myapp/empty")
+		assert.Equal(t, map[string]string{"apps": promotedPolicy}, clients.loaded)
+		assert.Equal(t, map[string]string{"apps/myapp/prod-db/password": "secret"}, clients.target.added)
+	})
+
+	t.Run("invalid policy", func(t *testing.T) {
+		clients := newPromoteTestClients(t)
+		clients.target.dryRunPolicy = func(t *testing.T, mode conjurapi.PolicyMode, policyBranch string, policySrc io.Reader) (*conjurapi.DryRunPolicyResponse, error) {
+			return &conjurapi.DryRunPolicyResponse{Status: "Invalid YAML", Errors: []conjurapi.DryRunError{{Line: 4, Column: 3, Message: "Group 'ops' not found"}}}, nil
+		}
+		_, stderr, err := executeCommandForTest(t, newPromoteTestCmd(clients),
+			"promote", "--from-profile", "staging", "--to-profile", "prod", "-b", "apps/myapp")
+		assert.EqualError(t, err, "Policy is invalid: Invalid YAML")
+		assert.Contains(t, stderr, "apps/myapp:4:3: Group 'ops' not found")
+		assert.Empty(t, clients.loaded)
+	})
+
+	t.Run("invalid flags", func(t *testing.T) {
+		clients := newPromoteTestClients(t)
+		_, _, err := executeCommandForTest(t, newPromoteTestCmd(clients),
+			"promote", "--from-profile", "prod", "--to-profile", "prod", "-b", "apps/myapp")
+		assert.EqualError(t, err, "The source and target profiles must be different")
+
+		_, _, err = executeCommandForTest(t, newPromoteTestCmd(clients),
+			"promote", "--from-profile", "staging", "--to-profile", "qa", "-b", "apps/myapp")
+		assert.EqualError(t, err, "qa: Profile \"qa\" does not exist")
+
+		_, _, err = executeCommandForTest(t, newPromoteTestCmd(clients),
+			"promote", "--from-profile", "staging", "--to-profile", "prod", "-b", "apps/myapp", "--rewrite", "staging")
+		assert.EqualError(t, err, "invalid rewrite rule \"staging\", expected from=to")
+	})
+}
@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-cli-go/pkg/clients"
+	"github.com/cyberark/conjur-cli-go/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+type reportClient interface {
+	Resources(filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error)
+	PermittedRoles(resourceID, privilege string) ([]string, error)
+	RoleMembers(roleID string) ([]map[string]interface{}, error)
+}
+
+type reportClientFactoryFunc func(*cobra.Command) (reportClient, error)
+
+func reportClientFactory(cmd *cobra.Command) (reportClient, error) {
+	return clients.AuthenticatedConjurClientForCommand(cmd)
+}
+
+// reportNow returns the time recorded in reports, replaced in tests
+var reportNow = time.Now
+
+// accessReportFormats lists the formats of 'report access'
+var accessReportFormats = []string{"csv", "html", "json"}
+
+// accessReportEntry is a user or host holding privileges on a variable, directly or through the
+// groups, layers and policies listed in Via
+type accessReportEntry struct {
+	Variable   string   `json:"variable"`
+	Role       string   `json:"role"`
+	Kind       string   `json:"kind"`
+	Privileges []string `json:"privileges"`
+	Via        []string `json:"via"`
+}
+
+type accessReport struct {
+	Branch      string              `json:"branch"`
+	GeneratedAt string              `json:"generated_at"`
+	Privileges  []string            `json:"privileges"`
+	Entries     []accessReportEntry `json:"entries"`
+}
+
+// isIdentityKind reports whether roles of a kind are identities, whose access is reported, rather
+// than collections of roles, which are expanded
+func isIdentityKind(kind string) bool {
+	return kind == "user" || kind == "host"
+}
+
+// variableInBranch reports whether a fully-qualified variable ID belongs to a policy branch
+func variableInBranch(resourceID string, branch string) bool {
+	if branch == "" || branch == "root" {
+		return true
+	}
+	return strings.HasPrefix(variableIDFromResourceID(resourceID), strings.Trim(branch, "/")+"/")
+}
+
+// roleExpander expands roles into the users and hosts they contain, caching the members of each role
+type roleExpander struct {
+	client  reportClient
+	members map[string][]string
+}
+
+func (e *roleExpander) identities(roleID string) ([]string, error) {
+	seen := map[string]bool{}
+	identities := []string{}
+
+	var expand func(roleID string) error
+	expand = func(roleID string) error {
+		if seen[roleID] {
+			return nil
+		}
+		seen[roleID] = true
+
+		if isIdentityKind(utils.KindFromID(roleID)) {
+			identities = append(identities, roleID)
+			return nil
+		}
+
+		members, ok := e.members[roleID]
+		if !ok {
+			result, err := e.client.RoleMembers(roleID)
+			if err != nil {
+				return err
+			}
+			for _, m := range result {
+				if member, _ := m["member"].(string); member != "" {
+					members = append(members, member)
+				}
+			}
+			e.members[roleID] = members
+		}
+
+		for _, member := range members {
+			if err := expand(member); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := expand(roleID); err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+func buildAccessReport(client reportClient, branch string, privileges []string) (accessReport, error) {
+	report := accessReport{
+		Branch:      branch,
+		GeneratedAt: reportNow().UTC().Format(time.RFC3339),
+		Privileges:  privileges,
+		Entries:     []accessReportEntry{},
+	}
+
+	variables := []string{}
+	filter := conjurapi.ResourceFilter{Kind: "variable"}
+	err := forEachResourcePage(client, filter, 1000, func(page []map[string]interface{}) error {
+		for _, resource := range page {
+			if id, _ := resource["id"].(string); id != "" && variableInBranch(id, branch) {
+				variables = append(variables, id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	sort.Strings(variables)
+
+	expander := &roleExpander{client: client, members: map[string][]string{}}
+	for _, variable := range variables {
+		entries := map[string]*accessReportEntry{}
+		for _, privilege := range privileges {
+			permitted, err := client.PermittedRoles(variable, privilege)
+			if err != nil {
+				return report, err
+			}
+
+			for _, role := range permitted {
+				identities, err := expander.identities(role)
+				if err != nil {
+					return report, err
+				}
+
+				for _, identity := range identities {
+					entry, ok := entries[identity]
+					if !ok {
+						entry = &accessReportEntry{Variable: variable, Role: identity, Kind: utils.KindFromID(identity), Privileges: []string{}, Via: []string{}}
+						entries[identity] = entry
+					}
+					if !containsString(entry.Privileges, privilege) {
+						entry.Privileges = append(entry.Privileges, privilege)
+					}
+					if role != identity && !containsString(entry.Via, role) {
+						entry.Via = append(entry.Via, role)
+					}
+				}
+			}
+		}
+
+		roles := make([]string, 0, len(entries))
+		for role := range entries {
+			roles = append(roles, role)
+		}
+		sort.Strings(roles)
+		for _, role := range roles {
+			entry := entries[role]
+			sort.Strings(entry.Privileges)
+			sort.Strings(entry.Via)
+			report.Entries = append(report.Entries, *entry)
+		}
+	}
+	return report, nil
+}
+
+func writeAccessReportCSV(out io.Writer, report accessReport) error {
+	writer := csv.NewWriter(out)
+	writer.Write([]string{"variable", "role", "kind", "privileges", "via"})
+	for _, entry := range report.Entries {
+		writer.Write([]string{
+			entry.Variable,
+			entry.Role,
+			entry.Kind,
+			strings.Join(entry.Privileges, " "),
+			strings.Join(entry.Via, " "),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+var accessReportHTML = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Conjur access review: {{ .Branch }}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #999; padding: 4px 8px; text-align: left; }
+</style>
+</head>
+<body>
+<h1>Conjur access review: {{ .Branch }}</h1>
+<p>Generated at {{ .GeneratedAt }}. Privileges: {{ range $i, $p := .Privileges }}{{ if $i }}, {{ end }}{{ $p }}{{ end }}.</p>
+<table>
+<tr><th>Variable</th><th>Role</th><th>Kind</th><th>Privileges</th><th>Via</th></tr>
+{{- range .Entries }}
+<tr><td>{{ .Variable }}</td><td>{{ .Role }}</td><td>{{ .Kind }}</td><td>{{ range $i, $p := .Privileges }}{{ if $i }}, {{ end }}{{ $p }}{{ end }}</td><td>{{ range $i, $v := .Via }}{{ if $i }}<br>{{ end }}{{ $v }}{{ end }}</td></tr>
+{{- end }}
+</table>
+</body>
+</html>
+`))
+
+func newReportCmd(clientFactory reportClientFactoryFunc) *cobra.Command {
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Generate reports",
+		Run: func(cmd *cobra.Command, args []string) {
+			// Print --help if called without subcommand
+			cmd.Help()
+		},
+	}
+
+	accessCmd := &cobra.Command{
+		Use:   "access",
+		Short: "Report which users and hosts can access the variables of a policy branch",
+		Long: `Report which users and hosts can access the variables of a policy branch.
+
+Every variable under --branch visible to the current user is listed with the users and hosts holding the read or execute privilege on it, directly or through groups, layers and policies, which are listed in the "via" column. The execute privilege allows fetching the value of a variable.
+
+Examples:
+- conjur report access --branch prod > access.csv
+- conjur report access --branch prod/db --format html > access.html
+- conjur report access --branch root --privilege execute --format json`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			branch, err := cmd.Flags().GetString("branch")
+			if err != nil {
+				return err
+			}
+
+			format, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return err
+			}
+			if !containsString(accessReportFormats, format) {
+				return fmt.Errorf("Format must be one of %s", strings.Join(accessReportFormats, ", "))
+			}
+
+			privileges, err := cmd.Flags().GetStringSlice("privilege")
+			if err != nil {
+				return err
+			}
+
+			client, err := clientFactory(cmd)
+			if err != nil {
+				return err
+			}
+
+			report, err := buildAccessReport(client, branch, privileges)
+			if err != nil {
+				return err
+			}
+
+			switch format {
+			case "json":
+				prettyData, err := utils.PrettyPrintToJSON(report)
+				if err != nil {
+					return err
+				}
+				cmd.Println(prettyData)
+				return nil
+			case "html":
+				return accessReportHTML.Execute(cmd.OutOrStdout(), report)
+			default:
+				return writeAccessReportCSV(cmd.OutOrStdout(), report)
+			}
+		},
+	}
+
+	accessCmd.Flags().StringP("branch", "b", "", "The policy branch whose variables are reported, or root for every variable")
+	accessCmd.Flags().String("format", "csv", "Format of the report: "+strings.Join(accessReportFormats, ", "))
+	accessCmd.Flags().StringSlice("privilege", []string{"read", "execute"}, "Privileges to report")
+	accessCmd.MarkFlagRequired("branch")
+
+	reportCmd.AddCommand(accessCmd)
+	return reportCmd
+}
+
+func init() {
+	reportCmd := newReportCmd(reportClientFactory)
+	rootCmd.AddCommand(reportCmd)
+}
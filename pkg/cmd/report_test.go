@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockReportClient struct {
+	t *testing.T
+}
+
+func (m mockReportClient) Resources(filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error) {
+	assert.Equal(m.t, "variable", filter.Kind)
+	if filter.Offset > 0 {
+		return []map[string]interface{}{}, nil
+	}
+	return []map[string]interface{}{
+		{"id": "dev:variable:prod/db/password"},
+		{"id": "dev:variable:prod/api/<key>"},
+		{"id": "dev:variable:production/other"},
+		{"id": "dev:variable:staging/db/password"},
+	}, nil
+}
+
+func (m mockReportClient) PermittedRoles(resourceID, privilege string) ([]string, error) {
+	switch {
+	case resourceID == "dev:variable:prod/db/password" && privilege == "execute":
+		return []string{"dev:layer:apps", "dev:host:apps/billing", "dev:group:dbas", "dev:user:alice"}, nil
+	case resourceID == "dev:variable:prod/db/password" && privilege == "read":
+		return []string{"dev:group:dbas", "dev:user:alice", "dev:user:auditor"}, nil
+	case resourceID == "dev:variable:prod/api/<key>":
+		return []string{"dev:user:alice"}, nil
+	}
+	m.t.Errorf("unexpected PermittedRoles(%s, %s)", resourceID, privilege)
+	return nil, nil
+}
+
+func (m mockReportClient) RoleMembers(roleID string) ([]map[string]interface{}, error) {
+	members := map[string][]map[string]interface{}{
+		"dev:layer:apps": {{"member": "dev:host:apps/billing"}},
+		"dev:group:dbas": {{"member": "dev:user:alice"}, {"member": "dev:group:dbas"}},
+	}
+	return members[roleID], nil
+}
+
+func newReportTestCmd(t *testing.T) *cobra.Command {
+	return newReportCmd(func(cmd *cobra.Command) (reportClient, error) {
+		return mockReportClient{t: t}, nil
+	})
+}
+
+func TestReportAccessCmd(t *testing.T) {
+	reportNow = func() time.Time { return time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC) }
+	defer func() { reportNow = time.Now }()
+
+	t.Run("csv", func(t *testing.T) {
+		stdout, _, err := executeCommandForTest(t, newReportTestCmd(t), "report", "access", "--branch", "prod")
+
+		assert.NoError(t, err)
+		assert.Equal(t, `variable,role,kind,privileges,via
+dev:variable:prod/api/<key>,dev:user:alice,user,execute read,
+dev:variable:prod/db/password,dev:host:apps/billing,host,execute,dev:layer:apps
+dev:variable:prod/db/password,dev:user:alice,user,execute read,dev:group:dbas
+dev:variable:prod/db/password,dev:user:auditor,user,read,
+`, stdout)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		stdout, _, err := executeCommandForTest(t, newReportTestCmd(t), "report", "access", "--branch", "prod/db", "--privilege", "execute", "--format", "json")
+
+		assert.NoError(t, err)
+		assert.Equal(t, `{
+  "branch": "prod/db",
+  "generated_at": "2026-10-01T12:00:00Z",
+  "privileges": [
+    "execute"
+  ],
+  "entries": [
+    {
+      "variable": "dev:variable:prod/db/password",
+      "role": "dev:host:apps/billing",
+      "kind": "host",
+      "privileges": [
+        "execute"
+      ],
+      "via": [
+        "dev:layer:apps"
+      ]
+    },
+    {
+      "variable": "dev:variable:prod/db/password",
+      "role": "dev:user:alice",
+      "kind": "user",
+      "privileges": [
+        "execute"
+      ],
+      "via": [
+        "dev:group:dbas"
+      ]
+    }
+  ]
+}
+`, stdout)
+	})
+
+	t.Run("html", func(t *testing.T) {
+		stdout, _, err := executeCommandForTest(t, newReportTestCmd(t), "report", "access", "--branch", "prod", "--format", "html")
+
+		assert.NoError(t, err)
+		assert.Contains(t, stdout, "<h1>Conjur access review: prod</h1>")
+		assert.Contains(t, stdout, "<p>Generated at 2026-10-01T12:00:00Z. Privileges: read, execute.</p>")
+		assert.Contains(t, stdout, "<tr><td>dev:variable:prod/api/&lt;key&gt;</td><td>dev:user:alice</td><td>user</td><td>execute, read</td><td></td></tr>")
+	})
+
+	t.Run("requires a branch", func(t *testing.T) {
+		_, stderr, _ := executeCommandForTest(t, newReportTestCmd(t), "report", "access")
+		assert.Contains(t, stderr, `Error: required flag(s) "branch" not set`)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		_, stderr, _ := executeCommandForTest(t, newReportTestCmd(t), "report", "access", "--branch", "prod", "--format", "pdf")
+		assert.Contains(t, stderr, "Error: Format must be one of csv, html, json\n")
+	})
+}
@@ -115,14 +115,16 @@ Examples:
 				return err
 			}
 
-			prettyResult, err := utils.PrettyPrintToJSON(result)
-			if err != nil {
-				return err
-			}
+			return printResult(cmd, result, func() error {
+				prettyResult, err := utils.PrettyPrintToJSON(result)
+				if err != nil {
+					return err
+				}
 
-			cmd.Println(prettyResult)
+				cmd.Println(prettyResult)
 
-			return nil
+				return nil
+			})
 		},
 	}
 }
@@ -157,14 +159,16 @@ Examples:
 				return err
 			}
 
-			prettyResult, err := utils.PrettyPrintToJSON(result)
-			if err != nil {
-				return err
-			}
+			return printResult(cmd, result, func() error {
+				prettyResult, err := utils.PrettyPrintToJSON(result)
+				if err != nil {
+					return err
+				}
 
-			cmd.Println(prettyResult)
+				cmd.Println(prettyResult)
 
-			return nil
+				return nil
+			})
 		},
 	}
 }
@@ -179,4 +183,8 @@ func init() {
 	resourceCmd.AddCommand(resourceExistsCmd)
 	resourceCmd.AddCommand(resourcePermittedRolesCmd)
 	resourceCmd.AddCommand(resourceShowCmd)
+
+	for _, cmd := range resourceCmd.Commands() {
+		cmd.ValidArgsFunction = completeResourceIDs(completionClientFactory, resourceKinds)
+	}
 }
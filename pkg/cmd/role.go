@@ -120,14 +120,16 @@ Examples:
 				return err
 			}
 
-			prettyResult, err := utils.PrettyPrintToJSON(result)
-			if err != nil {
-				return err
-			}
+			return printResult(cmd, result, func() error {
+				prettyResult, err := utils.PrettyPrintToJSON(result)
+				if err != nil {
+					return err
+				}
 
-			cmd.Println(prettyResult)
+				cmd.Println(prettyResult)
 
-			return nil
+				return nil
+			})
 		},
 	}
 }
@@ -182,14 +184,16 @@ Examples:
 				}
 			}
 
-			prettyResult, _ := utils.PrettyPrintToJSON(members)
-			if err != nil {
-				return err
-			}
+			return printResult(cmd, members, func() error {
+				prettyResult, err := utils.PrettyPrintToJSON(members)
+				if err != nil {
+					return err
+				}
 
-			cmd.Println(prettyResult)
+				cmd.Println(prettyResult)
 
-			return nil
+				return nil
+			})
 		},
 	}
 
@@ -228,14 +232,16 @@ Examples:
 				return err
 			}
 
-			prettyResult, _ := utils.PrettyPrintToJSON(result)
-			if err != nil {
-				return err
-			}
+			return printResult(cmd, result, func() error {
+				prettyResult, err := utils.PrettyPrintToJSON(result)
+				if err != nil {
+					return err
+				}
 
-			cmd.Println(prettyResult)
+				cmd.Println(prettyResult)
 
-			return nil
+				return nil
+			})
 		},
 	}
 }
@@ -252,4 +258,8 @@ func init() {
 	roleCmd.AddCommand(roleShowCmd)
 	roleCmd.AddCommand(roleMembersCmd)
 	roleCmd.AddCommand(roleMembershipsCmd)
+
+	for _, cmd := range roleCmd.Commands() {
+		cmd.ValidArgsFunction = completeResourceIDs(completionClientFactory, roleKinds)
+	}
 }
@@ -313,6 +313,16 @@ var roleMembershipsCmdTestCases = []roleCmdTestCase{
 			assert.Contains(t, stdout, "[\n  \"role1\"\n]\n")
 		},
 	},
+	{
+		name: "role memberships return memberships as yaml",
+		args: []string{"memberships", "meow", "--output", "yaml"},
+		roleMembershipsAll: func(t *testing.T, roleID string) (memberships []string, err error) {
+			return []string{"role1", "role2"}, nil
+		},
+		assert: func(t *testing.T, stdout string, stderr string, err error) {
+			assert.Equal(t, "- role1\n- role2\n", stdout)
+		},
+	},
 	{
 		name: "role memberships return no members",
 		args: []string{"memberships", "meow"},
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/cyberark/conjur-cli-go/pkg/utils"
 	"github.com/cyberark/conjur-cli-go/pkg/version"
 	"github.com/spf13/cobra"
 )
@@ -20,20 +22,35 @@ func newRootCommand() *cobra.Command {
 
 	rootCmd.PersistentFlags().BoolP("debug", "d", false, "Debug logging enabled")
 	rootCmd.PersistentFlags().Duration("timeout", time.Minute, "HTTP timeout duration, between 1s and 10m")
+	rootCmd.PersistentFlags().String("output", "", "Output format of read commands: "+strings.Join(utils.OutputFormats, ", "))
+	rootCmd.PersistentFlags().String("profile", "", "Connection profile to use instead of the current one (overrides CONJUR_PROFILE)")
+	rootCmd.PersistentFlags().String("query", "", "Select fields from the output of read commands, e.g. '.[].id' or '$.members[*].member'")
 	rootCmd.SetVersionTemplate("Conjur CLI version {{.Version}}\n")
 	return rootCmd
 }
 
+// exitCodeError is implemented by errors which carry the status code the CLI should exit with,
+// such as the *exec.ExitError of a child process
+type exitCodeError interface {
+	error
+	ExitCode() int
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	rootCmd.SetOut(os.Stdout)
 	rootCmd.SetErr(os.Stderr)
+	addPluginCommand(rootCmd, os.Args[1:], pluginClientFactory)
 	err := rootCmd.Execute()
 	if errors.Is(err, context.DeadlineExceeded) {
 		rootCmd.PrintErrln(
 			"Your request has timed out. If your operation is expected to be long-running, please consider increasing the HTTP timeout. For details, please refer to the command help.")
 	}
+	var exitErr exitCodeError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		os.Exit(exitErr.ExitCode())
+	}
 	if err != nil {
 		os.Exit(1)
 	}
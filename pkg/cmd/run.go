@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/cyberark/conjur-cli-go/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// lookupSecret finds the value of a variable in the result of a batch retrieval, whose keys are
+// fully-qualified IDs (account:variable:id). An ID which is not fully qualified belongs to the
+// account of the client.
+func lookupSecret(secrets map[string][]byte, account string, variableID string) ([]byte, bool) {
+	if value, ok := secrets[variableID]; ok {
+		return value, true
+	}
+	value, ok := secrets[account+":variable:"+variableID]
+	return value, ok
+}
+
+// resolveRunEnvironment turns the secrets file entries into environment variable assignments.
+// Entries exposed as files are written to tempDir.
+func resolveRunEnvironment(specs []utils.SecretSpec, secrets map[string][]byte, account string, tempDir string) ([]string, error) {
+	env := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		value := []byte(spec.Value)
+		if spec.IsVariable() {
+			var ok bool
+			value, ok = lookupSecret(secrets, account, spec.Value)
+			if !ok {
+				return nil, fmt.Errorf("value of variable %s for %s was not returned by Conjur", spec.Value, spec.Name)
+			}
+		}
+
+		if spec.AsFile {
+			file, err := os.CreateTemp(tempDir, spec.Name+"-")
+			if err != nil {
+				return nil, err
+			}
+			_, err = file.Write(value)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return nil, err
+			}
+			value = []byte(file.Name())
+		}
+
+		env = append(env, spec.Name+"="+string(value))
+	}
+	return env, nil
+}
+
+func newRunCmd(clientFactory variableGetClientFactoryFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run [flags] -- command [args...]",
+		Short: "Run a command with Conjur secrets in its environment",
+		Long: `Run a command with Conjur secrets in its environment.
+
+The secrets file maps environment variable names to values, using the same format as Summon's secrets.yml:
+
+  DB_PASSWORD: !var prod/db/password     # value of a Conjur variable
+  SSL_CERT: !var:file prod/ssl/cert      # value of a Conjur variable, written to a temporary file
+  DB_USER: !str admin                    # literal value
+  CONFIG: !file some literal content     # literal value, written to a temporary file
+
+All the variables are fetched in a single request. Temporary files are readable only by the
+current user and are removed when the command exits.
+
+Examples:
+- conjur run -- env
+- conjur run -f secrets.yml -- ./start-server.sh --port 8080
+- conjur run -f secrets.yml -e production -- ./deploy.sh`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("Must specify a command to run")
+			}
+
+			file, err := cmd.Flags().GetString("file")
+			if err != nil {
+				return err
+			}
+
+			environment, err := cmd.Flags().GetString("environment")
+			if err != nil {
+				return err
+			}
+
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+
+			specs, err := utils.ParseSecretsYML(data, environment)
+			if err != nil {
+				return fmt.Errorf("%s: %s", file, err)
+			}
+
+			variableIDs := make([]string, 0, len(specs))
+			idSet := make(map[string]struct{})
+			for _, spec := range specs {
+				if _, exists := idSet[spec.Value]; spec.IsVariable() && !exists {
+					idSet[spec.Value] = struct{}{}
+					variableIDs = append(variableIDs, spec.Value)
+				}
+			}
+
+			secrets := map[string][]byte{}
+			account := ""
+			if len(variableIDs) > 0 {
+				client, err := clientFactory(cmd)
+				if err != nil {
+					return err
+				}
+				account = client.GetConfig().Account
+
+				secrets, err = client.RetrieveBatchSecretsSafe(variableIDs)
+				if err != nil {
+					return err
+				}
+			}
+
+			tempDir, err := os.MkdirTemp("", "conjur-run-")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(tempDir)
+
+			env, err := resolveRunEnvironment(specs, secrets, account, tempDir)
+			if err != nil {
+				return err
+			}
+
+			child := exec.Command(args[0], args[1:]...)
+			child.Env = append(os.Environ(), env...)
+			child.Stdin = cmd.InOrStdin()
+			child.Stdout = cmd.OutOrStdout()
+			child.Stderr = cmd.ErrOrStderr()
+
+			// Forward the signals which would stop the CLI to the command instead, and wait for it
+			// to exit so that the temporary files are removed
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+			defer signal.Stop(signals)
+
+			if err := child.Start(); err != nil {
+				return err
+			}
+			done := make(chan struct{})
+			go func() {
+				for {
+					select {
+					case sig := <-signals:
+						child.Process.Signal(sig)
+					case <-done:
+						return
+					}
+				}
+			}()
+			err = child.Wait()
+			close(done)
+
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				// The child already reported its failure, only propagate its exit code
+				cmd.SilenceErrors = true
+				return exitErr
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().StringP("file", "f", "secrets.yml", "Path to the secrets file mapping environment variables to Conjur variables")
+	cmd.Flags().StringP("environment", "e", "", "Section of the secrets file to use, merged on top of the 'common' section")
+
+	return cmd
+}
+
+func init() {
+	runCmd := newRunCmd(variableGetClientFactory)
+	rootCmd.AddCommand(runCmd)
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+var runCmdTestCases = []struct {
+	name               string
+	secretsYML         string
+	args               []string
+	getBatch           func(t *testing.T, paths []string) (map[string][]byte, error)
+	clientFactoryError error
+	assert             func(t *testing.T, stdout string, stderr string, err error)
+}{
+	{
+		name: "run command help",
+		args: []string{"run", "--help"},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Contains(t, stdout, "HELP LONG")
+		},
+	},
+	{
+		name: "missing command",
+		args: []string{"run"},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Contains(t, stderr, "Error: Must specify a command to run\n")
+		},
+	},
+	{
+		name:       "injects variables in environment",
+		secretsYML: "DB_PASSWORD: !var prod/db/password\nDB_USER: !str admin\nAPI_KEY: !var prod/api-key\n",
+		args:       []string{"run", "--", "sh", "-c", "echo $DB_USER:$DB_PASSWORD:$API_KEY"},
+		getBatch: func(t *testing.T, paths []string) (map[string][]byte, error) {
+			assert.Equal(t, []string{"prod/db/password", "prod/api-key"}, paths)
+			return map[string][]byte{
+				"dev:variable:prod/db/password": []byte("s3cr3t"),
+				"dev:variable:prod/api-key":     []byte("k3y"),
+			}, nil
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, "admin:s3cr3t:k3y\n", stdout)
+		},
+	},
+	{
+		name:       "writes file variables to temporary files",
+		secretsYML: "SSL_CERT: !var:file prod/ssl/cert\n",
+		args:       []string{"run", "--", "sh", "-c", "cat $SSL_CERT; stat -c ' %a' $SSL_CERT"},
+		getBatch: func(t *testing.T, paths []string) (map[string][]byte, error) {
+			return map[string][]byte{"dev:variable:prod/ssl/cert": []byte("-----BEGIN CERTIFICATE-----")}, nil
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, "-----BEGIN CERTIFICATE----- 600\n", stdout)
+		},
+	},
+	{
+		name:       "only uses the variable of the requested ID",
+		secretsYML: "DB_PASSWORD: !var db/password\n",
+		args:       []string{"run", "--", "true"},
+		getBatch: func(t *testing.T, paths []string) (map[string][]byte, error) {
+			return map[string][]byte{
+				"dev:variable:staging/db/password": []byte("staging"),
+				"other:variable:db/password":       []byte("other"),
+			}, nil
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Contains(t, stderr, "Error: value of variable db/password for DB_PASSWORD was not returned by Conjur\n")
+		},
+	},
+	{
+		name:               "does not contact Conjur for literals only",
+		secretsYML:         "DB_USER: !str admin\n",
+		args:               []string{"run", "--", "sh", "-c", "echo $DB_USER"},
+		clientFactoryError: fmt.Errorf("%s", "client factory error"),
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, "admin\n", stdout)
+		},
+	},
+	{
+		name:       "propagates exit code",
+		secretsYML: "DB_USER: !str admin\n",
+		args:       []string{"run", "--", "sh", "-c", "exit 3"},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			var exitErr exitCodeError
+			assert.ErrorAs(t, err, &exitErr)
+			assert.Equal(t, 3, exitErr.ExitCode())
+			assert.NotContains(t, stderr, "Error:")
+		},
+	},
+	{
+		name:       "batch retrieval error",
+		secretsYML: "DB_PASSWORD: !var prod/db/password\n",
+		args:       []string{"run", "--", "true"},
+		getBatch: func(t *testing.T, paths []string) (map[string][]byte, error) {
+			return nil, fmt.Errorf("%s", "get error")
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Contains(t, stderr, "Error: get error\n")
+		},
+	},
+	{
+		name:       "invalid secrets file",
+		secretsYML: "DB_PASSWORD: !secret prod/db/password\n",
+		args:       []string{"run", "--", "true"},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Contains(t, stderr, "line 1: unsupported tag !secret for DB_PASSWORD\n")
+		},
+	},
+	{
+		name:               "client factory error",
+		secretsYML:         "DB_PASSWORD: !var prod/db/password\n",
+		args:               []string{"run", "--", "true"},
+		clientFactoryError: fmt.Errorf("%s", "client factory error"),
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Contains(t, stderr, "Error: client factory error\n")
+		},
+	},
+}
+
+func TestRunCmd(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range runCmdTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			args := tc.args
+			if tc.secretsYML != "" {
+				secretsFile := filepath.Join(t.TempDir(), "secrets.yml")
+				err := os.WriteFile(secretsFile, []byte(tc.secretsYML), 0600)
+				assert.NoError(t, err)
+				args = append([]string{args[0], "-f", secretsFile}, args[1:]...)
+			}
+
+			mockClient := mockVariableClient{t: t, getBatch: tc.getBatch}
+
+			cmd := newRunCmd(
+				func(cmd *cobra.Command) (variableGetClient, error) {
+					return mockClient, tc.clientFactoryError
+				},
+			)
+
+			stdout, stderr, err := executeCommandForTest(t, cmd, args...)
+			tc.assert(t, stdout, stderr, err)
+		})
+	}
+}
+
+func TestRunCmdRemovesTemporaryFilesOnSignal(t *testing.T) {
+	secretsFile := filepath.Join(t.TempDir(), "secrets.yml")
+	err := os.WriteFile(secretsFile, []byte("SSL_CERT: !var:file prod/ssl/cert\n"), 0600)
+	assert.NoError(t, err)
+
+	mockClient := mockVariableClient{t: t, getBatch: func(t *testing.T, paths []string) (map[string][]byte, error) {
+		return map[string][]byte{"dev:variable:prod/ssl/cert": []byte("-----BEGIN CERTIFICATE-----")}, nil
+	}}
+	cmd := newRunCmd(
+		func(cmd *cobra.Command) (variableGetClient, error) {
+			return mockClient, nil
+		},
+	)
+
+	// The command terminates the CLI, which forwards the signal back to the command
+	stdout, _, err := executeCommandForTest(t, cmd, "run", "-f", secretsFile, "--", "sh", "-c", "echo $SSL_CERT; kill -TERM $PPID; exec sleep 5")
+
+	var exitErr exitCodeError
+	assert.ErrorAs(t, err, &exitErr)
+	certFile := strings.TrimSpace(stdout)
+	assert.NotEmpty(t, certFile)
+	_, err = os.Stat(certFile)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -1,12 +1,20 @@
 package cmd
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"encoding/json"
 
+	"github.com/cyberark/conjur-api-go/conjurapi"
 	"github.com/cyberark/conjur-cli-go/pkg/clients"
+	"github.com/cyberark/conjur-cli-go/pkg/prompts"
+	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
 )
 
@@ -37,11 +45,17 @@ func newVariableCmd(
 	// the version of the latest value and can not provide a good default if
 	// this flag is an integer. Use a string to provide a default of "".
 	variableGetCmd.Flags().StringP("version", "v", "", "Specify the desired version of a single variable value")
+	variableGetCmd.Flags().Bool("raw", false, "Write the exact value of a single variable to stdout, without a trailing newline")
+	variableGetCmd.Flags().String("output-file", "", "Write the exact value of a single variable to a file readable only by the current user")
+	variableGetCmd.Flags().String("dir", "", "Write the value of each variable to a file named after its ID in this directory")
+	variableGetCmd.Flags().Bool("base64", false, "Encode the values in base64")
+	variableGetCmd.MarkFlagsMutuallyExclusive("raw", "output-file", "dir")
 
 	variableSetCmd.Flags().StringP("id", "i", "", "Provide variable identifier")
 	variableSetCmd.MarkFlagRequired("id")
-	variableSetCmd.Flags().StringP("value", "v", "", "Set the value of the specified variable")
-	variableSetCmd.MarkFlagRequired("value")
+	variableSetCmd.Flags().StringP("value", "v", "", "Set the value of the specified variable, or - to read it from stdin")
+	variableSetCmd.Flags().String("value-file", "", "Read the value of the specified variable from a file")
+	variableSetCmd.MarkFlagsMutuallyExclusive("value", "value-file")
 
 	return variableCmd
 }
@@ -50,6 +64,7 @@ type variableGetClient interface {
 	RetrieveSecret(string) ([]byte, error)
 	RetrieveBatchSecretsSafe(variableIDs []string) (map[string][]byte, error)
 	RetrieveSecretWithVersion(string, int) ([]byte, error)
+	GetConfig() conjurapi.Config
 }
 type variableSetClient interface {
 	AddSecret(string, string) error
@@ -75,21 +90,109 @@ func printMultilineResults(cmd *cobra.Command, secrets map[string][]byte) error
 		formattedSecrets[id[len(id)-1]] = string(value)
 	}
 	
-	if len(formattedSecrets) > 1 {
-		// Marshal the map to JSON
-		jsonData, err := json.MarshalIndent(formattedSecrets, "", "    ")
-		if err != nil {
-			return err
+	return printResult(cmd, formattedSecrets, func() error {
+		if len(formattedSecrets) > 1 {
+			// Marshal the map to JSON
+			jsonData, err := json.MarshalIndent(formattedSecrets, "", "    ")
+			if err != nil {
+				return err
+			}
+
+			cmd.Println(string(jsonData))
+		} else {
+			for _, v := range secrets {
+				cmd.Println(string(v))
+			}
 		}
 
-		cmd.Println(string(jsonData))
-	} else {
-		for _, v := range secrets {
-			cmd.Println(string(v))
+		return nil
+	})
+}
+
+// writeVariableValues writes the values of variables according to the --raw, --output-file,
+// --dir and --base64 flags, and reports whether any of them was set
+func writeVariableValues(cmd *cobra.Command, secrets map[string][]byte) (bool, error) {
+	raw, err := cmd.Flags().GetBool("raw")
+	if err != nil {
+		return false, err
+	}
+	outputFile, err := cmd.Flags().GetString("output-file")
+	if err != nil {
+		return false, err
+	}
+	dir, err := cmd.Flags().GetString("dir")
+	if err != nil {
+		return false, err
+	}
+	encode, err := cmd.Flags().GetBool("base64")
+	if err != nil {
+		return false, err
+	}
+
+	if !raw && outputFile == "" && dir == "" && !encode {
+		return false, nil
+	}
+
+	values := make(map[string][]byte, len(secrets))
+	for fullID, value := range secrets {
+		if encode {
+			value = []byte(base64.StdEncoding.EncodeToString(value))
 		}
+		values[variableIDFromResourceID(fullID)] = value
 	}
-	
-	return nil
+
+	if (raw || outputFile != "") && len(values) > 1 {
+		return true, errors.New("--raw and --output-file can only be used with a single variable, use --dir instead")
+	}
+
+	switch {
+	case raw:
+		for _, value := range values {
+			_, err = cmd.OutOrStdout().Write(value)
+		}
+		return true, err
+	case outputFile != "":
+		for _, value := range values {
+			err = writeSecretFile(outputFile, value)
+		}
+		return true, err
+	case dir != "":
+		for id, value := range values {
+			path := filepath.Join(dir, filepath.FromSlash(id))
+			if rel, err := filepath.Rel(dir, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return true, fmt.Errorf("cannot write variable %s outside of %s", id, dir)
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				return true, err
+			}
+			if err := writeSecretFile(path, value); err != nil {
+				return true, err
+			}
+		}
+		cmd.PrintErrf("Wrote %d variable(s) to %s\n", len(values), dir)
+		return true, nil
+	}
+
+	// Only --base64 is set, print the encoded values as usual
+	return true, printMultilineResults(cmd, values)
+}
+
+// writeSecretFile writes data to a file readable only by the current user, including when the
+// file already exists with broader permissions
+func writeSecretFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
 }
 
 func newVariableGetCmd(clientFactory variableGetClientFactoryFunc) *cobra.Command {
@@ -98,10 +201,14 @@ func newVariableGetCmd(clientFactory variableGetClientFactoryFunc) *cobra.Comman
 		Short: "Get the value of one or more Conjur variables",
 		Long: `Get the value of one or more Conjur variables.
 
+Values are printed as text followed by a newline. Use --raw or --output-file to get the exact bytes of a binary value or of a value without a trailing newline, --dir to write several variables to files, and --base64 to encode the values. Files are readable only by the current user.
+
 Examples:
 - conjur variable get -i secret
 - conjur variable get -i secret,secret2
 - conjur variable get -i secret -v 1
+- conjur variable get -i keystore --output-file keystore.p12
+- conjur variable get -i prod/db/password,prod/db/cert --dir ./secrets
 		`,
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -151,19 +258,58 @@ Examples:
 			if err != nil {
 				return err
 			}
+			if written, err := writeVariableValues(cmd, data); written || err != nil {
+				return err
+			}
 			return printMultilineResults(cmd, data)
 		},
 	}
 }
 
+// readVariableValue returns the value to set from --value, stdin, --value-file or an interactive prompt
+func readVariableValue(cmd *cobra.Command, id string) (string, error) {
+	value, err := cmd.Flags().GetString("value")
+	if err != nil {
+		return "", err
+	}
+
+	valueFile, err := cmd.Flags().GetString("value-file")
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case valueFile != "":
+		data, err := os.ReadFile(valueFile)
+		return string(data), err
+	case value == "-":
+		data, err := io.ReadAll(cmd.InOrStdin())
+		return string(data), err
+	case cmd.Flags().Changed("value"):
+		return value, nil
+	}
+
+	if stdin, ok := cmd.InOrStdin().(*os.File); !ok || !isatty.IsTerminal(stdin.Fd()) {
+		return "", errors.New("A value is required, use --value, --value - or --value-file")
+	}
+	return prompts.AskForVariableValue(id)
+}
+
 func newVariableSetCmd(clientFactory variableSetClientFactoryFunc) *cobra.Command {
 	return &cobra.Command{
 		Use:   "set",
 		Short: "Set the value of a Conjur variable",
 		Long: `Set the value of a Conjur variable.
 
+The value can be given with --value, read from stdin with --value -, or read from a file with --value-file. Values read from stdin or a file are sent as is, so they can be binary, and a trailing newline is kept. When no value is given and stdin is a terminal, the value is prompted for without being echoed.
+
+Passing the value with --value exposes it in the shell history and the process list, prefer the other options for sensitive values.
+
 Examples:
+- conjur variable set -i secret
 - conjur variable set -i secret -v value
+- printf '%s' "$TOKEN" | conjur variable set -i secret -v -
+- conjur variable set -i keystore --value-file keystore.p12
 		`,
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -172,7 +318,7 @@ Examples:
 				return err
 			}
 
-			value, err := cmd.Flags().GetString("value")
+			value, err := readVariableValue(cmd, id)
 			if err != nil {
 				return err
 			}
@@ -195,5 +341,18 @@ Examples:
 
 func init() {
 	variableCmd := newVariableCmd(variableGetClientFactory, variableSetClientFactory)
+	variableCmd.AddCommand(newVariableImportCmd(variableImportClientFactory))
+	variableCmd.AddCommand(newVariableExportCmd(variableExportClientFactory))
+	variableCmd.AddCommand(newVariableVersionsCmd(variableVersionsClientFactory))
+	variableCmd.AddCommand(newVariableRollbackCmd(variableVersionsClientFactory))
+	variableCmd.AddCommand(newVariableDiffCmd(variableVersionsClientFactory))
+	variableCmd.AddCommand(newVariableRotateCmd(variableSetClientFactory))
+	variableCmd.AddCommand(newVariableWatchCmd(variableWatchClientFactory))
+
+	for _, cmd := range variableCmd.Commands() {
+		if cmd.Flags().Lookup("id") != nil {
+			cmd.RegisterFlagCompletionFunc("id", completeVariableIDs(completionClientFactory))
+		}
+	}
 	rootCmd.AddCommand(variableCmd)
 }
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/cyberark/conjur-cli-go/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// runRotationHook runs the post-rotation hook with the new value on its stdin, so that the value
+// never appears in its arguments or environment
+func runRotationHook(cmd *cobra.Command, args []string, id string, value []byte) error {
+	hook := exec.Command(args[0], args[1:]...)
+	hook.Env = append(os.Environ(), "CONJUR_VARIABLE_ID="+id)
+	hook.Stdin = bytes.NewReader(value)
+	hook.Stdout = cmd.OutOrStdout()
+	hook.Stderr = cmd.ErrOrStderr()
+	return hook.Run()
+}
+
+func newVariableRotateCmd(clientFactory variableSetClientFactoryFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rotate [flags] [-- hook [args...]]",
+		Short: "Set a newly generated value for a Conjur variable",
+		Long: fmt.Sprintf(`Set a newly generated value for a Conjur variable.
+
+The value is generated locally with a cryptographically secure random number generator and is never printed. Generators:
+
+  password  random characters from the classes given by --classes (%s by default), at least one of each
+  hex       random bytes encoded in hexadecimal
+  base64    random bytes encoded in base64
+  uuid      a random (version 4) UUID
+  rsa       a PEM-encoded PKCS #8 RSA private key
+  ed25519   a PEM-encoded PKCS #8 Ed25519 private key
+
+--length is the number of characters of passwords (default 32), the number of random bytes for hex and base64 (default 32) and the number of bits of RSA keys (default 4096).
+
+A post-rotation hook can be given after --. It runs once the value is stored in Conjur, with the new value on its stdin and the variable ID in CONJUR_VARIABLE_ID, for instance to update the credential in the system that uses it. When the hook fails, the command exits with its exit code and the new value is kept in Conjur.
+
+Examples:
+- conjur variable rotate -i prod/db/password
+- conjur variable rotate -i prod/db/password --length 24 --classes lower,upper,digit
+- conjur variable rotate -i prod/api/token --generator hex --length 16
+- conjur variable rotate -i prod/ssh/key --generator ed25519
+- conjur variable rotate -i prod/db/password -- ./update-db-password.sh`, strings.Join(utils.PasswordCharacterClasses, ",")),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := cmd.Flags().GetString("id")
+			if err != nil {
+				return err
+			}
+
+			generator, err := cmd.Flags().GetString("generator")
+			if err != nil {
+				return err
+			}
+
+			length, err := cmd.Flags().GetInt("length")
+			if err != nil {
+				return err
+			}
+
+			classes, err := cmd.Flags().GetStringSlice("classes")
+			if err != nil {
+				return err
+			}
+
+			if len(args) > 0 && cmd.ArgsLenAtDash() != 0 {
+				return errors.New("The post-rotation hook must be given after --")
+			}
+
+			value, err := utils.GenerateSecret(generator, utils.SecretGeneratorOptions{
+				Length:  length,
+				Classes: classes,
+			})
+			if err != nil {
+				return err
+			}
+
+			client, err := clientFactory(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := client.AddSecret(id, string(value)); err != nil {
+				return err
+			}
+			cmd.Printf("Rotated %s using the %s generator\n", id, generator)
+
+			if len(args) == 0 {
+				return nil
+			}
+
+			err = runRotationHook(cmd, args, id, value)
+
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				// The hook already reported its failure, only propagate its exit code
+				cmd.PrintErrf("The post-rotation hook failed, the new value of %s is kept\n", id)
+				cmd.SilenceErrors = true
+				return exitErr
+			}
+			if err != nil {
+				return fmt.Errorf("The post-rotation hook failed, the new value of %s is kept: %s", id, err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("id", "i", "", "Provide variable identifier")
+	cmd.Flags().StringP("generator", "g", utils.SecretGeneratorPassword, "Generator of the new value: "+strings.Join(utils.SecretGenerators, ", "))
+	cmd.Flags().IntP("length", "l", 0, "Length of the new value (depends on the generator)")
+	cmd.Flags().StringSlice("classes", nil, "Character classes of passwords: "+strings.Join(utils.PasswordCharacterClasses, ", "))
+	cmd.MarkFlagRequired("id")
+
+	return cmd
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func newVariableRotateTestCmd(t *testing.T, values map[string]string, setErr error) *cobra.Command {
+	client := mockVariableClient{t: t, set: func(t *testing.T, path string, value string) error {
+		if setErr != nil {
+			return setErr
+		}
+		values[path] = value
+		return nil
+	}}
+
+	variableCmd := &cobra.Command{Use: "variable"}
+	variableCmd.AddCommand(newVariableRotateCmd(func(cmd *cobra.Command) (variableSetClient, error) {
+		return client, nil
+	}))
+	return variableCmd
+}
+
+func TestVariableRotateCmd(t *testing.T) {
+	t.Run("rotates with a password by default", func(t *testing.T) {
+		values := map[string]string{}
+		stdout, _, err := executeCommandForTest(t, newVariableRotateTestCmd(t, values, nil), "variable", "rotate", "-i", "prod/db/password")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Rotated prod/db/password using the password generator\n", stdout)
+		assert.Len(t, values["prod/db/password"], 32)
+		assert.NotContains(t, stdout, values["prod/db/password"])
+	})
+
+	t.Run("passes generator options", func(t *testing.T) {
+		values := map[string]string{}
+		_, _, err := executeCommandForTest(t, newVariableRotateTestCmd(t, values, nil), "variable", "rotate", "-i", "pin", "--length", "6", "--classes", "digit")
+
+		assert.NoError(t, err)
+		assert.Regexp(t, `^[0-9]{6}$`, values["pin"])
+
+		_, _, err = executeCommandForTest(t, newVariableRotateTestCmd(t, values, nil), "variable", "rotate", "-i", "token", "-g", "hex", "-l", "4")
+		assert.NoError(t, err)
+		assert.Regexp(t, `^[0-9a-f]{8}$`, values["token"])
+	})
+
+	t.Run("invalid generator", func(t *testing.T) {
+		values := map[string]string{}
+		_, stderr, _ := executeCommandForTest(t, newVariableRotateTestCmd(t, values, nil), "variable", "rotate", "-i", "token", "-g", "uuid", "-l", "4")
+
+		assert.Contains(t, stderr, "Error: the uuid generator does not support a length\n")
+		assert.Empty(t, values)
+	})
+
+	t.Run("does not run the hook when the value is not stored", func(t *testing.T) {
+		marker := filepath.Join(t.TempDir(), "marker")
+		_, stderr, _ := executeCommandForTest(t, newVariableRotateTestCmd(t, map[string]string{}, errors.New("403 Forbidden")),
+			"variable", "rotate", "-i", "token", "--", "touch", marker)
+
+		assert.Contains(t, stderr, "Error: 403 Forbidden\n")
+		assert.NoFileExists(t, marker)
+	})
+
+	t.Run("runs the hook with the new value on stdin", func(t *testing.T) {
+		values := map[string]string{}
+		out := filepath.Join(t.TempDir(), "out")
+		stdout, _, err := executeCommandForTest(t, newVariableRotateTestCmd(t, values, nil),
+			"variable", "rotate", "-i", "token", "-g", "uuid", "--", "sh", "-c", "cat > "+out+"; echo updated $CONJUR_VARIABLE_ID")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Rotated token using the uuid generator\nupdated token\n", stdout)
+		data, err := os.ReadFile(out)
+		assert.NoError(t, err)
+		assert.Equal(t, values["token"], string(data))
+	})
+
+	t.Run("propagates the exit code of the hook", func(t *testing.T) {
+		values := map[string]string{}
+		_, stderr, err := executeCommandForTest(t, newVariableRotateTestCmd(t, values, nil), "variable", "rotate", "-i", "token", "--", "sh", "-c", "exit 3")
+
+		var exitErr exitCodeError
+		assert.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 3, exitErr.ExitCode())
+		assert.Contains(t, stderr, "The post-rotation hook failed, the new value of token is kept\n")
+		assert.NotEmpty(t, values["token"])
+	})
+
+	t.Run("hook must follow --", func(t *testing.T) {
+		_, stderr, _ := executeCommandForTest(t, newVariableRotateTestCmd(t, map[string]string{}, nil), "variable", "rotate", "-i", "token", "touch")
+		assert.Contains(t, stderr, "Error: The post-rotation hook must be given after --\n")
+	})
+}
@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"encoding/json"
 
+	"github.com/cyberark/conjur-api-go/conjurapi"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 )
@@ -26,6 +29,9 @@ func (m mockVariableClient) RetrieveSecretWithVersion(path string, version int)
 func (m mockVariableClient) RetrieveBatchSecretsSafe(paths []string) (map[string][]byte, error) {
 	return m.getBatch(m.t, paths)
 }
+func (m mockVariableClient) GetConfig() conjurapi.Config {
+	return conjurapi.Config{Account: "dev"}
+}
 func (m mockVariableClient) AddSecret(path string, value string) error {
 	return m.set(m.t, path, value)
 }
@@ -141,6 +147,29 @@ var variableCmdTestCases = []struct {
 			assert.Equal(t, expectedMap, result, "The JSON output should match the expected key-value pairs")
 		},
 	},
+	{
+		name: "get two variables as table",
+		args: []string{"variable", "get", "-i", "meow,woof", "--output", "table"},
+		getBatch: func(t *testing.T, path []string) (map[string][]byte, error) {
+			return map[string][]byte{
+				"dev:variable:meow": []byte("moo"),
+				"dev:variable:woof": []byte("quack"),
+			}, nil
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Equal(t, "MEOW  WOOF\nmoo   quack\n", stdout)
+		},
+	},
+	{
+		name: "get single variable with query",
+		args: []string{"variable", "get", "-i", "meow", "--query", ".meow"},
+		getBatch: func(t *testing.T, path []string) (map[string][]byte, error) {
+			return map[string][]byte{"dev:variable:meow": []byte("moo")}, nil
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Equal(t, "\"moo\"\n", stdout)
+		},
+	},
 	{
 		name: "get two variables with version",
 		args: []string{"variable", "get", "-i", "meow,woof", "-v", "1"},
@@ -190,7 +219,21 @@ var variableCmdTestCases = []struct {
 		name: "set subcommand missing required flags",
 		args: []string{"variable", "set"},
 		assert: func(t *testing.T, stdout, stderr string, err error) {
-			assert.Contains(t, stderr, "Error: required flag(s) \"id\", \"value\" not set\n")
+			assert.Contains(t, stderr, "Error: required flag(s) \"id\" not set\n")
+		},
+	},
+	{
+		name: "set subcommand without value and terminal",
+		args: []string{"variable", "set", "-i", "meow"},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Contains(t, stderr, "Error: A value is required, use --value, --value - or --value-file\n")
+		},
+	},
+	{
+		name: "set subcommand with value and value file",
+		args: []string{"variable", "set", "-i", "meow", "-v", "moo", "--value-file", "moo.txt"},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Contains(t, stderr, "if any flags in the group [value value-file] are set none of the others can be")
 		},
 	},
 	{
@@ -226,3 +269,151 @@ func TestVariableCmd(t *testing.T) {
 		})
 	}
 }
+
+func TestVariableSetValueSources(t *testing.T) {
+	binaryValue := []byte{0x00, 0xff, 0xfe, '\n', 'm', 'o', 'o', '\n'}
+
+	newSetCmd := func(t *testing.T, values *[]string) *cobra.Command {
+		mockClient := mockVariableClient{t: t, set: func(t *testing.T, path string, value string) error {
+			assert.Equal(t, "meow", path)
+			*values = append(*values, value)
+			return nil
+		}}
+		return newVariableCmd(
+			func(cmd *cobra.Command) (variableGetClient, error) {
+				return mockClient, nil
+			},
+			func(cmd *cobra.Command) (variableSetClient, error) {
+				return mockClient, nil
+			},
+		)
+	}
+
+	t.Run("value file", func(t *testing.T) {
+		valueFile := filepath.Join(t.TempDir(), "value.bin")
+		assert.NoError(t, os.WriteFile(valueFile, binaryValue, 0600))
+
+		values := []string{}
+		stdout, _, err := executeCommandForTest(t, newSetCmd(t, &values), "variable", "set", "-i", "meow", "--value-file", valueFile)
+		assert.NoError(t, err)
+		assert.Contains(t, stdout, "Value added")
+		assert.Equal(t, []string{string(binaryValue)}, values)
+	})
+
+	t.Run("missing value file", func(t *testing.T) {
+		values := []string{}
+		_, stderr, _ := executeCommandForTest(t, newSetCmd(t, &values), "variable", "set", "-i", "meow", "--value-file", filepath.Join(t.TempDir(), "missing"))
+		assert.Contains(t, stderr, "no such file or directory")
+		assert.Empty(t, values)
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		values := []string{}
+		rootCmd := newRootCommand()
+		rootCmd.AddCommand(newSetCmd(t, &values))
+		rootCmd.SetArgs([]string{"variable", "set", "-i", "meow", "-v", "-"})
+
+		out, err := executeCommandForTestWithPipeResponses(t, rootCmd, string(binaryValue))
+		assert.NoError(t, err)
+		assert.Contains(t, out, "Value added")
+		assert.Equal(t, []string{string(binaryValue)}, values)
+	})
+
+	t.Run("prompt", func(t *testing.T) {
+		values := []string{}
+		rootCmd := newRootCommand()
+		rootCmd.AddCommand(newSetCmd(t, &values))
+		rootCmd.SetArgs([]string{"variable", "set", "-i", "meow"})
+
+		out, err := executeCommandForTestWithPromptResponses(t, rootCmd, []promptResponse{
+			{prompt: "Enter the value of meow (it will not be echoed):", response: "s3cr3t"},
+		})
+		assert.NoError(t, err)
+		assert.NotContains(t, out, "s3cr3t")
+		assert.Equal(t, []string{"s3cr3t"}, values)
+	})
+}
+
+func TestVariableGetOutputModes(t *testing.T) {
+	binaryValue := []byte{0x00, 0xff, 'm', 'o', 'o'}
+
+	newGetCmd := func(t *testing.T) *cobra.Command {
+		mockClient := mockVariableClient{
+			t: t,
+			getBatch: func(t *testing.T, paths []string) (map[string][]byte, error) {
+				values := map[string][]byte{}
+				for _, path := range paths {
+					values["dev:variable:"+path] = append([]byte(path+":"), binaryValue...)
+				}
+				return values, nil
+			},
+		}
+		return newVariableCmd(
+			func(cmd *cobra.Command) (variableGetClient, error) {
+				return mockClient, nil
+			},
+			func(cmd *cobra.Command) (variableSetClient, error) {
+				return mockClient, nil
+			},
+		)
+	}
+
+	t.Run("raw", func(t *testing.T) {
+		stdout, _, err := executeCommandForTest(t, newGetCmd(t), "variable", "get", "-i", "meow", "--raw")
+		assert.NoError(t, err)
+		assert.Equal(t, "meow:"+string(binaryValue), stdout)
+	})
+
+	t.Run("raw with several variables", func(t *testing.T) {
+		_, stderr, _ := executeCommandForTest(t, newGetCmd(t), "variable", "get", "-i", "meow,woof", "--raw")
+		assert.Contains(t, stderr, "Error: --raw and --output-file can only be used with a single variable, use --dir instead\n")
+	})
+
+	t.Run("base64", func(t *testing.T) {
+		stdout, _, err := executeCommandForTest(t, newGetCmd(t), "variable", "get", "-i", "meow", "--base64")
+		assert.NoError(t, err)
+		assert.Equal(t, "bWVvdzoA/21vbw==\n", stdout)
+	})
+
+	t.Run("output file", func(t *testing.T) {
+		outputFile := filepath.Join(t.TempDir(), "value.bin")
+		assert.NoError(t, os.WriteFile(outputFile, []byte("previous content"), 0644))
+
+		stdout, _, err := executeCommandForTest(t, newGetCmd(t), "variable", "get", "-i", "meow", "--output-file", outputFile)
+		assert.NoError(t, err)
+		assert.Empty(t, stdout)
+
+		data, err := os.ReadFile(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "meow:"+string(binaryValue), string(data))
+
+		info, err := os.Stat(outputFile)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("dir", func(t *testing.T) {
+		dir := t.TempDir()
+		_, stderr, err := executeCommandForTest(t, newGetCmd(t), "variable", "get", "-i", "meow,prod/woof", "--dir", dir)
+		assert.NoError(t, err)
+		assert.Contains(t, stderr, "Wrote 2 variable(s) to "+dir+"\n")
+
+		data, err := os.ReadFile(filepath.Join(dir, "prod", "woof"))
+		assert.NoError(t, err)
+		assert.Equal(t, "prod/woof:"+string(binaryValue), string(data))
+
+		data, err = os.ReadFile(filepath.Join(dir, "meow"))
+		assert.NoError(t, err)
+		assert.Equal(t, "meow:"+string(binaryValue), string(data))
+	})
+
+	t.Run("dir outside of directory", func(t *testing.T) {
+		_, stderr, _ := executeCommandForTest(t, newGetCmd(t), "variable", "get", "-i", "../meow", "--dir", t.TempDir())
+		assert.Contains(t, stderr, "Error: cannot write variable ../meow outside of")
+	})
+
+	t.Run("exclusive modes", func(t *testing.T) {
+		_, stderr, _ := executeCommandForTest(t, newGetCmd(t), "variable", "get", "-i", "meow", "--raw", "--dir", t.TempDir())
+		assert.Contains(t, stderr, "if any flags in the group [raw output-file dir] are set none of the others can be")
+	})
+}
@@ -0,0 +1,375 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/cyberark/conjur-cli-go/pkg/clients"
+	"github.com/cyberark/conjur-cli-go/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+type variableImportClient interface {
+	AddSecret(variableID string, secretValue string) error
+	ResourceExists(resourceID string) (bool, error)
+}
+
+type variableExportClient interface {
+	Resources(filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error)
+	RetrieveBatchSecretsSafe(variableIDs []string) (map[string][]byte, error)
+}
+
+type variableImportClientFactoryFunc func(*cobra.Command) (variableImportClient, error)
+type variableExportClientFactoryFunc func(*cobra.Command) (variableExportClient, error)
+
+func variableImportClientFactory(cmd *cobra.Command) (variableImportClient, error) {
+	return clients.AuthenticatedConjurClientForCommand(cmd)
+}
+
+func variableExportClientFactory(cmd *cobra.Command) (variableExportClient, error) {
+	return clients.AuthenticatedConjurClientForCommand(cmd)
+}
+
+// workerClients returns a client per worker of a command calling Conjur from several goroutines,
+// from a single authenticated client (see clients.ConcurrentClients). Clients which are not Conjur
+// clients, such as test doubles, are shared by the workers.
+func workerClients[T any](client T, workers int) ([]T, error) {
+	conjurClient, ok := any(client).(clients.ConjurClient)
+	if !ok {
+		shared := make([]T, workers)
+		for i := range shared {
+			shared[i] = client
+		}
+		return shared, nil
+	}
+
+	concurrent, err := clients.ConcurrentClients(conjurClient, workers)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]T, 0, workers)
+	for _, worker := range concurrent {
+		result = append(result, any(worker).(T))
+	}
+	return result, nil
+}
+
+// variableIDFromResourceID strips the account and kind from a fully-qualified variable ID, so
+// that exported variables can be imported into another account
+func variableIDFromResourceID(resourceID string) string {
+	parts := strings.SplitN(resourceID, ":", 3)
+	if len(parts) == 3 && parts[1] == "variable" {
+		return parts[2]
+	}
+	return resourceID
+}
+
+// importVariable sets the value of a variable, or only checks that the variable exists in dry
+// run mode
+func importVariable(client variableImportClient, id string, value string, dryrun bool) error {
+	if !dryrun {
+		return client.AddSecret(id, value)
+	}
+
+	exists, err := client.ResourceExists("variable:" + id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("variable does not exist")
+	}
+	return nil
+}
+
+func newVariableImportCmd(clientFactory variableImportClientFactoryFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Set the values of Conjur variables from a file",
+		Long: `Set the values of Conjur variables from a file.
+
+The file maps variable IDs to values, in one of the formats written by 'conjur variable export':
+
+- json: {"prod/db/password": "value"}
+- yaml: prod/db/password: value
+- env:  prod/db/password="value"
+
+The format is guessed from the file extension unless --format is given. Variables are set concurrently, and the result of each one is reported. With --dry-run, the variables are only checked to exist.
+
+Examples:
+- conjur variable import -f secrets.json
+- conjur variable import -f secrets.yml --dry-run
+- cat secrets.env | conjur variable import -f - --format env --concurrency 20`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := cmd.Flags().GetString("file")
+			if err != nil {
+				return err
+			}
+
+			format, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return err
+			}
+
+			concurrency, err := cmd.Flags().GetInt("concurrency")
+			if err != nil {
+				return err
+			}
+			if concurrency <= 0 {
+				return errors.New("Concurrency must be greater than 0")
+			}
+
+			dryrun, err := cmd.Flags().GetBool("dry-run")
+			if err != nil {
+				return err
+			}
+
+			format, err = utils.SecretsFileFormat(file, format)
+			if err != nil {
+				return err
+			}
+
+			var data []byte
+			if file == "-" {
+				data, err = io.ReadAll(cmd.InOrStdin())
+			} else {
+				data, err = os.ReadFile(file)
+			}
+			if err != nil {
+				return err
+			}
+
+			secrets, err := utils.ParseSecretsFile(data, format)
+			if err != nil {
+				return fmt.Errorf("%s: %s", file, err)
+			}
+
+			ids := make([]string, 0, len(secrets))
+			for id := range secrets {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+
+			client, err := clientFactory(cmd)
+			if err != nil {
+				return err
+			}
+			workers, err := workerClients(client, min(concurrency, len(ids)))
+			if err != nil {
+				return err
+			}
+
+			var (
+				mutex  sync.Mutex
+				wg     sync.WaitGroup
+				failed int
+			)
+			jobs := make(chan string)
+			for _, client := range workers {
+				wg.Add(1)
+				go func(client variableImportClient) {
+					defer wg.Done()
+					for id := range jobs {
+						err := importVariable(client, id, secrets[id], dryrun)
+
+						mutex.Lock()
+						switch {
+						case err != nil:
+							failed++
+							cmd.PrintErrf("Failed %s: %s\n", id, err)
+						case dryrun:
+							cmd.Printf("Would set %s\n", id)
+						default:
+							cmd.Printf("Set %s\n", id)
+						}
+						mutex.Unlock()
+					}
+				}(client)
+			}
+			for _, id := range ids {
+				jobs <- id
+			}
+			close(jobs)
+			wg.Wait()
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d variable(s) failed", failed, len(ids))
+			}
+			if dryrun {
+				cmd.Printf("%d variable(s) would be set\n", len(ids))
+			} else {
+				cmd.Printf("%d variable(s) set\n", len(ids))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("file", "f", "", "The file to import, or - for stdin")
+	cmd.Flags().String("format", "", "Format of the file: json, yaml or env (guessed from the file extension by default)")
+	cmd.Flags().Int("concurrency", 10, "Number of variables set at the same time")
+	cmd.Flags().Bool("dry-run", false, "Check that the variables exist without setting them")
+	cmd.MarkFlagRequired("file")
+
+	return cmd
+}
+
+// isMissingValue reports whether Conjur rejected a request for the values of variables because
+// one of them has no value
+func isMissingValue(err error) bool {
+	var conjurErr *response.ConjurError
+	return errors.As(err, &conjurErr) && conjurErr.Code == http.StatusNotFound
+}
+
+// retrieveVariableChunk fetches the values of a chunk of variables. Conjur rejects a batch when
+// one of its variables has no value, in which case the variables are fetched one by one and
+// those without a value are skipped. Any other error fails the chunk.
+func retrieveVariableChunk(cmd *cobra.Command, client variableExportClient, ids []string) (map[string][]byte, error) {
+	values, err := client.RetrieveBatchSecretsSafe(ids)
+	if err == nil {
+		return values, nil
+	}
+	if !isMissingValue(err) {
+		return nil, err
+	}
+
+	values = map[string][]byte{}
+	for _, id := range ids {
+		value, err := client.RetrieveBatchSecretsSafe([]string{id})
+		if isMissingValue(err) {
+			cmd.PrintErrf("Skipped %s: %s\n", id, err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for fullID, v := range value {
+			values[fullID] = v
+		}
+	}
+	return values, nil
+}
+
+func newVariableExportCmd(clientFactory variableExportClientFactoryFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write the values of Conjur variables to a file",
+		Long: `Write the values of Conjur variables to a file.
+
+Every variable visible to the current user and matching --search is exported, using IDs without the account so that the file can be imported into another account with 'conjur variable import'. Variables without a value are skipped.
+
+The format is guessed from the file extension unless --format is given, and defaults to json when writing to stdout. Binary values are written as !!binary scalars in yaml and with \x escapes in env files; they cannot be written as json, which fails the export. The file is only readable by the current user.
+
+Examples:
+- conjur variable export -f secrets.json
+- conjur variable export --search prod/db -f secrets.env
+- conjur variable export --format yaml`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := cmd.Flags().GetString("file")
+			if err != nil {
+				return err
+			}
+
+			format, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return err
+			}
+
+			search, err := cmd.Flags().GetString("search")
+			if err != nil {
+				return err
+			}
+
+			kind, err := cmd.Flags().GetString("kind")
+			if err != nil {
+				return err
+			}
+			if kind != "variable" {
+				return errors.New("Only variables can be exported")
+			}
+
+			chunkSize, err := cmd.Flags().GetInt("chunk-size")
+			if err != nil {
+				return err
+			}
+			if chunkSize <= 0 {
+				return errors.New("Chunk size must be greater than 0")
+			}
+
+			if file == "-" && format == "" {
+				format = utils.SecretsFormatJSON
+			}
+			format, err = utils.SecretsFileFormat(file, format)
+			if err != nil {
+				return err
+			}
+
+			client, err := clientFactory(cmd)
+			if err != nil {
+				return err
+			}
+
+			ids := []string{}
+			filter := conjurapi.ResourceFilter{Kind: kind, Search: search}
+			err = forEachResourcePage(client, filter, 1000, func(page []map[string]interface{}) error {
+				for _, resource := range page {
+					if id, ok := resource["id"].(string); ok {
+						ids = append(ids, variableIDFromResourceID(id))
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			secrets := make(map[string]string, len(ids))
+			for start := 0; start < len(ids); start += chunkSize {
+				end := start + chunkSize
+				if end > len(ids) {
+					end = len(ids)
+				}
+
+				values, err := retrieveVariableChunk(cmd, client, ids[start:end])
+				if err != nil {
+					return err
+				}
+				for fullID, value := range values {
+					secrets[variableIDFromResourceID(fullID)] = string(value)
+				}
+			}
+
+			data, err := utils.FormatSecretsFile(secrets, format)
+			if err != nil {
+				return err
+			}
+
+			if file == "-" {
+				cmd.Print(string(data))
+				return nil
+			}
+
+			if err := os.WriteFile(file, data, 0600); err != nil {
+				return err
+			}
+			cmd.PrintErrf("Exported %d variable(s) to %s\n", len(secrets), file)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("file", "f", "-", "The file to write, or - for stdout")
+	cmd.Flags().String("format", "", "Format of the file: json, yaml or env (guessed from the file extension by default)")
+	cmd.Flags().StringP("search", "s", "", "Export only the variables matching this search term")
+	cmd.Flags().StringP("kind", "k", "variable", "Kind of resources to export, only variable is supported")
+	cmd.Flags().Int("chunk-size", 100, "Number of variable values fetched per request")
+
+	return cmd
+}
@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockVariableTransferClient struct {
+	t              *testing.T
+	mutex          *sync.Mutex
+	added          map[string]string
+	existing       map[string]bool
+	resources      []map[string]interface{}
+	values         map[string][]byte
+	retrieveError  error
+	addSecretError error
+}
+
+func (m mockVariableTransferClient) AddSecret(variableID string, secretValue string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.addSecretError != nil && variableID == "prod/broken" {
+		return m.addSecretError
+	}
+	m.added[variableID] = secretValue
+	return nil
+}
+
+func (m mockVariableTransferClient) ResourceExists(resourceID string) (bool, error) {
+	return m.existing[resourceID], nil
+}
+
+func (m mockVariableTransferClient) Resources(filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error) {
+	assert.Equal(m.t, "variable", filter.Kind)
+	if filter.Offset >= len(m.resources) {
+		return []map[string]interface{}{}, nil
+	}
+	end := filter.Offset + filter.Limit
+	if end > len(m.resources) {
+		end = len(m.resources)
+	}
+	return m.resources[filter.Offset:end], nil
+}
+
+func (m mockVariableTransferClient) RetrieveBatchSecretsSafe(variableIDs []string) (map[string][]byte, error) {
+	values := map[string][]byte{}
+	for _, id := range variableIDs {
+		if m.retrieveError != nil && id == "prod/broken" {
+			return nil, m.retrieveError
+		}
+		value, ok := m.values[id]
+		if !ok {
+			return nil, &response.ConjurError{Code: 404, Message: "CONJ00076E Variable dev:variable:" + id + " is empty or not found."}
+		}
+		values["dev:variable:"+id] = value
+	}
+	return values, nil
+}
+
+func newVariableTransferTestCmd(client mockVariableTransferClient) *cobra.Command {
+	variableCmd := &cobra.Command{Use: "variable"}
+	variableCmd.AddCommand(newVariableImportCmd(func(cmd *cobra.Command) (variableImportClient, error) {
+		return client, nil
+	}))
+	variableCmd.AddCommand(newVariableExportCmd(func(cmd *cobra.Command) (variableExportClient, error) {
+		return client, nil
+	}))
+	return variableCmd
+}
+
+func newMockVariableTransferClient(t *testing.T) mockVariableTransferClient {
+	return mockVariableTransferClient{
+		t:        t,
+		mutex:    &sync.Mutex{},
+		added:    map[string]string{},
+		existing: map[string]bool{},
+		values:   map[string][]byte{},
+	}
+}
+
+func sortedLines(output string) []string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	sort.Strings(lines)
+	return lines
+}
+
+func TestVariableImportCmd(t *testing.T) {
+	t.Run("imports a yaml file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "secrets.yml")
+		assert.NoError(t, os.WriteFile(file, []byte("prod/a: one\nprod/b: two\nprod/c: three\n"), 0600))
+
+		client := newMockVariableTransferClient(t)
+		stdout, _, err := executeCommandForTest(t, newVariableTransferTestCmd(client), "variable", "import", "-f", file, "--concurrency", "2")
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"prod/a": "one", "prod/b": "two", "prod/c": "three"}, client.added)
+		assert.Equal(t, []string{"3 variable(s) set", "Set prod/a", "Set prod/b", "Set prod/c"}, sortedLines(stdout))
+	})
+
+	t.Run("authenticates once for all workers", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "secrets.json")
+		assert.NoError(t, os.WriteFile(file, []byte(`{"prod/a": "one", "prod/b": "two", "prod/c": "three"}`), 0600))
+
+		client := newMockVariableTransferClient(t)
+		created := 0
+		variableCmd := &cobra.Command{Use: "variable"}
+		variableCmd.AddCommand(newVariableImportCmd(func(cmd *cobra.Command) (variableImportClient, error) {
+			created++
+			return client, nil
+		}))
+
+		_, _, err := executeCommandForTest(t, variableCmd, "variable", "import", "-f", file, "--concurrency", "2")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, created)
+		assert.Len(t, client.added, 3)
+	})
+
+	t.Run("reports failures", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "secrets.json")
+		assert.NoError(t, os.WriteFile(file, []byte(`{"prod/a": "one", "prod/broken": "two"}`), 0600))
+
+		client := newMockVariableTransferClient(t)
+		client.addSecretError = errors.New("403 Forbidden")
+		stdout, stderr, err := executeCommandForTest(t, newVariableTransferTestCmd(client), "variable", "import", "-f", file)
+
+		assert.EqualError(t, err, "1 of 2 variable(s) failed")
+		assert.Equal(t, "Set prod/a\n", stdout)
+		assert.Contains(t, stderr, "Failed prod/broken: 403 Forbidden\n")
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		client := newMockVariableTransferClient(t)
+		client.existing["variable:prod/a"] = true
+
+		cmd := newVariableTransferTestCmd(client)
+		stdout, stderr, err := executeCommandForTest(t, cmd, "variable", "import", "-f", "-", "--format", "env", "--dry-run")
+		assert.NoError(t, err)
+		assert.Equal(t, "0 variable(s) would be set\n", stdout)
+		assert.Empty(t, stderr)
+
+		file := filepath.Join(t.TempDir(), "secrets.env")
+		assert.NoError(t, os.WriteFile(file, []byte("prod/a=one\nprod/missing=two\n"), 0600))
+		stdout, stderr, err = executeCommandForTest(t, newVariableTransferTestCmd(client), "variable", "import", "-f", file, "--dry-run")
+		assert.EqualError(t, err, "1 of 2 variable(s) failed")
+		assert.Equal(t, "Would set prod/a\n", stdout)
+		assert.Contains(t, stderr, "Failed prod/missing: variable does not exist\n")
+		assert.Empty(t, client.added)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, stderr, _ := executeCommandForTest(t, newVariableTransferTestCmd(newMockVariableTransferClient(t)), "variable", "import", "-f", "secrets.txt")
+		assert.Contains(t, stderr, "Error: cannot guess the format of secrets.txt")
+	})
+
+	t.Run("invalid concurrency", func(t *testing.T) {
+		_, stderr, _ := executeCommandForTest(t, newVariableTransferTestCmd(newMockVariableTransferClient(t)), "variable", "import", "-f", "secrets.json", "--concurrency", "0")
+		assert.Contains(t, stderr, "Error: Concurrency must be greater than 0\n")
+	})
+}
+
+func TestVariableExportCmd(t *testing.T) {
+	exportClient := func(t *testing.T) mockVariableTransferClient {
+		client := newMockVariableTransferClient(t)
+		for _, id := range []string{"prod/a", "prod/b", "prod/empty", "prod/c"} {
+			client.resources = append(client.resources, map[string]interface{}{"id": "dev:variable:" + id})
+		}
+		client.values = map[string][]byte{"prod/a": []byte("one"), "prod/b": []byte("two"), "prod/c": []byte("th\"ree")}
+		return client
+	}
+
+	t.Run("exports to stdout in chunks", func(t *testing.T) {
+		stdout, stderr, err := executeCommandForTest(t, newVariableTransferTestCmd(exportClient(t)), "variable", "export", "--chunk-size", "2")
+
+		assert.NoError(t, err)
+		assert.Equal(t, `{
+  "prod/a": "one",
+  "prod/b": "two",
+  "prod/c": "th\"ree"
+}
+`, stdout)
+		assert.Contains(t, stderr, "Skipped prod/empty: CONJ00076E Variable dev:variable:prod/empty is empty or not found.")
+	})
+
+	t.Run("skips variables without a value in single variable chunks", func(t *testing.T) {
+		stdout, stderr, err := executeCommandForTest(t, newVariableTransferTestCmd(exportClient(t)), "variable", "export", "--chunk-size", "1", "--format", "env")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "prod/a=\"one\"\nprod/b=\"two\"\nprod/c=\"th\\\"ree\"\n", stdout)
+		assert.Contains(t, stderr, "Skipped prod/empty:")
+	})
+
+	t.Run("fails on other errors", func(t *testing.T) {
+		client := exportClient(t)
+		client.resources = append(client.resources, map[string]interface{}{"id": "dev:variable:prod/broken"})
+		client.retrieveError = &response.ConjurError{Code: 403, Message: "Forbidden"}
+
+		for _, chunkSize := range []string{"1", "100"} {
+			stdout, stderr, err := executeCommandForTest(t, newVariableTransferTestCmd(client), "variable", "export", "--chunk-size", chunkSize)
+
+			assert.Error(t, err)
+			assert.Empty(t, stdout)
+			assert.Contains(t, stderr, "Error: Forbidden")
+		}
+	})
+
+	t.Run("exports to a file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "secrets.env")
+		_, stderr, err := executeCommandForTest(t, newVariableTransferTestCmd(exportClient(t)), "variable", "export", "-f", file)
+
+		assert.NoError(t, err)
+		assert.Contains(t, stderr, "Exported 3 variable(s) to "+file+"\n")
+
+		data, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		assert.Equal(t, "prod/a=\"one\"\nprod/b=\"two\"\nprod/c=\"th\\\"ree\"\n", string(data))
+
+		info, err := os.Stat(file)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "secrets.yml")
+		_, _, err := executeCommandForTest(t, newVariableTransferTestCmd(exportClient(t)), "variable", "export", "-f", file)
+		assert.NoError(t, err)
+
+		client := newMockVariableTransferClient(t)
+		_, _, err = executeCommandForTest(t, newVariableTransferTestCmd(client), "variable", "import", "-f", file)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"prod/a": "one", "prod/b": "two", "prod/c": "th\"ree"}, client.added)
+	})
+
+	t.Run("binary values", func(t *testing.T) {
+		client := exportClient(t)
+		client.values["prod/a"] = []byte("\xff\x00\xfe")
+
+		_, stderr, err := executeCommandForTest(t, newVariableTransferTestCmd(client), "variable", "export")
+		assert.Error(t, err)
+		assert.Contains(t, stderr, "Error: value of prod/a is binary and cannot be written as JSON, use the yaml or env format\n")
+
+		file := filepath.Join(t.TempDir(), "secrets.yml")
+		_, _, err = executeCommandForTest(t, newVariableTransferTestCmd(client), "variable", "export", "-f", file)
+		assert.NoError(t, err)
+
+		imported := newMockVariableTransferClient(t)
+		_, _, err = executeCommandForTest(t, newVariableTransferTestCmd(imported), "variable", "import", "-f", file)
+		assert.NoError(t, err)
+		assert.Equal(t, "\xff\x00\xfe", imported.added["prod/a"])
+	})
+
+	t.Run("only variables", func(t *testing.T) {
+		_, stderr, _ := executeCommandForTest(t, newVariableTransferTestCmd(exportClient(t)), "variable", "export", "--kind", "host")
+		assert.Contains(t, stderr, "Error: Only variables can be exported\n")
+	})
+}
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/cyberark/conjur-cli-go/pkg/clients"
+	"github.com/cyberark/conjur-cli-go/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+type variableVersionsClient interface {
+	Resource(resourceID string) (map[string]interface{}, error)
+	RetrieveSecret(variableID string) ([]byte, error)
+	RetrieveSecretWithVersion(variableID string, version int) ([]byte, error)
+	AddSecret(variableID string, secretValue string) error
+}
+
+type variableVersionsClientFactoryFunc func(*cobra.Command) (variableVersionsClient, error)
+
+func variableVersionsClientFactory(cmd *cobra.Command) (variableVersionsClient, error) {
+	return clients.AuthenticatedConjurClientForCommand(cmd)
+}
+
+// variableVersionsDifferError is returned by 'variable diff' when the versions differ, so that
+// the CLI exits with a dedicated status code
+type variableVersionsDifferError struct{}
+
+func (e variableVersionsDifferError) Error() string {
+	return "versions differ"
+}
+
+func (e variableVersionsDifferError) ExitCode() int {
+	return 2
+}
+
+// variableVersions returns the versions of a variable listed in the "secrets" field of its
+// resource, sorted from the oldest to the current one
+func variableVersions(client variableVersionsClient, id string) ([]map[string]interface{}, error) {
+	resource, err := client.Resource("variable:" + id)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, _ := resource["secrets"].([]interface{})
+	versions := make([]map[string]interface{}, 0, len(secrets))
+	for _, secret := range secrets {
+		secretMap, ok := secret.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		version, ok := secretMap["version"].(float64)
+		if !ok {
+			continue
+		}
+		versions = append(versions, map[string]interface{}{
+			"version":    int(version),
+			"expires_at": secretMap["expires_at"],
+		})
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i]["version"].(int) < versions[j]["version"].(int)
+	})
+	for i, version := range versions {
+		version["current"] = i == len(versions)-1
+	}
+	return versions, nil
+}
+
+// retrieveVariableVersion fetches a version of a variable, or its current value when version is 0
+func retrieveVariableVersion(client variableVersionsClient, id string, version int) ([]byte, error) {
+	if version == 0 {
+		return client.RetrieveSecret(id)
+	}
+	return client.RetrieveSecretWithVersion(id, version)
+}
+
+func describeVariableVersion(version int) string {
+	if version == 0 {
+		return "the current version"
+	}
+	return fmt.Sprintf("version %d", version)
+}
+
+func newVariableVersionsCmd(clientFactory variableVersionsClientFactoryFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "versions",
+		Short: "List the versions of a Conjur variable",
+		Long: `List the versions of a Conjur variable, with their expiration date.
+
+Conjur keeps the last 20 versions of each variable. Values are not displayed, use 'conjur variable get -i [id] -v [version]' to fetch one.
+
+Examples:
+- conjur variable versions -i secret
+- conjur variable versions -i secret --output json`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := cmd.Flags().GetString("id")
+			if err != nil {
+				return err
+			}
+
+			client, err := clientFactory(cmd)
+			if err != nil {
+				return err
+			}
+
+			versions, err := variableVersions(client, id)
+			if err != nil {
+				return err
+			}
+
+			return printResult(cmd, versions, func() error {
+				if len(versions) == 0 {
+					cmd.Printf("Variable %s has no value\n", id)
+					return nil
+				}
+
+				table, err := utils.FormatOutput(versions, utils.OutputFormatTable, "")
+				if err != nil {
+					return err
+				}
+				cmd.Println(table)
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringP("id", "i", "", "Provide variable identifier")
+	cmd.MarkFlagRequired("id")
+
+	return cmd
+}
+
+func newVariableRollbackCmd(clientFactory variableVersionsClientFactoryFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Restore a previous version of a Conjur variable",
+		Long: `Restore a previous version of a Conjur variable.
+
+The value of the given version is set as a new version of the variable, so the history is kept and the rollback can itself be reverted.
+
+Examples:
+- conjur variable rollback -i secret --to 3`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := cmd.Flags().GetString("id")
+			if err != nil {
+				return err
+			}
+
+			to, err := cmd.Flags().GetInt("to")
+			if err != nil {
+				return err
+			}
+			if to <= 0 {
+				return fmt.Errorf("Version must be greater than 0")
+			}
+
+			client, err := clientFactory(cmd)
+			if err != nil {
+				return err
+			}
+
+			value, err := client.RetrieveSecretWithVersion(id, to)
+			if err != nil {
+				return err
+			}
+
+			if err := client.AddSecret(id, string(value)); err != nil {
+				return err
+			}
+
+			cmd.Printf("Rolled back %s to the value of version %d\n", id, to)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("id", "i", "", "Provide variable identifier")
+	cmd.Flags().Int("to", 0, "The version to restore")
+	cmd.MarkFlagRequired("id")
+	cmd.MarkFlagRequired("to")
+
+	return cmd
+}
+
+func newVariableDiffCmd(clientFactory variableVersionsClientFactoryFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare two versions of a Conjur variable",
+		Long: `Compare two versions of a Conjur variable, without displaying their values.
+
+When --to is not given, the version is compared to the current version. The command exits with status 0 when the versions are identical, 2 when they differ and 1 on error.
+
+Examples:
+- conjur variable diff -i secret --from 3
+- conjur variable diff -i secret --from 3 --to 5`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := cmd.Flags().GetString("id")
+			if err != nil {
+				return err
+			}
+
+			from, err := cmd.Flags().GetInt("from")
+			if err != nil {
+				return err
+			}
+
+			to, err := cmd.Flags().GetInt("to")
+			if err != nil {
+				return err
+			}
+			if from <= 0 || to < 0 {
+				return fmt.Errorf("Version must be greater than 0")
+			}
+
+			client, err := clientFactory(cmd)
+			if err != nil {
+				return err
+			}
+
+			fromValue, err := retrieveVariableVersion(client, id, from)
+			if err != nil {
+				return err
+			}
+
+			toValue, err := retrieveVariableVersion(client, id, to)
+			if err != nil {
+				return err
+			}
+
+			if bytes.Equal(fromValue, toValue) {
+				cmd.Printf("Version %d and %s of %s are identical\n", from, describeVariableVersion(to), id)
+				return nil
+			}
+
+			cmd.Printf("Version %d and %s of %s differ\n", from, describeVariableVersion(to), id)
+			cmd.SilenceErrors = true
+			return variableVersionsDifferError{}
+		},
+	}
+
+	cmd.Flags().StringP("id", "i", "", "Provide variable identifier")
+	cmd.Flags().Int("from", 0, "The version to compare")
+	cmd.Flags().Int("to", 0, "The version to compare to (defaults to the current version)")
+	cmd.MarkFlagRequired("id")
+	cmd.MarkFlagRequired("from")
+
+	return cmd
+}
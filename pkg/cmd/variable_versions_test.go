@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockVariableVersionsClient struct {
+	t        *testing.T
+	secrets  []interface{}
+	versions map[int]string
+	added    map[string]string
+}
+
+func (m mockVariableVersionsClient) Resource(resourceID string) (map[string]interface{}, error) {
+	assert.Equal(m.t, "variable:meow", resourceID)
+	return map[string]interface{}{"id": "dev:variable:meow", "secrets": m.secrets}, nil
+}
+
+func (m mockVariableVersionsClient) RetrieveSecret(variableID string) ([]byte, error) {
+	return m.RetrieveSecretWithVersion(variableID, len(m.versions))
+}
+
+func (m mockVariableVersionsClient) RetrieveSecretWithVersion(variableID string, version int) ([]byte, error) {
+	value, ok := m.versions[version]
+	if !ok {
+		return nil, errors.New("404 Not Found. Requested version does not exist")
+	}
+	return []byte(value), nil
+}
+
+func (m mockVariableVersionsClient) AddSecret(variableID string, secretValue string) error {
+	m.added[variableID] = secretValue
+	return nil
+}
+
+func newMockVariableVersionsClient(t *testing.T) mockVariableVersionsClient {
+	return mockVariableVersionsClient{
+		t: t,
+		secrets: []interface{}{
+			map[string]interface{}{"version": float64(2), "expires_at": nil},
+			map[string]interface{}{"version": float64(1), "expires_at": nil},
+			map[string]interface{}{"version": float64(3), "expires_at": "2026-12-01T00:00:00Z"},
+		},
+		versions: map[int]string{1: "one", 2: "two", 3: "one"},
+		added:    map[string]string{},
+	}
+}
+
+func newVariableVersionsTestCmd(client mockVariableVersionsClient) *cobra.Command {
+	factory := func(cmd *cobra.Command) (variableVersionsClient, error) {
+		return client, nil
+	}
+	variableCmd := &cobra.Command{Use: "variable"}
+	variableCmd.AddCommand(newVariableVersionsCmd(factory))
+	variableCmd.AddCommand(newVariableRollbackCmd(factory))
+	variableCmd.AddCommand(newVariableDiffCmd(factory))
+	return variableCmd
+}
+
+func TestVariableVersionsCmd(t *testing.T) {
+	t.Run("lists versions", func(t *testing.T) {
+		stdout, _, err := executeCommandForTest(t, newVariableVersionsTestCmd(newMockVariableVersionsClient(t)), "variable", "versions", "-i", "meow")
+
+		assert.NoError(t, err)
+		assert.Equal(t, `CURRENT  EXPIRES_AT            VERSION
+false                          1
+false                          2
+true     2026-12-01T00:00:00Z  3
+`, stdout)
+	})
+
+	t.Run("query", func(t *testing.T) {
+		stdout, _, err := executeCommandForTest(t, newVariableVersionsTestCmd(newMockVariableVersionsClient(t)), "variable", "versions", "-i", "meow", "--query", "[-1].version")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "3\n", stdout)
+	})
+
+	t.Run("no value", func(t *testing.T) {
+		client := newMockVariableVersionsClient(t)
+		client.secrets = nil
+		stdout, _, err := executeCommandForTest(t, newVariableVersionsTestCmd(client), "variable", "versions", "-i", "meow")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Variable meow has no value\n", stdout)
+	})
+}
+
+func TestVariableRollbackCmd(t *testing.T) {
+	t.Run("restores a version", func(t *testing.T) {
+		client := newMockVariableVersionsClient(t)
+		stdout, _, err := executeCommandForTest(t, newVariableVersionsTestCmd(client), "variable", "rollback", "-i", "meow", "--to", "2")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Rolled back meow to the value of version 2\n", stdout)
+		assert.Equal(t, map[string]string{"meow": "two"}, client.added)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		client := newMockVariableVersionsClient(t)
+		_, stderr, _ := executeCommandForTest(t, newVariableVersionsTestCmd(client), "variable", "rollback", "-i", "meow", "--to", "7")
+
+		assert.Contains(t, stderr, "Error: 404 Not Found. Requested version does not exist\n")
+		assert.Empty(t, client.added)
+	})
+
+	t.Run("invalid version", func(t *testing.T) {
+		_, stderr, _ := executeCommandForTest(t, newVariableVersionsTestCmd(newMockVariableVersionsClient(t)), "variable", "rollback", "-i", "meow", "--to", "0")
+		assert.Contains(t, stderr, "Error: Version must be greater than 0\n")
+	})
+}
+
+func TestVariableDiffCmd(t *testing.T) {
+	t.Run("identical versions", func(t *testing.T) {
+		stdout, _, err := executeCommandForTest(t, newVariableVersionsTestCmd(newMockVariableVersionsClient(t)), "variable", "diff", "-i", "meow", "--from", "1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Version 1 and the current version of meow are identical\n", stdout)
+	})
+
+	t.Run("different versions", func(t *testing.T) {
+		stdout, stderr, err := executeCommandForTest(t, newVariableVersionsTestCmd(newMockVariableVersionsClient(t)), "variable", "diff", "-i", "meow", "--from", "1", "--to", "2")
+
+		var exitErr exitCodeError
+		assert.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 2, exitErr.ExitCode())
+		assert.Equal(t, "Version 1 and version 2 of meow differ\n", stdout)
+		assert.NotContains(t, stdout+stderr, "one")
+		assert.NotContains(t, stdout+stderr, "two")
+	})
+}
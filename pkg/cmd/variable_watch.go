@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/cyberark/conjur-cli-go/pkg/clients"
+	"github.com/cyberark/conjur-cli-go/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+type variableWatchClient interface {
+	RetrieveBatchSecretsSafe(variableIDs []string) (map[string][]byte, error)
+	RefreshToken() error
+	ForceRefreshToken() error
+	GetConfig() conjurapi.Config
+}
+
+type variableWatchClientFactoryFunc func(*cobra.Command) (variableWatchClient, error)
+
+func variableWatchClientFactory(cmd *cobra.Command) (variableWatchClient, error) {
+	return clients.AuthenticatedConjurClientForCommand(cmd)
+}
+
+// maxTemplatePasses bounds the number of times templates are rendered in a cycle to discover
+// the variables they use, e.g. when a variable is only used in a conditional branch
+const maxTemplatePasses = 5
+
+type secretTemplateWatcher struct {
+	cmd       *cobra.Command
+	client    variableWatchClient
+	templates []utils.SecretTemplate
+	sources   map[string][]byte
+	ids       map[string]struct{}
+}
+
+// fetchSecrets retrieves the values of variables, refreshing the access token before it expires
+// and once more if Conjur rejects it
+func (w *secretTemplateWatcher) fetchSecrets(ids []string) (map[string][]byte, error) {
+	if err := w.client.RefreshToken(); err != nil {
+		return nil, err
+	}
+
+	secrets, err := w.client.RetrieveBatchSecretsSafe(ids)
+
+	var conjurErr *response.ConjurError
+	if errors.As(err, &conjurErr) && conjurErr.Code == 401 {
+		if err := w.client.ForceRefreshToken(); err != nil {
+			return nil, err
+		}
+		secrets, err = w.client.RetrieveBatchSecretsSafe(ids)
+	}
+	return secrets, err
+}
+
+// render renders every template with the current values of the variables they use. Variables
+// seen for the first time are fetched and the templates are rendered again.
+func (w *secretTemplateWatcher) render() (map[string][]byte, error) {
+	for pass := 0; pass < maxTemplatePasses; pass++ {
+		fetched := make(map[string]struct{}, len(w.ids))
+		ids := make([]string, 0, len(w.ids))
+		for id := range w.ids {
+			fetched[id] = struct{}{}
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		secrets := map[string][]byte{}
+		if len(ids) > 0 {
+			var err error
+			secrets, err = w.fetchSecrets(ids)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		discovered := false
+		lookup := func(id string) (string, error) {
+			if value, ok := lookupSecret(secrets, w.client.GetConfig().Account, id); ok {
+				return string(value), nil
+			}
+			if _, ok := fetched[id]; ok {
+				return "", fmt.Errorf("value of variable %s was not returned by Conjur", id)
+			}
+			w.ids[id] = struct{}{}
+			discovered = true
+			return "", nil
+		}
+
+		rendered := make(map[string][]byte, len(w.templates))
+		for _, tmpl := range w.templates {
+			out, err := utils.RenderSecretTemplate(w.sources[tmpl.Source], tmpl.Source, lookup)
+			if err != nil {
+				return nil, err
+			}
+			rendered[tmpl.Destination] = out
+		}
+
+		if !discovered {
+			return rendered, nil
+		}
+	}
+	return nil, errors.New("Templates use a different set of variables on every render")
+}
+
+// cycle renders the templates and writes those whose output or mode changed. It returns whether
+// any file was written.
+func (w *secretTemplateWatcher) cycle() (bool, error) {
+	rendered, err := w.render()
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for _, tmpl := range w.templates {
+		out := rendered[tmpl.Destination]
+		if info, err := os.Stat(tmpl.Destination); err == nil && info.Mode().Perm() == tmpl.Mode {
+			if current, err := os.ReadFile(tmpl.Destination); err == nil && bytes.Equal(current, out) {
+				continue
+			}
+		}
+
+		if err := utils.WriteFileAtomic(tmpl.Destination, out, tmpl.Mode); err != nil {
+			return changed, err
+		}
+		w.cmd.Printf("Rendered %s to %s\n", tmpl.Source, tmpl.Destination)
+		changed = true
+	}
+	return changed, nil
+}
+
+func runReloadCommand(cmd *cobra.Command, args []string) error {
+	reload := exec.Command(args[0], args[1:]...)
+	reload.Stdout = cmd.OutOrStdout()
+	reload.Stderr = cmd.ErrOrStderr()
+	return reload.Run()
+}
+
+func newVariableWatchCmd(clientFactory variableWatchClientFactoryFunc) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch [flags] [-- reload-command [args...]]",
+		Short: "Render templates with Conjur secrets and keep them up to date",
+		Long: `Render templates with Conjur secrets and keep them up to date.
+
+Templates use the Go text/template syntax, in which {{ secret "id" }} is replaced by the value of the variable id:
+
+  [database]
+  user = app
+  password = {{ secret "prod/db/password" }}
+
+Each --template is given as source:destination[:mode], the mode of the destination file defaulting to 0600. The variables used by the templates are fetched in a single request every --interval, and a destination file is only written, with an atomic rename, when its content changes. The access token is refreshed as needed.
+
+The command given after -- is run whenever at least one file is written, for instance to reload the service that reads them. The command runs until it is interrupted, or once with --once.
+
+Examples:
+- conjur variable watch -t db.conf.tmpl:/etc/app/db.conf --once
+- conjur variable watch -t db.conf.tmpl:/etc/app/db.conf:0640 --interval 30s -- systemctl reload app`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			specs, err := cmd.Flags().GetStringArray("template")
+			if err != nil {
+				return err
+			}
+
+			interval, err := cmd.Flags().GetDuration("interval")
+			if err != nil {
+				return err
+			}
+			if interval <= 0 {
+				return errors.New("Interval must be greater than 0")
+			}
+
+			once, err := cmd.Flags().GetBool("once")
+			if err != nil {
+				return err
+			}
+
+			if len(args) > 0 && cmd.ArgsLenAtDash() != 0 {
+				return errors.New("The reload command must be given after --")
+			}
+
+			watcher := &secretTemplateWatcher{
+				cmd:     cmd,
+				sources: map[string][]byte{},
+				ids:     map[string]struct{}{},
+			}
+			for _, spec := range specs {
+				tmpl, err := utils.ParseSecretTemplateSpec(spec)
+				if err != nil {
+					return err
+				}
+				if _, ok := watcher.sources[tmpl.Source]; !ok {
+					data, err := os.ReadFile(tmpl.Source)
+					if err != nil {
+						return err
+					}
+					watcher.sources[tmpl.Source] = data
+				}
+				watcher.templates = append(watcher.templates, tmpl)
+			}
+
+			watcher.client, err = clientFactory(cmd)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			for {
+				changed, err := watcher.cycle()
+				if err != nil {
+					if once {
+						return err
+					}
+					// Keep the previous files and retry on the next interval
+					cmd.PrintErrf("Error: %s\n", err)
+				}
+
+				if changed && len(args) > 0 {
+					if err := runReloadCommand(cmd, args); err != nil {
+						if once {
+							return fmt.Errorf("Reload command failed: %s", err)
+						}
+						cmd.PrintErrf("Error: Reload command failed: %s\n", err)
+					}
+				}
+
+				if once {
+					return nil
+				}
+
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(interval):
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringArrayP("template", "t", nil, "Template to render, as source:destination[:mode] (can be repeated)")
+	cmd.Flags().Duration("interval", time.Minute, "Interval between two checks of the variables")
+	cmd.Flags().Bool("once", false, "Render the templates once and exit")
+	cmd.MarkFlagRequired("template")
+
+	return cmd
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/cyberark/conjur-cli-go/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockVariableWatchClient struct {
+	values        map[string]string
+	requests      *[][]string
+	unauthorized  *int
+	forceRefreshs *int
+}
+
+func (m mockVariableWatchClient) RetrieveBatchSecretsSafe(variableIDs []string) (map[string][]byte, error) {
+	*m.requests = append(*m.requests, variableIDs)
+	if *m.unauthorized > 0 {
+		*m.unauthorized--
+		return nil, &response.ConjurError{Code: 401, Message: "Unauthorized"}
+	}
+
+	secrets := map[string][]byte{}
+	for _, id := range variableIDs {
+		if value, ok := m.values[id]; ok {
+			secrets["dev:variable:"+id] = []byte(value)
+		}
+	}
+	return secrets, nil
+}
+
+func (m mockVariableWatchClient) GetConfig() conjurapi.Config {
+	return conjurapi.Config{Account: "dev"}
+}
+
+func (m mockVariableWatchClient) RefreshToken() error {
+	return nil
+}
+
+func (m mockVariableWatchClient) ForceRefreshToken() error {
+	*m.forceRefreshs++
+	return nil
+}
+
+func newMockVariableWatchClient(values map[string]string) mockVariableWatchClient {
+	return mockVariableWatchClient{
+		values:        values,
+		requests:      &[][]string{},
+		unauthorized:  new(int),
+		forceRefreshs: new(int),
+	}
+}
+
+func newVariableWatchTestCmd(client mockVariableWatchClient) *cobra.Command {
+	variableCmd := &cobra.Command{Use: "variable"}
+	variableCmd.AddCommand(newVariableWatchCmd(func(cmd *cobra.Command) (variableWatchClient, error) {
+		return client, nil
+	}))
+	return variableCmd
+}
+
+func TestVariableWatchCmd(t *testing.T) {
+	writeTemplate := func(t *testing.T, dir string, content string) string {
+		path := filepath.Join(dir, "db.conf.tmpl")
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	t.Run("renders templates once and runs the reload command", func(t *testing.T) {
+		dir := t.TempDir()
+		source := writeTemplate(t, dir, `user={{ secret "prod/db/user" }}
+{{ if eq (secret "prod/db/user") "admin" }}password={{ secret "prod/db/password" }}{{ end }}
+`)
+		destination := filepath.Join(dir, "db.conf")
+
+		client := newMockVariableWatchClient(map[string]string{"prod/db/user": "admin", "prod/db/password": "s3cr3t"})
+		stdout, _, err := executeCommandForTest(t, newVariableWatchTestCmd(client),
+			"variable", "watch", "-t", source+":"+destination+":0640", "--once", "--", "echo", "reloaded")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Rendered "+source+" to "+destination+"\nreloaded\n", stdout)
+		assert.Equal(t, [][]string{{"prod/db/user"}, {"prod/db/password", "prod/db/user"}}, *client.requests)
+
+		data, err := os.ReadFile(destination)
+		assert.NoError(t, err)
+		assert.Equal(t, "user=admin\npassword=s3cr3t\n", string(data))
+
+		info, err := os.Stat(destination)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	})
+
+	t.Run("missing variable", func(t *testing.T) {
+		dir := t.TempDir()
+		source := writeTemplate(t, dir, `{{ secret "prod/missing" }}`)
+
+		_, stderr, _ := executeCommandForTest(t, newVariableWatchTestCmd(newMockVariableWatchClient(nil)),
+			"variable", "watch", "-t", source+":"+filepath.Join(dir, "out"), "--once")
+		assert.Contains(t, stderr, "value of variable prod/missing was not returned by Conjur")
+	})
+
+	t.Run("invalid template spec", func(t *testing.T) {
+		_, stderr, _ := executeCommandForTest(t, newVariableWatchTestCmd(newMockVariableWatchClient(nil)), "variable", "watch", "-t", "db.conf.tmpl", "--once")
+		assert.Contains(t, stderr, `Error: template "db.conf.tmpl" must be source:destination[:mode]`)
+	})
+}
+
+func TestSecretTemplateWatcherCycle(t *testing.T) {
+	dir := t.TempDir()
+	destination := filepath.Join(dir, "token")
+	values := map[string]string{"prod/token": "one"}
+	client := newMockVariableWatchClient(values)
+
+	cmd := newVariableWatchTestCmd(client)
+	watcher := &secretTemplateWatcher{
+		cmd:       cmd,
+		client:    client,
+		templates: []utils.SecretTemplate{{Source: "token.tmpl", Destination: destination, Mode: 0600}},
+		sources:   map[string][]byte{"token.tmpl": []byte(`{{ secret "prod/token" }}`)},
+		ids:       map[string]struct{}{},
+	}
+
+	changed, err := watcher.cycle()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	changed, err = watcher.cycle()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	values["prod/token"] = "two"
+	*client.unauthorized = 1
+	changed, err = watcher.cycle()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 1, *client.forceRefreshs)
+
+	data, err := os.ReadFile(destination)
+	assert.NoError(t, err)
+	assert.Equal(t, "two", string(data))
+}
@@ -33,14 +33,16 @@ func newWhoamiCommand(clientFactory whoamiClientFactoryFunc) *cobra.Command {
 				return err
 			}
 
-			userData, err = utils.PrettyPrintJSON(userData)
-			if err != nil {
-				return err
-			}
+			return printResult(cmd, userData, func() error {
+				prettyData, err := utils.PrettyPrintJSON(userData)
+				if err != nil {
+					return err
+				}
 
-			cmd.Println(string(userData))
+				cmd.Println(string(prettyData))
 
-			return nil
+				return nil
+			})
 		},
 	}
 }
@@ -44,6 +44,16 @@ var whoamiCmdTestCases = []struct {
 			assert.Contains(t, stdout, expectedOut)
 		},
 	},
+	{
+		name: "query response",
+		args: []string{"whoami", "--output", "tsv", "--query", ".user"},
+		whoami: func() ([]byte, error) {
+			return []byte(`{"user":"test","account":"dev"}`), nil
+		},
+		assert: func(t *testing.T, stdout, stderr string, err error) {
+			assert.Equal(t, "test\n", stdout)
+		},
+	},
 	{
 		name: "non-json response",
 		args: []string{"whoami"},
@@ -112,6 +112,13 @@ func newUsernamePrompt() *survey.Question {
 	}
 }
 
+func newAPIKeyPrompt() *survey.Question {
+	return &survey.Question{
+		Prompt:   &survey.Password{Message: "Please enter your API key (it will not be echoed):"},
+		Validate: survey.Required,
+	}
+}
+
 func newChangePasswordPrompt() *survey.Question {
 	return &survey.Question{
 		Prompt:   &survey.Password{Message: "Please enter a new password (it will not be echoed):"},
@@ -119,6 +126,26 @@ func newChangePasswordPrompt() *survey.Question {
 	}
 }
 
+func newVariableValuePrompt(variableID string) *survey.Question {
+	return &survey.Question{
+		Prompt:   &survey.Password{Message: fmt.Sprintf("Enter the value of %s (it will not be echoed):", variableID)},
+		Validate: survey.Required,
+	}
+}
+
+// AskForVariableValue presents a prompt to retrieve the value of a variable from the user
+func AskForVariableValue(variableID string) (string, error) {
+	var userInput string
+
+	q := newVariableValuePrompt(variableID)
+	err := survey.AskOne(q.Prompt, &userInput, survey.WithValidator(q.Validate), survey.WithShowCursor(true))
+	if err != nil {
+		return "", err
+	}
+
+	return userInput, nil
+}
+
 // MaybeAskForCredentials optionally presents a prompt to retrieve missing username and/or password from the user
 func MaybeAskForCredentials(username string, password string) (string, string, error) {
 	var err error
@@ -145,6 +172,32 @@ func MaybeAskForCredentials(username string, password string) (string, string, e
 	return username, password, err
 }
 
+// MaybeAskForAPIKeyCredentials optionally presents a prompt to retrieve missing login and/or API key from the user
+func MaybeAskForAPIKeyCredentials(login string, apiKey string) (string, string, error) {
+	var err error
+	var userInput string
+
+	if len(login) == 0 {
+		q := newUsernamePrompt()
+		err := survey.AskOne(q.Prompt, &userInput, survey.WithValidator(q.Validate), survey.WithShowCursor(true))
+		if err != nil {
+			return "", "", err
+		}
+		login = userInput
+	}
+
+	if len(apiKey) == 0 {
+		q := newAPIKeyPrompt()
+		err := survey.AskOne(q.Prompt, &userInput, survey.WithValidator(q.Validate), survey.WithShowCursor(true))
+		if err != nil {
+			return "", "", err
+		}
+		apiKey = userInput
+	}
+
+	return login, apiKey, err
+}
+
 // MaybeAskForChangePassword optionally presents a prompt to retrieve missing new password from the user
 func MaybeAskForChangePassword(newPassword string) (string, error) {
 	var err error
@@ -204,3 +257,16 @@ func MaybeAskToOverwriteFile(filePath string) error {
 
 	return nil
 }
+
+// AskForBackupPassphrase presents a prompt to retrieve the passphrase of a backup from the user
+func AskForBackupPassphrase() (string, error) {
+	var userInput string
+
+	prompt := &survey.Password{Message: "Enter the passphrase of the backup (it will not be echoed):"}
+	err := survey.AskOne(prompt, &userInput, survey.WithValidator(survey.Required), survey.WithShowCursor(true))
+	if err != nil {
+		return "", err
+	}
+
+	return userInput, nil
+}
@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+)
+
+// BackupFormatVersion is the version of the backup archive written by 'conjur backup create'
+const BackupFormatVersion = 1
+
+// Names of the files of a backup archive
+const (
+	BackupManifestFile   = "manifest.json"
+	BackupPolicyYAMLFile = "policy.yml"
+	BackupPolicyJSONFile = "policy.json"
+	BackupSecretsFile    = "secrets.json.age"
+)
+
+// BackupManifest describes the content of a backup archive
+type BackupManifest struct {
+	Version   int    `json:"version"`
+	CreatedAt string `json:"created_at"`
+	Branch    string `json:"branch"`
+	Variables int    `json:"variables"`
+}
+
+// Backup is the content of a backup archive. Secrets holds the age-encrypted JSON object mapping
+// variable IDs to base64-encoded values, and is empty when the values were not backed up.
+type Backup struct {
+	Manifest   BackupManifest
+	PolicyYAML []byte
+	PolicyJSON []byte
+	Secrets    []byte
+}
+
+// WriteBackup writes a backup as a gzipped tar archive
+func WriteBackup(w io.Writer, backup Backup) error {
+	manifest, err := json.MarshalIndent(backup.Manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{BackupManifestFile, manifest},
+		{BackupPolicyYAMLFile, backup.PolicyYAML},
+		{BackupPolicyJSONFile, backup.PolicyJSON},
+	}
+	if len(backup.Secrets) > 0 {
+		files = append(files, struct {
+			name string
+			data []byte
+		}{BackupSecretsFile, backup.Secrets})
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	modTime := time.Now()
+	for _, file := range files {
+		header := &tar.Header{Name: file.name, Mode: 0600, Size: int64(len(file.data)), ModTime: modTime}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ReadBackup reads a backup archive written by WriteBackup
+func ReadBackup(r io.Reader) (Backup, error) {
+	backup := Backup{}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return backup, fmt.Errorf("not a backup archive: %s", err)
+	}
+	tr := tar.NewReader(gz)
+
+	hasManifest := false
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return backup, err
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return backup, err
+		}
+
+		switch header.Name {
+		case BackupManifestFile:
+			if err := json.Unmarshal(data, &backup.Manifest); err != nil {
+				return backup, fmt.Errorf("invalid %s: %s", BackupManifestFile, err)
+			}
+			hasManifest = true
+		case BackupPolicyYAMLFile:
+			backup.PolicyYAML = data
+		case BackupPolicyJSONFile:
+			backup.PolicyJSON = data
+		case BackupSecretsFile:
+			backup.Secrets = data
+		}
+	}
+
+	switch {
+	case !hasManifest:
+		return backup, fmt.Errorf("not a backup archive: %s is missing", BackupManifestFile)
+	case backup.Manifest.Version != BackupFormatVersion:
+		return backup, fmt.Errorf("unsupported backup version %d", backup.Manifest.Version)
+	case len(backup.PolicyYAML) == 0:
+		return backup, fmt.Errorf("invalid backup archive: %s is missing", BackupPolicyYAMLFile)
+	}
+	return backup, nil
+}
+
+// BackupRecipients returns the age recipients the secrets of a backup are encrypted to: the given
+// age public keys, or the passphrase when there are none
+func BackupRecipients(publicKeys []string, passphrase string) ([]age.Recipient, error) {
+	if len(publicKeys) == 0 {
+		if passphrase == "" {
+			return nil, errors.New("a passphrase or an age recipient is required to back up secrets")
+		}
+		recipient, err := age.NewScryptRecipient(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Recipient{recipient}, nil
+	}
+
+	recipients := make([]age.Recipient, 0, len(publicKeys))
+	for _, publicKey := range publicKeys {
+		recipient, err := age.ParseX25519Recipient(strings.TrimSpace(publicKey))
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, nil
+}
+
+// BackupIdentities returns the age identities used to decrypt the secrets of a backup: those of
+// an age identity file, or the passphrase when there is no file
+func BackupIdentities(identityFile string, passphrase string) ([]age.Identity, error) {
+	if identityFile == "" {
+		if passphrase == "" {
+			return nil, errors.New("a passphrase or an age identity file is required to restore secrets")
+		}
+		identity, err := age.NewScryptIdentity(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Identity{identity}, nil
+	}
+
+	file, err := os.Open(identityFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	identities, err := age.ParseIdentities(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", identityFile, err)
+	}
+	return identities, nil
+}
+
+// EncryptBackupSecrets encrypts the values of variables for the given recipients. The values are
+// kept as bytes, so that binary values are backed up unchanged.
+func EncryptBackupSecrets(secrets map[string][]byte, recipients ...age.Recipient) ([]byte, error) {
+	data, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &bytes.Buffer{}
+	w, err := age.Encrypt(out, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// DecryptBackupSecrets decrypts the values of variables encrypted by EncryptBackupSecrets
+func DecryptBackupSecrets(data []byte, identities ...age.Identity) (map[string][]byte, error) {
+	r, err := age.Decrypt(bytes.NewReader(data), identities...)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := map[string][]byte{}
+	if err := json.NewDecoder(r).Decode(&secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupArchive(t *testing.T) {
+	backup := Backup{
+		Manifest:   BackupManifest{Version: BackupFormatVersion, CreatedAt: "2026-10-17T00:00:00Z", Branch: "root", Variables: 1},
+		PolicyYAML: []byte("- !variable db/password\n"),
+		PolicyJSON: []byte(`[{"!variable": {"id": "db/password"}}]`),
+		Secrets:    []byte("encrypted"),
+	}
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, WriteBackup(out, backup))
+
+	read, err := ReadBackup(bytes.NewReader(out.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, backup, read)
+
+	_, err = ReadBackup(bytes.NewReader([]byte("policy")))
+	assert.ErrorContains(t, err, "not a backup archive")
+
+	out.Reset()
+	backup.Manifest.Version = 2
+	assert.NoError(t, WriteBackup(out, backup))
+	_, err = ReadBackup(bytes.NewReader(out.Bytes()))
+	assert.EqualError(t, err, "unsupported backup version 2")
+}
+
+func TestBackupSecrets(t *testing.T) {
+	secrets := map[string][]byte{"db/password": []byte("secret"), "db/user": []byte("admin"), "db/key": {0xff, 0x00, 0xfe, 0x80}}
+
+	t.Run("age keys", func(t *testing.T) {
+		identity, err := age.GenerateX25519Identity()
+		assert.NoError(t, err)
+
+		recipients, err := BackupRecipients([]string{identity.Recipient().String()}, "")
+		assert.NoError(t, err)
+		data, err := EncryptBackupSecrets(secrets, recipients...)
+		assert.NoError(t, err)
+		assert.NotContains(t, string(data), "secret")
+
+		identityFile := filepath.Join(t.TempDir(), "key.txt")
+		assert.NoError(t, os.WriteFile(identityFile, []byte("# key\n"+identity.String()+"\n"), 0600))
+		identities, err := BackupIdentities(identityFile, "")
+		assert.NoError(t, err)
+		decrypted, err := DecryptBackupSecrets(data, identities...)
+		assert.NoError(t, err)
+		assert.Equal(t, secrets, decrypted)
+
+		other, err := age.GenerateX25519Identity()
+		assert.NoError(t, err)
+		_, err = DecryptBackupSecrets(data, other)
+		assert.ErrorContains(t, err, "no identity matched")
+	})
+
+	t.Run("passphrase", func(t *testing.T) {
+		recipients, err := BackupRecipients(nil, "correct horse")
+		assert.NoError(t, err)
+		data, err := EncryptBackupSecrets(secrets, recipients...)
+		assert.NoError(t, err)
+
+		identities, err := BackupIdentities("", "correct horse")
+		assert.NoError(t, err)
+		decrypted, err := DecryptBackupSecrets(data, identities...)
+		assert.NoError(t, err)
+		assert.Equal(t, secrets, decrypted)
+
+		identities, err = BackupIdentities("", "wrong")
+		assert.NoError(t, err)
+		_, err = DecryptBackupSecrets(data, identities...)
+		assert.Error(t, err)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		_, err := BackupRecipients(nil, "")
+		assert.EqualError(t, err, "a passphrase or an age recipient is required to back up secrets")
+		_, err = BackupRecipients([]string{"not-a-key"}, "")
+		assert.Error(t, err)
+		_, err = BackupIdentities("", "")
+		assert.EqualError(t, err, "a passphrase or an age identity file is required to restore secrets")
+	})
+}
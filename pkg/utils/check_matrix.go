@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PermissionCheck is an expected privilege of a role on a resource
+type PermissionCheck struct {
+	Name      string `yaml:"name" json:"name,omitempty"`
+	Role      string `yaml:"role" json:"role"`
+	Resource  string `yaml:"resource" json:"resource"`
+	Privilege string `yaml:"privilege" json:"privilege"`
+	Expected  *bool  `yaml:"expected" json:"expected"`
+}
+
+// String describes the check by its name, or by its role, privilege and resource
+func (c PermissionCheck) String() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return fmt.Sprintf("%s %s %s", c.Role, c.Privilege, c.Resource)
+}
+
+// ParseCheckMatrix parses a YAML or JSON list of permission checks. Checks without an expected
+// result are expected to be allowed.
+func ParseCheckMatrix(data []byte) ([]PermissionCheck, error) {
+	checks := []PermissionCheck{}
+	if err := yaml.Unmarshal(data, &checks); err != nil {
+		return nil, err
+	}
+
+	for i := range checks {
+		check := &checks[i]
+		switch {
+		case check.Role == "":
+			return nil, fmt.Errorf("check %d: role is required", i+1)
+		case check.Resource == "":
+			return nil, fmt.Errorf("check %d: resource is required", i+1)
+		case check.Privilege == "":
+			return nil, fmt.Errorf("check %d: privilege is required", i+1)
+		}
+		if check.Expected == nil {
+			allowed := true
+			check.Expected = &allowed
+		}
+	}
+	return checks, nil
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCheckMatrix(t *testing.T) {
+	checks, err := ParseCheckMatrix([]byte(`
+- name: billing reads its password
+  role: host:apps/billing
+  resource: variable:prod/db/password
+  privilege: execute
+- role: user:bob
+  resource: variable:prod/db/password
+  privilege: update
+  expected: false
+`))
+	assert.NoError(t, err)
+	assert.Len(t, checks, 2)
+	assert.Equal(t, "billing reads its password", checks[0].String())
+	assert.True(t, *checks[0].Expected)
+	assert.Equal(t, "user:bob update variable:prod/db/password", checks[1].String())
+	assert.False(t, *checks[1].Expected)
+
+	checks, err = ParseCheckMatrix([]byte(`[{"role": "user:bob", "resource": "variable:x", "privilege": "read", "expected": true}]`))
+	assert.NoError(t, err)
+	assert.Len(t, checks, 1)
+
+	_, err = ParseCheckMatrix([]byte("- role: user:bob\n  privilege: read\n"))
+	assert.EqualError(t, err, "check 1: resource is required")
+
+	_, err = ParseCheckMatrix([]byte("role: user:bob\n"))
+	assert.ErrorContains(t, err, "cannot unmarshal")
+}
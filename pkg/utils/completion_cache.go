@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CompletionCacheDirEnvVar overrides the directory where shell completion results are cached
+const CompletionCacheDirEnvVar = "CONJUR_COMPLETION_CACHE_DIR"
+
+// CompletionCache stores the results of shell completion queries on disk for a short time, so
+// that completing the same prefix again is fast and works while the server is unreachable
+type CompletionCache struct {
+	Dir string
+}
+
+type completionCacheEntry struct {
+	CreatedAt time.Time `json:"created_at"`
+	Values    []string  `json:"values"`
+}
+
+// DefaultCompletionCache returns the completion cache located in CONJUR_COMPLETION_CACHE_DIR, or
+// in the conjur/completion directory of the user cache directory when the variable is not set
+func DefaultCompletionCache() (CompletionCache, error) {
+	if dir := os.Getenv(CompletionCacheDirEnvVar); dir != "" {
+		return CompletionCache{Dir: dir}, nil
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return CompletionCache{}, err
+	}
+	return CompletionCache{Dir: filepath.Join(cacheDir, "conjur", "completion")}, nil
+}
+
+// path returns the file of a key, hashed so that any key is a valid file name
+func (c CompletionCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get returns the values cached under a key if they are not older than maxAge
+func (c CompletionCache) Get(key string, maxAge time.Duration) ([]string, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	entry := completionCacheEntry{}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	if time.Since(entry.CreatedAt) > maxAge {
+		return nil, false
+	}
+	return entry.Values, true
+}
+
+// Set caches values under a key. The cache is only readable by the current user, as it holds
+// resource IDs.
+func (c CompletionCache) Set(key string, values []string) error {
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(completionCacheEntry{CreatedAt: time.Now(), Values: values})
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(c.path(key), data, 0600)
+}
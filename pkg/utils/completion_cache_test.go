@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletionCache(t *testing.T) {
+	cache := CompletionCache{Dir: t.TempDir() + "/completion"}
+
+	_, ok := cache.Get("variable\nprod", time.Minute)
+	assert.False(t, ok)
+
+	assert.NoError(t, cache.Set("variable\nprod", []string{"dev:variable:prod/db/password"}))
+	ids, ok := cache.Get("variable\nprod", time.Minute)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"dev:variable:prod/db/password"}, ids)
+
+	_, ok = cache.Get("variable\nstaging", time.Minute)
+	assert.False(t, ok)
+
+	time.Sleep(10 * time.Millisecond)
+	_, ok = cache.Get("variable\nprod", time.Millisecond)
+	assert.False(t, ok)
+
+	info, err := os.Stat(cache.path("variable\nprod"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestDefaultCompletionCache(t *testing.T) {
+	t.Setenv(CompletionCacheDirEnvVar, "/tmp/conjur-completion")
+	cache, err := DefaultCompletionCache()
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/conjur-completion", cache.Dir)
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// GraphFormatDOT is the Graphviz DOT language
+	GraphFormatDOT = "dot"
+	// GraphFormatMermaid is a Mermaid flowchart
+	GraphFormatMermaid = "mermaid"
+	// GraphFormatJSON is the JSON representation of Graph
+	GraphFormatJSON = "json"
+)
+
+// GraphFormats lists the formats supported by FormatGraph
+var GraphFormats = []string{GraphFormatDOT, GraphFormatMermaid, GraphFormatJSON}
+
+// GraphNode is a Conjur role or resource, identified by its fully-qualified ID
+type GraphNode struct {
+	ID    string `json:"id"`
+	Kind  string `json:"kind"`
+	Depth int    `json:"depth"`
+	// Privileges are the privileges the node holds on the root resource, when the graph starts
+	// from a resource
+	Privileges []string `json:"privileges,omitempty"`
+}
+
+// GraphEdge links a member to a role it belongs to, or a role to a resource it has privileges on
+type GraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Label string `json:"label"`
+}
+
+// Graph is a graph of Conjur roles and resources
+type Graph struct {
+	Root  string      `json:"root"`
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// KindFromID returns the kind of a fully-qualified Conjur ID (account:kind:id)
+func KindFromID(id string) string {
+	parts := strings.SplitN(id, ":", 3)
+	if len(parts) == 3 {
+		return parts[1]
+	}
+	return ""
+}
+
+// Sort orders nodes by depth and ID and edges by endpoints, so that the output is stable
+func (g *Graph) Sort() {
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		if g.Nodes[i].Depth != g.Nodes[j].Depth {
+			return g.Nodes[i].Depth < g.Nodes[j].Depth
+		}
+		return g.Nodes[i].ID < g.Nodes[j].ID
+	})
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+}
+
+// FormatGraph renders a graph as DOT, Mermaid or JSON
+func FormatGraph(g Graph, format string) (string, error) {
+	switch format {
+	case GraphFormatDOT:
+		return formatDOT(g), nil
+	case GraphFormatMermaid:
+		return formatMermaid(g), nil
+	case GraphFormatJSON:
+		return PrettyPrintToJSON(g)
+	}
+	return "", fmt.Errorf("format must be one of %s", strings.Join(GraphFormats, ", "))
+}
+
+// graphNodeShapes distinguishes roles from resources that are not roles
+var graphNodeShapes = map[string]string{
+	"user":   "ellipse",
+	"host":   "ellipse",
+	"group":  "ellipse",
+	"layer":  "ellipse",
+	"policy": "ellipse",
+}
+
+func formatDOT(g Graph) string {
+	out := &strings.Builder{}
+	out.WriteString("digraph conjur {\n  rankdir=LR;\n")
+	for _, node := range g.Nodes {
+		shape, ok := graphNodeShapes[node.Kind]
+		if !ok {
+			shape = "box"
+		}
+		attributes := fmt.Sprintf("shape=%s", shape)
+		if node.ID == g.Root {
+			attributes += ", style=bold"
+		}
+		fmt.Fprintf(out, "  %q [%s];\n", node.ID, attributes)
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(out, "  %q -> %q [label=%q];\n", edge.From, edge.To, edge.Label)
+	}
+	out.WriteString("}")
+	return out.String()
+}
+
+func formatMermaid(g Graph) string {
+	// Mermaid node IDs cannot contain the characters of Conjur IDs, so nodes are numbered and
+	// labeled with their ID
+	names := make(map[string]string, len(g.Nodes))
+	out := &strings.Builder{}
+	out.WriteString("flowchart LR\n")
+	for i, node := range g.Nodes {
+		name := fmt.Sprintf("n%d", i)
+		names[node.ID] = name
+
+		label := strings.ReplaceAll(node.ID, `"`, "#quot;")
+		if _, ok := graphNodeShapes[node.Kind]; ok {
+			fmt.Fprintf(out, "  %s([\"%s\"])\n", name, label)
+		} else {
+			fmt.Fprintf(out, "  %s[\"%s\"]\n", name, label)
+		}
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(out, "  %s -->|%s| %s\n", names[edge.From], edge.Label, names[edge.To])
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKindFromID(t *testing.T) {
+	assert.Equal(t, "variable", KindFromID("dev:variable:prod/db:password"))
+	assert.Equal(t, "", KindFromID("variable"))
+}
+
+func TestFormatGraph(t *testing.T) {
+	g := Graph{
+		Root: "dev:variable:db",
+		Nodes: []GraphNode{
+			{ID: "dev:variable:db", Kind: "variable"},
+			{ID: "dev:host:app\"1", Kind: "host", Depth: 1},
+		},
+		Edges: []GraphEdge{{From: "dev:host:app\"1", To: "dev:variable:db", Label: "execute"}},
+	}
+
+	out, err := FormatGraph(g, GraphFormatDOT)
+	assert.NoError(t, err)
+	assert.Equal(t, `digraph conjur {
+  rankdir=LR;
+  "dev:variable:db" [shape=box, style=bold];
+  "dev:host:app\"1" [shape=ellipse];
+  "dev:host:app\"1" -> "dev:variable:db" [label="execute"];
+}`, out)
+
+	out, err = FormatGraph(g, GraphFormatMermaid)
+	assert.NoError(t, err)
+	assert.Equal(t, `flowchart LR
+  n0["dev:variable:db"]
+  n1(["dev:host:app#quot;1"])
+  n1 -->|execute| n0`, out)
+
+	_, err = FormatGraph(g, "svg")
+	assert.EqualError(t, err, "format must be one of dot, mermaid, json")
+}
@@ -0,0 +1,388 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// OutputFormatJSON renders results as indented JSON
+	OutputFormatJSON = "json"
+	// OutputFormatYAML renders results as YAML
+	OutputFormatYAML = "yaml"
+	// OutputFormatTable renders results as an aligned table with a header
+	OutputFormatTable = "table"
+	// OutputFormatTSV renders results as tab-separated values without a header
+	OutputFormatTSV = "tsv"
+)
+
+// OutputFormats lists the formats supported by FormatOutput
+var OutputFormats = []string{OutputFormatJSON, OutputFormatYAML, OutputFormatTable, OutputFormatTSV}
+
+// FormatOutput applies the query to obj, then renders the selected data in the given format
+func FormatOutput(obj interface{}, format string, query string) (string, error) {
+	data, err := normalize(obj)
+	if err != nil {
+		return "", err
+	}
+
+	if query != "" {
+		data, err = ApplyQuery(data, query)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	switch format {
+	case OutputFormatJSON:
+		return PrettyPrintToJSON(data)
+	case OutputFormatYAML:
+		out, err := yaml.Marshal(data)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(string(out), "\n"), nil
+	case OutputFormatTable:
+		return formatTable(data), nil
+	case OutputFormatTSV:
+		return formatTSV(data), nil
+	default:
+		return "", fmt.Errorf("output format must be one of %s", strings.Join(OutputFormats, ", "))
+	}
+}
+
+// normalize converts obj into the generic representation produced by encoding/json, so that
+// structs, typed maps and raw JSON all render the same way
+func normalize(obj interface{}) (interface{}, error) {
+	var raw []byte
+	switch v := obj.(type) {
+	case []byte:
+		raw = v
+	case json.RawMessage:
+		raw = v
+	default:
+		var err error
+		raw, err = json.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+	return convertNumbers(data), nil
+}
+
+func convertNumbers(data interface{}) interface{} {
+	switch v := data.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = convertNumbers(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = convertNumbers(value)
+		}
+	}
+	return data
+}
+
+// queryStep is a single element of a parsed query
+type queryStep struct {
+	key     string
+	index   int
+	isIndex bool
+	iterate bool
+}
+
+// ApplyQuery selects fields from data using a small subset of jq and JSONPath syntax:
+//
+//	.                 the whole document
+//	.key or ["key"]   a field of an object
+//	[0], [-1]         an element of an array
+//	[] or [*]         every element of an array or every value of an object
+//
+// Steps can be chained, e.g. ".[].id" or "$.members[*].member". Once a step iterates,
+// the following steps apply to each element and the result is a list.
+func ApplyQuery(data interface{}, query string) (interface{}, error) {
+	steps, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	current := []interface{}{data}
+	iterated := false
+	for _, step := range steps {
+		next := make([]interface{}, 0, len(current))
+		for _, value := range current {
+			selected, err := applyQueryStep(value, step)
+			if err != nil {
+				return nil, fmt.Errorf("query %q: %s", query, err)
+			}
+			next = append(next, selected...)
+		}
+		current = next
+		iterated = iterated || step.iterate
+	}
+
+	if iterated {
+		return current, nil
+	}
+	return current[0], nil
+}
+
+func applyQueryStep(value interface{}, step queryStep) ([]interface{}, error) {
+	switch {
+	case step.iterate:
+		switch v := value.(type) {
+		case []interface{}:
+			return v, nil
+		case map[string]interface{}:
+			keys := sortedKeys(v)
+			values := make([]interface{}, 0, len(keys))
+			for _, key := range keys {
+				values = append(values, v[key])
+			}
+			return values, nil
+		case nil:
+			return []interface{}{}, nil
+		}
+		return nil, fmt.Errorf("cannot iterate over %s", describeType(value))
+	case step.isIndex:
+		switch v := value.(type) {
+		case []interface{}:
+			index := step.index
+			if index < 0 {
+				index += len(v)
+			}
+			if index < 0 || index >= len(v) {
+				return []interface{}{nil}, nil
+			}
+			return []interface{}{v[index]}, nil
+		case nil:
+			return []interface{}{nil}, nil
+		}
+		return nil, fmt.Errorf("cannot index %s with a number", describeType(value))
+	default:
+		switch v := value.(type) {
+		case map[string]interface{}:
+			return []interface{}{v[step.key]}, nil
+		case nil:
+			return []interface{}{nil}, nil
+		}
+		return nil, fmt.Errorf("cannot get field %q of %s", step.key, describeType(value))
+	}
+}
+
+func describeType(value interface{}) string {
+	switch value.(type) {
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	default:
+		return "a number"
+	}
+}
+
+func parseQuery(query string) ([]queryStep, error) {
+	invalid := func() ([]queryStep, error) {
+		return nil, fmt.Errorf("invalid query %q", query)
+	}
+
+	query = strings.TrimSpace(query)
+	query = strings.TrimPrefix(query, "$")
+
+	steps := []queryStep{}
+	for i := 0; i < len(query); {
+		switch query[i] {
+		case '.':
+			i++
+			if i < len(query) && query[i] == '"' {
+				end := strings.IndexByte(query[i+1:], '"')
+				if end < 0 {
+					return invalid()
+				}
+				steps = append(steps, queryStep{key: query[i+1 : i+1+end]})
+				i += end + 2
+				continue
+			}
+			start := i
+			for i < len(query) && isQueryIdentChar(query[i]) {
+				i++
+			}
+			if i > start {
+				steps = append(steps, queryStep{key: query[start:i]})
+			}
+		case '[':
+			end := strings.IndexByte(query[i:], ']')
+			if end < 0 {
+				return invalid()
+			}
+			content := strings.TrimSpace(query[i+1 : i+end])
+			i += end + 1
+
+			switch {
+			case content == "" || content == "*":
+				steps = append(steps, queryStep{iterate: true})
+			case len(content) >= 2 && (content[0] == '"' || content[0] == '\'') && content[len(content)-1] == content[0]:
+				steps = append(steps, queryStep{key: content[1 : len(content)-1]})
+			default:
+				index, err := strconv.Atoi(content)
+				if err != nil {
+					return invalid()
+				}
+				steps = append(steps, queryStep{index: index, isIndex: true})
+			}
+		default:
+			return invalid()
+		}
+	}
+	return steps, nil
+}
+
+func isQueryIdentChar(c byte) bool {
+	return c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// tableRows flattens data into a header and rows. Arrays of objects produce one row per
+// object with the union of their keys as columns, a single object produces a single row,
+// and arrays of scalars produce a single VALUE column.
+func tableRows(data interface{}) ([]string, [][]string) {
+	switch v := data.(type) {
+	case []interface{}:
+		columns := map[string]struct{}{}
+		allObjects := len(v) > 0
+		for _, item := range v {
+			object, ok := item.(map[string]interface{})
+			if !ok {
+				allObjects = false
+				break
+			}
+			for key := range object {
+				columns[key] = struct{}{}
+			}
+		}
+
+		if !allObjects {
+			rows := make([][]string, 0, len(v))
+			for _, item := range v {
+				rows = append(rows, []string{formatCell(item)})
+			}
+			return []string{"VALUE"}, rows
+		}
+
+		header := make([]string, 0, len(columns))
+		for key := range columns {
+			header = append(header, key)
+		}
+		sort.Strings(header)
+
+		rows := make([][]string, 0, len(v))
+		for _, item := range v {
+			object := item.(map[string]interface{})
+			row := make([]string, 0, len(header))
+			for _, key := range header {
+				row = append(row, formatCell(object[key]))
+			}
+			rows = append(rows, row)
+		}
+		return header, rows
+	case map[string]interface{}:
+		header := sortedKeys(v)
+		row := make([]string, 0, len(header))
+		for _, key := range header {
+			row = append(row, formatCell(v[key]))
+		}
+		return header, [][]string{row}
+	default:
+		return []string{"VALUE"}, [][]string{{formatCell(v)}}
+	}
+}
+
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		out, _ := json.Marshal(v)
+		return string(out)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func formatTable(data interface{}) string {
+	header, rows := tableRows(data)
+
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+
+	upperHeader := make([]string, 0, len(header))
+	for _, column := range header {
+		upperHeader = append(upperHeader, strings.ToUpper(column))
+	}
+	fmt.Fprintln(w, strings.Join(upperHeader, "\t"))
+	for _, row := range rows {
+		cells := make([]string, 0, len(row))
+		for _, cell := range row {
+			cells = append(cells, escapeCell(cell))
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	w.Flush()
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func formatTSV(data interface{}) string {
+	_, rows := tableRows(data)
+
+	lines := make([]string, 0, len(rows))
+	for _, row := range rows {
+		cells := make([]string, 0, len(row))
+		for _, cell := range row {
+			cells = append(cells, escapeCell(cell))
+		}
+		lines = append(lines, strings.Join(cells, "\t"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// escapeCell keeps every row on a single line
+func escapeCell(cell string) string {
+	return strings.NewReplacer("\t", `\t`, "\n", `\n`, "\r", `\r`).Replace(cell)
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var outputTestResources = []map[string]interface{}{
+	{"id": "dev:host:test-host", "owner": "dev:user:admin", "permissions": []string{}},
+	{"id": "dev:layer:test-layer", "owner": "dev:user:admin", "restricted_to": []string{"10.0.0.0/8"}},
+}
+
+func TestFormatOutput(t *testing.T) {
+	testCases := []struct {
+		name        string
+		obj         interface{}
+		format      string
+		query       string
+		expected    string
+		expectedErr string
+	}{
+		{
+			name:     "json",
+			obj:      map[string]int{"count": 2},
+			format:   OutputFormatJSON,
+			expected: "{\n  \"count\": 2\n}",
+		},
+		{
+			name:     "json from raw bytes",
+			obj:      []byte(`{"username":"alice","account":"dev"}`),
+			format:   OutputFormatJSON,
+			expected: "{\n  \"account\": \"dev\",\n  \"username\": \"alice\"\n}",
+		},
+		{
+			name:     "yaml",
+			obj:      outputTestResources,
+			format:   OutputFormatYAML,
+			expected: "- id: dev:host:test-host\n  owner: dev:user:admin\n  permissions: []\n- id: dev:layer:test-layer\n  owner: dev:user:admin\n  restricted_to:\n    - 10.0.0.0/8",
+		},
+		{
+			name:   "table of objects",
+			obj:    outputTestResources,
+			format: OutputFormatTable,
+			expected: "ID                    OWNER           PERMISSIONS  RESTRICTED_TO\n" +
+				"dev:host:test-host    dev:user:admin  []           \n" +
+				"dev:layer:test-layer  dev:user:admin               [\"10.0.0.0/8\"]",
+		},
+		{
+			name:     "table of scalars",
+			obj:      []string{"dev:user:alice", "dev:user:bob"},
+			format:   OutputFormatTable,
+			expected: "VALUE\ndev:user:alice\ndev:user:bob",
+		},
+		{
+			name:     "table of single object",
+			obj:      map[string]interface{}{"username": "alice", "account": "dev"},
+			format:   OutputFormatTable,
+			expected: "ACCOUNT  USERNAME\ndev      alice",
+		},
+		{
+			name:     "tsv",
+			obj:      outputTestResources,
+			format:   OutputFormatTSV,
+			expected: "dev:host:test-host\tdev:user:admin\t[]\t\ndev:layer:test-layer\tdev:user:admin\t\t[\"10.0.0.0/8\"]",
+		},
+		{
+			name:     "tsv escapes newlines and tabs",
+			obj:      map[string]string{"value": "line1\nline2\tend"},
+			format:   OutputFormatTSV,
+			expected: `line1\nline2\tend`,
+		},
+		{
+			name:     "query iterating over array",
+			obj:      outputTestResources,
+			format:   OutputFormatTSV,
+			query:    ".[].id",
+			expected: "dev:host:test-host\ndev:layer:test-layer",
+		},
+		{
+			name:     "query with JSONPath syntax",
+			obj:      map[string]interface{}{"members": []map[string]string{{"member": "dev:user:alice"}, {"member": "dev:user:bob"}}},
+			format:   OutputFormatJSON,
+			query:    "$.members[*].member",
+			expected: "[\n  \"dev:user:alice\",\n  \"dev:user:bob\"\n]",
+		},
+		{
+			name:     "query with index and quoted key",
+			obj:      outputTestResources,
+			format:   OutputFormatJSON,
+			query:    `.[-1]["restricted_to"][0]`,
+			expected: `"10.0.0.0/8"`,
+		},
+		{
+			name:     "query on missing field",
+			obj:      outputTestResources,
+			format:   OutputFormatJSON,
+			query:    ".[0].missing",
+			expected: "null",
+		},
+		{
+			name:        "query on wrong type",
+			obj:         outputTestResources,
+			format:      OutputFormatJSON,
+			query:       ".id",
+			expectedErr: "query \".id\": cannot get field \"id\" of an array",
+		},
+		{
+			name:        "invalid query",
+			obj:         outputTestResources,
+			format:      OutputFormatJSON,
+			query:       ".[0",
+			expectedErr: "invalid query \".[0\"",
+		},
+		{
+			name:        "unsupported format",
+			obj:         outputTestResources,
+			format:      "xml",
+			expectedErr: "output format must be one of json, yaml, table, tsv",
+		},
+	}
+
+	t.Parallel()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := FormatOutput(tc.obj, tc.format, tc.query)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
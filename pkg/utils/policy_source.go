@@ -0,0 +1,393 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PolicySource is a policy file and the branch it is loaded into
+type PolicySource struct {
+	Branch string
+	File   string
+	// DependsOn lists branches whose policies must be loaded first
+	DependsOn []string
+}
+
+// policyManifest is the format of a manifest listing the policy files to load
+type policyManifest struct {
+	Policies []struct {
+		Branch    string   `yaml:"branch"`
+		File      string   `yaml:"file"`
+		DependsOn []string `yaml:"depends_on"`
+	} `yaml:"policies"`
+}
+
+// JoinPolicyBranch returns the branch at path relative to base, where "root" is the root policy
+func JoinPolicyBranch(base string, path string) string {
+	path = strings.Trim(filepath.ToSlash(path), "/")
+	if path == "" || path == "." {
+		return base
+	}
+	if base == "" || base == "root" {
+		return path
+	}
+	return strings.TrimSuffix(base, "/") + "/" + path
+}
+
+func isPolicyFile(name string) bool {
+	ext := filepath.Ext(name)
+	return (ext == ".yml" || ext == ".yaml") && !strings.HasPrefix(name, "_") && !strings.HasPrefix(name, ".")
+}
+
+// DiscoverPolicySources returns the policy files to load from path, sorted so that every file is
+// loaded after the files it depends on.
+//
+// When path is a directory, every YAML file of the tree is loaded into the branch matching its
+// directory, relative to branch, and files of a parent branch are loaded first. Files whose name
+// starts with "_" are skipped, so that they can be used with !include.
+//
+// When path is a manifest, a YAML mapping with a "policies" list, each entry gives a file,
+// relative to the manifest, the branch to load it into, relative to branch, and optional
+// branches it depends on. Otherwise path is a single policy file loaded into branch.
+func DiscoverPolicySources(path string, branch string) ([]PolicySource, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return discoverPolicyDir(path, branch)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if isPolicyManifest(data) {
+		return readPolicyManifest(path, data, branch)
+	}
+	return []PolicySource{{Branch: branch, File: path}}, nil
+}
+
+func discoverPolicyDir(dir string, branch string) ([]PolicySource, error) {
+	sources := []PolicySource{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isPolicyFile(entry.Name()) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		sources = append(sources, PolicySource{Branch: JoinPolicyBranch(branch, rel), File: path})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no policy files found in %s", dir)
+	}
+	return SortPolicySources(sources)
+}
+
+// isPolicyManifest tells manifests apart from policies, which are always lists
+func isPolicyManifest(data []byte) bool {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return false
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "policies" {
+			return true
+		}
+	}
+	return false
+}
+
+func readPolicyManifest(path string, data []byte, branch string) ([]PolicySource, error) {
+	var manifest policyManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	sources := make([]PolicySource, 0, len(manifest.Policies))
+	for i, entry := range manifest.Policies {
+		if entry.File == "" {
+			return nil, fmt.Errorf("%s: policy %d is missing required field \"file\"", path, i+1)
+		}
+
+		file := entry.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+
+		dependsOn := make([]string, 0, len(entry.DependsOn))
+		for _, dependency := range entry.DependsOn {
+			dependsOn = append(dependsOn, JoinPolicyBranch(branch, dependency))
+		}
+
+		sources = append(sources, PolicySource{
+			Branch:    JoinPolicyBranch(branch, entry.Branch),
+			File:      file,
+			DependsOn: dependsOn,
+		})
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("%s: no policies listed", path)
+	}
+	return SortPolicySources(sources)
+}
+
+// isAncestorBranch reports whether child is nested in parent
+func isAncestorBranch(parent string, child string) bool {
+	if parent == child {
+		return false
+	}
+	return parent == "root" || strings.HasPrefix(child, parent+"/")
+}
+
+// SortPolicySources orders sources so that every source comes after the sources of the branches
+// it depends on, explicitly or because they are its ancestors. The order of independent sources
+// is preserved.
+func SortPolicySources(sources []PolicySource) ([]PolicySource, error) {
+	branches := map[string]bool{}
+	for _, source := range sources {
+		branches[source.Branch] = true
+	}
+
+	dependencies := make([][]int, len(sources))
+	for i, source := range sources {
+		for _, dependency := range source.DependsOn {
+			if !branches[dependency] {
+				return nil, fmt.Errorf("policy %s depends on branch '%s', which has no policy to load", source.File, dependency)
+			}
+		}
+		for j, other := range sources {
+			if i == j {
+				continue
+			}
+			dependsOnOther := isAncestorBranch(other.Branch, source.Branch)
+			for _, dependency := range source.DependsOn {
+				dependsOnOther = dependsOnOther || dependency == other.Branch
+			}
+			if dependsOnOther {
+				dependencies[i] = append(dependencies[i], j)
+			}
+		}
+	}
+
+	sorted := make([]PolicySource, 0, len(sources))
+	done := make([]bool, len(sources))
+	for len(sorted) < len(sources) {
+		progress := false
+		for i, source := range sources {
+			if done[i] {
+				continue
+			}
+			ready := true
+			for _, j := range dependencies[i] {
+				ready = ready && done[j]
+			}
+			if ready {
+				sorted = append(sorted, source)
+				done[i] = true
+				progress = true
+			}
+		}
+
+		if !progress {
+			cycle := []string{}
+			for i, source := range sources {
+				if !done[i] {
+					cycle = append(cycle, source.File)
+				}
+			}
+			return nil, fmt.Errorf("circular dependency between policies %s", strings.Join(cycle, ", "))
+		}
+	}
+	return sorted, nil
+}
+
+// ParseTemplateVars builds template variables from YAML or JSON files, then key=value pairs.
+// Later values override earlier ones. It returns nil when no variables are given.
+func ParseTemplateVars(varFiles []string, vars []string) (map[string]interface{}, error) {
+	if len(varFiles) == 0 && len(vars) == 0 {
+		return nil, nil
+	}
+
+	result := map[string]interface{}{}
+	for _, varFile := range varFiles {
+		data, err := os.ReadFile(varFile)
+		if err != nil {
+			return nil, err
+		}
+		fileVars := map[string]interface{}{}
+		if err := yaml.Unmarshal(data, &fileVars); err != nil {
+			return nil, fmt.Errorf("%s: %s", varFile, err)
+		}
+		for key, value := range fileVars {
+			result[key] = value
+		}
+	}
+
+	for _, pair := range vars {
+		key, value, found := strings.Cut(pair, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid variable %q, must be key=value", pair)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// RenderPolicy prepares a policy to be loaded. When vars is not nil, the policy is first rendered
+// as a Go template, then the "!include path" statements are replaced with the statements of the
+// included files, relative to dir. Included files are rendered the same way.
+func RenderPolicy(data []byte, name string, dir string, vars map[string]interface{}) ([]byte, error) {
+	includeStack := []string{}
+	if _, err := os.Stat(name); err == nil {
+		if absPath, err := filepath.Abs(name); err == nil {
+			includeStack = append(includeStack, absPath)
+		}
+	}
+	return renderPolicy(data, name, dir, vars, includeStack)
+}
+
+func renderPolicy(data []byte, name string, dir string, vars map[string]interface{}, includeStack []string) ([]byte, error) {
+	if vars != nil {
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(string(data))
+		if err != nil {
+			return nil, err
+		}
+		out := &bytes.Buffer{}
+		if err := tmpl.Execute(out, vars); err != nil {
+			return nil, err
+		}
+		data = out.Bytes()
+	}
+
+	if !bytes.Contains(data, []byte("!include")) {
+		return data, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		// Leave the reporting of syntax errors to the server
+		return data, nil
+	}
+
+	changed, err := resolveIncludes(&doc, name, dir, vars, includeStack)
+	if err != nil || !changed {
+		return data, err
+	}
+
+	out := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// resolveIncludes replaces the !include items of every list in node with the statements of the
+// included files, and reports whether any was found
+func resolveIncludes(node *yaml.Node, name string, dir string, vars map[string]interface{}, includeStack []string) (bool, error) {
+	changed := false
+
+	if node.Kind == yaml.SequenceNode {
+		content := make([]*yaml.Node, 0, len(node.Content))
+		for _, item := range node.Content {
+			if item.Tag != "!include" {
+				content = append(content, item)
+				continue
+			}
+
+			statements, err := includePolicy(item, name, dir, vars, includeStack)
+			if err != nil {
+				return false, err
+			}
+			content = append(content, statements...)
+			changed = true
+		}
+		node.Content = content
+	}
+
+	for _, child := range node.Content {
+		childChanged, err := resolveIncludes(child, name, dir, vars, includeStack)
+		if err != nil {
+			return false, err
+		}
+		changed = changed || childChanged
+	}
+	return changed, nil
+}
+
+func includePolicy(node *yaml.Node, name string, dir string, vars map[string]interface{}, includeStack []string) ([]*yaml.Node, error) {
+	if node.Kind != yaml.ScalarNode || node.Value == "" {
+		return nil, fmt.Errorf("%s:%d: !include must be followed by a file path", name, node.Line)
+	}
+
+	path := node.Value
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, included := range includeStack {
+		if included == absPath {
+			return nil, fmt.Errorf("%s:%d: circular !include of %s", name, node.Line, node.Value)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%d: %s", name, node.Line, err)
+	}
+
+	data, err = renderPolicy(data, path, filepath.Dir(path), vars, append(includeStack, absPath))
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return []*yaml.Node{}, nil
+	}
+	if doc.Content[0].Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("%s: included policy must be a list of statements", path)
+	}
+	return doc.Content[0].Content, nil
+}
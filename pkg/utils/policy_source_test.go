@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func sourceBranches(sources []PolicySource) []string {
+	branches := []string{}
+	for _, source := range sources {
+		branches = append(branches, source.Branch)
+	}
+	return branches
+}
+
+func TestJoinPolicyBranch(t *testing.T) {
+	assert.Equal(t, "apps", JoinPolicyBranch("root", "apps"))
+	assert.Equal(t, "root", JoinPolicyBranch("root", "."))
+	assert.Equal(t, "staging/apps/web", JoinPolicyBranch("staging", "apps/web"))
+	assert.Equal(t, "staging", JoinPolicyBranch("staging", ""))
+}
+
+func TestDiscoverPolicySources(t *testing.T) {
+	t.Run("single file", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTestFiles(t, dir, map[string]string{"policy.yml": "- !variable password\n"})
+
+		sources, err := DiscoverPolicySources(filepath.Join(dir, "policy.yml"), "staging")
+		assert.NoError(t, err)
+		assert.Equal(t, []PolicySource{{Branch: "staging", File: filepath.Join(dir, "policy.yml")}}, sources)
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTestFiles(t, dir, map[string]string{
+			"apps/web/web.yml":   "- !host web\n",
+			"apps/apps.yml":      "- !policy web\n",
+			"apps/_partial.yml":  "- !group partial\n",
+			"apps/README.md":     "not a policy",
+			"root.yml":           "- !policy apps\n- !policy db\n",
+			"db/db.yaml":         "- !variable password\n",
+			".git/config.yml":    "- !ignored\n",
+			"apps/web/extra.yml": "- !host extra\n",
+		})
+
+		sources, err := DiscoverPolicySources(dir, "root")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"root", "apps", "apps/web", "apps/web", "db"}, sourceBranches(sources))
+		assert.Equal(t, filepath.Join(dir, "root.yml"), sources[0].File)
+		assert.Equal(t, filepath.Join(dir, "apps", "web", "extra.yml"), sources[2].File)
+	})
+
+	t.Run("empty directory", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := DiscoverPolicySources(dir, "root")
+		assert.ErrorContains(t, err, "no policy files found")
+	})
+
+	t.Run("manifest", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTestFiles(t, dir, map[string]string{
+			"manifest.yml": `policies:
+  - branch: apps/web
+    file: web.yml
+  - branch: apps
+    file: apps.yml
+    depends_on: [ db ]
+  - branch: db
+    file: /abs/db.yml
+`,
+		})
+
+		sources, err := DiscoverPolicySources(filepath.Join(dir, "manifest.yml"), "staging")
+		assert.NoError(t, err)
+		assert.Equal(t, []PolicySource{
+			{Branch: "staging/db", File: "/abs/db.yml", DependsOn: []string{}},
+			{Branch: "staging/apps", File: filepath.Join(dir, "apps.yml"), DependsOn: []string{"staging/db"}},
+			{Branch: "staging/apps/web", File: filepath.Join(dir, "web.yml"), DependsOn: []string{}},
+		}, sources)
+	})
+
+	t.Run("manifest with unknown dependency", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTestFiles(t, dir, map[string]string{
+			"manifest.yml": "policies:\n  - branch: apps\n    file: apps.yml\n    depends_on: [ db ]\n",
+		})
+
+		_, err := DiscoverPolicySources(filepath.Join(dir, "manifest.yml"), "root")
+		assert.ErrorContains(t, err, "depends on branch 'db', which has no policy to load")
+	})
+
+	t.Run("missing path", func(t *testing.T) {
+		_, err := DiscoverPolicySources(filepath.Join(t.TempDir(), "missing"), "root")
+		assert.ErrorContains(t, err, "no such file or directory")
+	})
+}
+
+func TestSortPolicySources(t *testing.T) {
+	sorted, err := SortPolicySources([]PolicySource{
+		{Branch: "b", File: "b.yml", DependsOn: []string{"a"}},
+		{Branch: "c", File: "c.yml"},
+		{Branch: "a", File: "a.yml"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c", "a", "b"}, sourceBranches(sorted))
+
+	_, err = SortPolicySources([]PolicySource{
+		{Branch: "a", File: "a.yml", DependsOn: []string{"b"}},
+		{Branch: "b", File: "b.yml", DependsOn: []string{"a"}},
+	})
+	assert.EqualError(t, err, "circular dependency between policies a.yml, b.yml")
+}
+
+func TestParseTemplateVars(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"vars.yml":  "env: staging\nreplicas: 3\n",
+		"vars.json": `{"env": "production", "region": "us-east-1"}`,
+	})
+
+	vars, err := ParseTemplateVars(nil, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, vars)
+
+	vars, err = ParseTemplateVars(
+		[]string{filepath.Join(dir, "vars.yml"), filepath.Join(dir, "vars.json")},
+		[]string{"region=eu-west-1", "url=https://example.com/?a=b"},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"env":      "production",
+		"replicas": 3,
+		"region":   "eu-west-1",
+		"url":      "https://example.com/?a=b",
+	}, vars)
+
+	_, err = ParseTemplateVars(nil, []string{"env"})
+	assert.EqualError(t, err, "invalid variable \"env\", must be key=value")
+}
+
+func TestRenderPolicy(t *testing.T) {
+	t.Run("unchanged without variables or includes", func(t *testing.T) {
+		policy := "# {{ .env }} is not rendered\n- !variable   password\n"
+		rendered, err := RenderPolicy([]byte(policy), "policy.yml", ".", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, policy, string(rendered))
+	})
+
+	t.Run("template variables", func(t *testing.T) {
+		rendered, err := RenderPolicy([]byte("- !variable {{ .env }}/password\n"), "policy.yml", ".", map[string]interface{}{"env": "staging"})
+		assert.NoError(t, err)
+		assert.Equal(t, "- !variable staging/password\n", string(rendered))
+	})
+
+	t.Run("missing template variable", func(t *testing.T) {
+		_, err := RenderPolicy([]byte("- !variable {{ .env }}/password\n"), "policy.yml", ".", map[string]interface{}{})
+		assert.ErrorContains(t, err, "map has no entry for key \"env\"")
+	})
+
+	t.Run("includes", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTestFiles(t, dir, map[string]string{
+			"common/_variables.yml": "- !variable {{ .env }}/password\n- !include _more.yml\n",
+			"common/_more.yml":      "- !variable url\n",
+		})
+
+		policy := `- !policy
+  id: db
+  body:
+    - !include common/_variables.yml
+    - !layer clients
+`
+		rendered, err := RenderPolicy([]byte(policy), "policy.yml", dir, map[string]interface{}{"env": "staging"})
+		assert.NoError(t, err)
+		assert.Equal(t, `- !policy
+  id: db
+  body:
+    - !variable staging/password
+    - !variable url
+    - !layer clients
+`, string(rendered))
+	})
+
+	t.Run("circular includes", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTestFiles(t, dir, map[string]string{
+			"a.yml": "- !include b.yml\n",
+			"b.yml": "- !include a.yml\n",
+		})
+
+		data, err := os.ReadFile(filepath.Join(dir, "a.yml"))
+		assert.NoError(t, err)
+		_, err = RenderPolicy(data, filepath.Join(dir, "a.yml"), dir, nil)
+		assert.ErrorContains(t, err, "circular !include of a.yml")
+	})
+
+	t.Run("missing include", func(t *testing.T) {
+		_, err := RenderPolicy([]byte("- !include missing.yml\n"), "policy.yml", t.TempDir(), nil)
+		assert.ErrorContains(t, err, "policy.yml:1: open")
+	})
+}
@@ -0,0 +1,464 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyIssue is a problem found in a policy file by ValidatePolicy
+type PolicyIssue struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (i PolicyIssue) String() string {
+	return fmt.Sprintf("%d:%d: %s", i.Line, i.Column, i.Message)
+}
+
+// policyRecordFields lists the fields allowed for each record that defines a resource, in
+// addition to id, owner and annotations
+var policyRecordFields = map[string][]string{
+	"policy":       {"body"},
+	"variable":     {"kind", "mime_type"},
+	"user":         {"uidnumber", "public_keys", "restricted_to"},
+	"host":         {"restricted_to"},
+	"group":        {"gidnumber"},
+	"layer":        {},
+	"webservice":   {},
+	"host-factory": {"layers"},
+}
+
+// policyRoleKinds lists the kinds of resources which are also roles
+var policyRoleKinds = map[string]bool{
+	"policy": true,
+	"user":   true,
+	"host":   true,
+	"group":  true,
+	"layer":  true,
+}
+
+// PolicyPrivileges lists the privileges accepted in !permit and !deny statements
+var PolicyPrivileges = []string{"read", "execute", "update", "create", "authenticate"}
+
+type policyReference struct {
+	node *yaml.Node
+	kind string
+	id   string
+}
+
+type policyValidator struct {
+	branch      string
+	issues      []PolicyIssue
+	definitions map[string]int
+	references  []policyReference
+}
+
+// ValidatePolicy checks a Conjur policy file without sending it to the server. It reports
+// unknown tags, missing or unknown fields, duplicate IDs and invalid privileges.
+//
+// With checkReferences, it also reports references to resources that should be defined in the
+// file but are not. This only suits files defining everything they use, as a policy updating a
+// branch usually refers to resources loaded before. Relative references are always checked.
+// Absolute references are only checked when branch, the policy the file is loaded into, is given
+// and contains them.
+//
+// An error is returned when the file is not valid YAML.
+func ValidatePolicy(data []byte, branch string, checkReferences bool) ([]PolicyIssue, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	v := &policyValidator{
+		branch:      strings.Trim(branch, "/"),
+		issues:      []PolicyIssue{},
+		definitions: map[string]int{},
+	}
+	if len(doc.Content) == 0 {
+		return v.issues, nil
+	}
+
+	v.validateStatements(doc.Content[0], "")
+	if checkReferences {
+		v.checkDanglingReferences()
+	}
+
+	sort.SliceStable(v.issues, func(i, j int) bool {
+		if v.issues[i].Line != v.issues[j].Line {
+			return v.issues[i].Line < v.issues[j].Line
+		}
+		return v.issues[i].Column < v.issues[j].Column
+	})
+	return v.issues, nil
+}
+
+func (v *policyValidator) addIssue(node *yaml.Node, format string, args ...interface{}) {
+	v.issues = append(v.issues, PolicyIssue{
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// policyTag returns the name of a local tag, like "variable" for "!variable"
+func policyTag(node *yaml.Node) (string, bool) {
+	if !strings.HasPrefix(node.Tag, "!") || strings.HasPrefix(node.Tag, "!!") {
+		return "", false
+	}
+	return strings.TrimPrefix(node.Tag, "!"), true
+}
+
+func joinPolicyPath(parts ...string) string {
+	nonEmpty := []string{}
+	for _, part := range parts {
+		if part = strings.Trim(part, "/"); part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, "/")
+}
+
+// mappingFields returns the values of a mapping by key, reporting duplicate and unknown keys
+func (v *policyValidator) mappingFields(node *yaml.Node, tag string, allowed []string) map[string]*yaml.Node {
+	allowedSet := map[string]bool{}
+	for _, field := range allowed {
+		allowedSet[field] = true
+	}
+
+	fields := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if !allowedSet[key.Value] {
+			v.addIssue(key, "unknown field %q for !%s", key.Value, tag)
+			continue
+		}
+		if _, exists := fields[key.Value]; exists {
+			v.addIssue(key, "duplicate field %q for !%s", key.Value, tag)
+			continue
+		}
+		fields[key.Value] = value
+	}
+	return fields
+}
+
+func (v *policyValidator) validateStatements(node *yaml.Node, path string) {
+	if node.Kind != yaml.SequenceNode {
+		v.addIssue(node, "policy must be a list of statements")
+		return
+	}
+
+	for _, statement := range node.Content {
+		tag, ok := policyTag(statement)
+		if !ok {
+			v.addIssue(statement, "statement must be tagged, like !variable or !permit")
+			continue
+		}
+
+		switch tag {
+		case "grant":
+			v.validateGrant(statement, path)
+		case "permit", "deny":
+			v.validatePermit(statement, tag, path)
+		case "revoke":
+			v.validateRevoke(statement, path)
+		case "delete":
+			v.validateDelete(statement)
+		default:
+			if _, isRecord := policyRecordFields[tag]; isRecord {
+				v.validateRecord(statement, tag, path)
+			} else {
+				v.addIssue(statement, "unknown tag !%s", tag)
+			}
+		}
+	}
+}
+
+func (v *policyValidator) validateRecord(node *yaml.Node, tag string, path string) {
+	var id *yaml.Node
+	fields := map[string]*yaml.Node{}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		// Short form, like "- !variable password"
+		if node.Value != "" {
+			id = node
+		}
+	case yaml.MappingNode:
+		fields = v.mappingFields(node, tag, append([]string{"id", "owner", "annotations"}, policyRecordFields[tag]...))
+		id = fields["id"]
+	default:
+		v.addIssue(node, "!%s must be an ID or a mapping", tag)
+		return
+	}
+
+	if id == nil || id.Kind != yaml.ScalarNode || id.Value == "" {
+		v.addIssue(node, "!%s is missing required field \"id\"", tag)
+		return
+	}
+
+	fullID := joinPolicyPath(path, id.Value)
+	key := tag + ":" + fullID
+	if line, exists := v.definitions[key]; exists {
+		v.addIssue(id, "duplicate ID !%s %s, first defined on line %d", tag, fullID, line)
+	} else {
+		v.definitions[key] = id.Line
+	}
+
+	if owner, ok := fields["owner"]; ok {
+		v.validateReference(owner, "owner", path, policyRoleKinds)
+	}
+	if annotations, ok := fields["annotations"]; ok && annotations.Kind != yaml.MappingNode {
+		v.addIssue(annotations, "annotations of !%s must be a mapping", tag)
+	}
+
+	switch tag {
+	case "policy":
+		if body, ok := fields["body"]; ok {
+			v.validateStatements(body, fullID)
+		}
+	case "host-factory":
+		layers, ok := fields["layers"]
+		if !ok {
+			v.addIssue(node, "!host-factory is missing required field \"layers\"")
+			break
+		}
+		v.validateReferences(layers, "layers", path, map[string]bool{"layer": true})
+	}
+}
+
+func (v *policyValidator) validateGrant(node *yaml.Node, path string) {
+	if node.Kind != yaml.MappingNode {
+		v.addIssue(node, "!grant must be a mapping")
+		return
+	}
+
+	fields := v.mappingFields(node, "grant", []string{"role", "member", "members"})
+
+	if role, ok := fields["role"]; ok {
+		v.validateReference(role, "role", path, policyRoleKinds)
+	} else {
+		v.addIssue(node, "!grant is missing required field \"role\"")
+	}
+
+	member, hasMember := fields["member"]
+	members, hasMembers := fields["members"]
+	switch {
+	case hasMember && hasMembers:
+		v.addIssue(node, "!grant must have either \"member\" or \"members\", not both")
+	case hasMember:
+		v.validateMembers(member, path)
+	case hasMembers:
+		v.validateMembers(members, path)
+	default:
+		v.addIssue(node, "!grant is missing required field \"member\"")
+	}
+}
+
+// validateMembers checks the members of a !grant, which are role references or !member
+// mappings with a role and an admin option
+func (v *policyValidator) validateMembers(node *yaml.Node, path string) {
+	members := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		members = node.Content
+	}
+
+	for _, member := range members {
+		if member.Tag != "!member" {
+			v.validateReference(member, "member", path, policyRoleKinds)
+			continue
+		}
+
+		if member.Kind != yaml.MappingNode {
+			v.addIssue(member, "!member must be a mapping")
+			continue
+		}
+		fields := v.mappingFields(member, "member", []string{"role", "admin"})
+		if role, ok := fields["role"]; ok {
+			v.validateReference(role, "role", path, policyRoleKinds)
+		} else {
+			v.addIssue(member, "!member is missing required field \"role\"")
+		}
+		if admin, ok := fields["admin"]; ok && admin.ShortTag() != "!!bool" {
+			v.addIssue(admin, "admin of !member must be true or false")
+		}
+	}
+}
+
+// validateRevoke checks a !revoke, which removes a role from another role. Both roles usually
+// exist already, so they are not expected to be defined in the file.
+func (v *policyValidator) validateRevoke(node *yaml.Node, path string) {
+	if node.Kind != yaml.MappingNode {
+		v.addIssue(node, "!revoke must be a mapping")
+		return
+	}
+
+	fields := v.mappingFields(node, "revoke", []string{"role", "member"})
+	for _, field := range []string{"role", "member"} {
+		if ref, ok := fields[field]; ok {
+			v.checkReference(ref, field, policyRoleKinds)
+		} else {
+			v.addIssue(node, "!revoke is missing required field %q", field)
+		}
+	}
+}
+
+// validateDelete checks a !delete, which removes an existing record, so the record is not
+// expected to be defined in the file
+func (v *policyValidator) validateDelete(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		v.addIssue(node, "!delete must be a mapping")
+		return
+	}
+
+	fields := v.mappingFields(node, "delete", []string{"record"})
+	if record, ok := fields["record"]; ok {
+		v.checkReference(record, "record", nil)
+	} else {
+		v.addIssue(node, "!delete is missing required field \"record\"")
+	}
+}
+
+func (v *policyValidator) validatePermit(node *yaml.Node, tag string, path string) {
+	if node.Kind != yaml.MappingNode {
+		v.addIssue(node, "!%s must be a mapping", tag)
+		return
+	}
+
+	fields := v.mappingFields(node, tag, []string{"role", "privilege", "privileges", "resource", "resources"})
+
+	if role, ok := fields["role"]; ok {
+		v.validateReferences(role, "role", path, policyRoleKinds)
+	} else {
+		v.addIssue(node, "!%s is missing required field \"role\"", tag)
+	}
+
+	privileges, ok := fields["privileges"]
+	if !ok {
+		privileges, ok = fields["privilege"]
+	}
+	if ok {
+		v.validatePrivileges(privileges)
+	} else {
+		v.addIssue(node, "!%s is missing required field \"privileges\"", tag)
+	}
+
+	resource, ok := fields["resource"]
+	if !ok {
+		resource, ok = fields["resources"]
+	}
+	if ok {
+		v.validateReferences(resource, "resource", path, nil)
+	} else {
+		v.addIssue(node, "!%s is missing required field \"resource\"", tag)
+	}
+}
+
+func (v *policyValidator) validatePrivileges(node *yaml.Node) {
+	privileges := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		privileges = node.Content
+	}
+	if len(privileges) == 0 {
+		v.addIssue(node, "privileges must not be empty")
+	}
+
+	for _, privilege := range privileges {
+		valid := false
+		for _, known := range PolicyPrivileges {
+			if privilege.Kind == yaml.ScalarNode && privilege.Value == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			v.addIssue(privilege, "invalid privilege %q, must be one of %s", privilege.Value, strings.Join(PolicyPrivileges, ", "))
+		}
+	}
+}
+
+// validateReferences checks a reference or a list of references
+func (v *policyValidator) validateReferences(node *yaml.Node, field string, path string, kinds map[string]bool) {
+	if node.Kind != yaml.SequenceNode {
+		v.validateReference(node, field, path, kinds)
+		return
+	}
+	for _, item := range node.Content {
+		v.validateReference(item, field, path, kinds)
+	}
+}
+
+// validateReference checks a single reference, like "!group admins", and records it to check
+// that it is defined in the file. When kinds is not nil, only references to these kinds are
+// allowed.
+func (v *policyValidator) validateReference(node *yaml.Node, field string, path string, kinds map[string]bool) {
+	kind, ok := v.checkReference(node, field, kinds)
+	if !ok {
+		return
+	}
+
+	v.references = append(v.references, policyReference{node: node, kind: kind, id: v.resolveReference(node.Value, path)})
+}
+
+// checkReference checks a single reference and returns its kind. When kinds is not nil, only
+// references to these kinds are allowed.
+func (v *policyValidator) checkReference(node *yaml.Node, field string, kinds map[string]bool) (string, bool) {
+	kind, ok := policyTag(node)
+	if !ok || node.Kind != yaml.ScalarNode {
+		v.addIssue(node, "%s must be a tagged reference, like !group admins", field)
+		return "", false
+	}
+	if _, isRecord := policyRecordFields[kind]; !isRecord {
+		v.addIssue(node, "unknown tag !%s", kind)
+		return "", false
+	}
+	if kinds != nil && !kinds[kind] {
+		allowed := make([]string, 0, len(kinds))
+		for k := range kinds {
+			allowed = append(allowed, "!"+k)
+		}
+		sort.Strings(allowed)
+		v.addIssue(node, "%s cannot be a !%s, must be one of %s", field, kind, strings.Join(allowed, ", "))
+		return "", false
+	}
+	if node.Value == "" {
+		v.addIssue(node, "reference to !%s is missing an ID", kind)
+		return "", false
+	}
+	return kind, true
+}
+
+// resolveReference returns the ID of a reference relative to the loaded branch, or an empty
+// string when the reference points outside of it
+func (v *policyValidator) resolveReference(id string, path string) string {
+	if !strings.HasPrefix(id, "/") {
+		return joinPolicyPath(path, id)
+	}
+
+	id = strings.Trim(id, "/")
+	switch {
+	case v.branch == "":
+		return ""
+	case v.branch == "root":
+		return id
+	case strings.HasPrefix(id, v.branch+"/"):
+		return strings.TrimPrefix(id, v.branch+"/")
+	}
+	return ""
+}
+
+// checkDanglingReferences reports references to resources of the loaded branch which the
+// policy does not define
+func (v *policyValidator) checkDanglingReferences() {
+	for _, ref := range v.references {
+		if ref.id == "" {
+			continue
+		}
+		if _, defined := v.definitions[ref.kind+":"+ref.id]; !defined {
+			v.addIssue(ref.node, "!%s %s is not defined in this policy", ref.kind, ref.id)
+		}
+	}
+}
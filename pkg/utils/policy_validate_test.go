@@ -0,0 +1,199 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePolicy(t *testing.T) {
+	testCases := []struct {
+		name            string
+		policy          string
+		branch          string
+		checkReferences bool
+		expected        []string
+		expectedErr     string
+	}{
+		{
+			name:     "empty",
+			policy:   "",
+			expected: []string{},
+		},
+		{
+			name: "valid policy",
+			policy: `- !policy
+  id: db
+  owner: !group admins
+  body:
+    - !variable password
+    - !variable
+      id: url
+      annotations:
+        description: Database URL
+    - !layer clients
+    - !host-factory
+      id: clients-factory
+      layers: [ !layer clients ]
+    - !permit
+      role: !layer clients
+      privileges: [ read, execute ]
+      resources:
+        - !variable password
+        - !variable url
+- !group admins
+- !user alice
+- !grant
+  role: !group admins
+  members:
+    - !user alice
+    - !member
+      role: !layer db/clients
+      admin: true
+- !deny
+  role: !user alice
+  privilege: update
+  resource: !variable db/password
+`,
+			expected: []string{},
+		},
+		{
+			name:   "unknown tags and untagged statements",
+			policy: "- !secret password\n- id: password\n- !permit\n  role: !robot r2d2\n  privileges: [ read ]\n  resource: !variable /prod/password\n",
+			expected: []string{
+				"1:3: unknown tag !secret",
+				"2:3: statement must be tagged, like !variable or !permit",
+				"4:9: unknown tag !robot",
+			},
+		},
+		{
+			name: "missing and unknown fields",
+			policy: `- !variable
+  kind: password
+  size: 32
+- !grant
+  role: !group admins
+- !permit
+  role: !group admins
+- !host-factory apps
+- !group admins
+`,
+			expected: []string{
+				"1:3: !variable is missing required field \"id\"",
+				"3:3: unknown field \"size\" for !variable",
+				"4:3: !grant is missing required field \"member\"",
+				"6:3: !permit is missing required field \"privileges\"",
+				"6:3: !permit is missing required field \"resource\"",
+				"8:3: !host-factory is missing required field \"layers\"",
+			},
+		},
+		{
+			name:   "duplicate IDs",
+			policy: "- !variable password\n- !policy\n  id: db\n  body:\n    - !variable password\n- !variable password\n- !group password\n",
+			expected: []string{
+				"6:3: duplicate ID !variable password, first defined on line 1",
+			},
+		},
+		{
+			name:            "dangling references",
+			checkReferences: true,
+			policy: `- !policy
+  id: db
+  body:
+    - !variable password
+    - !permit
+      role: !layer clients
+      privileges: [ read ]
+      resource: !variable password
+- !grant
+  role: !group admins
+  member: !user /alice
+- !permit
+  role: !host /apps/app
+  privileges: [ read ]
+  resource: !variable db/password
+`,
+			expected: []string{
+				"6:13: !layer db/clients is not defined in this policy",
+				"10:9: !group admins is not defined in this policy",
+			},
+		},
+		{
+			name:     "references not checked by default",
+			policy:   "- !permit\n  role: !layer clients\n  privileges: [ read ]\n  resource: !variable password\n",
+			expected: []string{},
+		},
+		{
+			name:            "absolute references within branch",
+			branch:          "apps",
+			checkReferences: true,
+			policy:          "- !host app\n- !permit\n  role: !host /apps/app\n  privileges: [ read ]\n  resource: !variable /apps/missing\n- !grant\n  role: !group /admins\n  member: !host app\n",
+			expected: []string{
+				"5:13: !variable missing is not defined in this policy",
+			},
+		},
+		{
+			name: "invalid privileges and reference kinds",
+			policy: `- !variable password
+- !host-factory
+  id: factory
+  layers: [ !group admins ]
+- !permit
+  role: !variable password
+  privileges: [ read, write ]
+  resource: !variable password
+- !grant
+  role: admins
+  member: !host app
+`,
+			expected: []string{
+				"4:13: layers cannot be a !group, must be one of !layer",
+				"6:9: role cannot be a !variable, must be one of !group, !host, !layer, !policy, !user",
+				"7:23: invalid privilege \"write\", must be one of read, execute, update, create, authenticate",
+				"10:9: role must be a tagged reference, like !group admins",
+			},
+		},
+		{
+			name: "delete and revoke",
+			policy: `- !delete
+  record: !variable old-password
+- !revoke
+  role: !group admins
+  member: !user alice
+- !delete
+  record: !secret old
+- !revoke
+  role: !variable password
+- !delete old
+`,
+			expected: []string{
+				"7:11: unknown tag !secret",
+				"8:3: !revoke is missing required field \"member\"",
+				"9:9: role cannot be a !variable, must be one of !group, !host, !layer, !policy, !user",
+				"10:3: !delete must be a mapping",
+			},
+		},
+		{
+			name:        "invalid YAML",
+			policy:      "- !variable\n  id: [",
+			expectedErr: "yaml: line 2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			issues, err := ValidatePolicy([]byte(tc.policy), tc.branch, tc.checkReferences)
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			messages := []string{}
+			for _, issue := range issues {
+				messages = append(messages, issue.String())
+			}
+			assert.Equal(t, tc.expected, messages)
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RewriteRule replaces path segments in the IDs of a promoted policy
+type RewriteRule struct {
+	From string
+	To   string
+}
+
+// ParseRewriteRules parses rules given as from=to
+func ParseRewriteRules(values []string) ([]RewriteRule, error) {
+	rules := make([]RewriteRule, 0, len(values))
+	for _, value := range values {
+		from, to, ok := strings.Cut(value, "=")
+		if !ok || from == "" {
+			return nil, fmt.Errorf("invalid rewrite rule %q, expected from=to", value)
+		}
+		rules = append(rules, RewriteRule{From: from, To: to})
+	}
+	return rules, nil
+}
+
+// RewriteID applies the rules, in order, to an ID. A rule only matches whole path segments, so
+// that staging=prod rewrites apps/staging/db but neither apps/staging-db nor apps/stagingdb.
+func RewriteID(id string, rules []RewriteRule) string {
+	for _, rule := range rules {
+		id = replaceSegments(id, rule.From, rule.To)
+	}
+	return id
+}
+
+// replaceSegments replaces the occurrences of from in id which start and end on a path segment
+// boundary
+func replaceSegments(id string, from string, to string) string {
+	var out strings.Builder
+	last := 0
+	for start := 0; start <= len(id)-len(from); {
+		i := strings.Index(id[start:], from)
+		if i < 0 {
+			break
+		}
+		i += start
+		end := i + len(from)
+
+		startsSegment := i == 0 || id[i-1] == '/' || from[0] == '/'
+		endsSegment := end == len(id) || id[end] == '/' || from[len(from)-1] == '/'
+		if !startsSegment || !endsSegment {
+			start = i + 1
+			continue
+		}
+
+		out.WriteString(id[last:i])
+		out.WriteString(to)
+		last = end
+		start = end
+	}
+	out.WriteString(id[last:])
+	return out.String()
+}
+
+// fullyQualifiedKinds lists the resource kinds which can follow the account in a fully-qualified ID
+const fullyQualifiedKinds = "user|host|group|layer|policy|variable|webservice|host_factory"
+
+var fullyQualifiedID = regexp.MustCompile(`^(/?)([^:/]+):(` + fullyQualifiedKinds + `):(.*)$`)
+
+// rewritePolicyID applies the rules to an ID of a policy. The account of a fully-qualified ID is
+// replaced with the target account when it is the source account, and the rules only apply to
+// the part of the ID following the kind.
+func rewritePolicyID(id string, rules []RewriteRule, sourceAccount string, targetAccount string) string {
+	match := fullyQualifiedID.FindStringSubmatch(id)
+	if match == nil {
+		return RewriteID(id, rules)
+	}
+
+	account := match[2]
+	if account == sourceAccount && targetAccount != "" {
+		account = targetAccount
+	}
+	return match[1] + account + ":" + match[3] + ":" + RewriteID(match[4], rules)
+}
+
+// isPolicyTag reports whether a tag is a Conjur policy tag, such as !variable, rather than a YAML
+// tag
+func isPolicyTag(tag string) bool {
+	return strings.HasPrefix(tag, "!") && !strings.HasPrefix(tag, "!!")
+}
+
+// policyIDNodes returns the scalars of a policy holding IDs: the id of the records, and the
+// tagged references to records
+func policyIDNodes(node *yaml.Node) []*yaml.Node {
+	nodes := []*yaml.Node{}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if isPolicyTag(node.Tag) {
+			nodes = append(nodes, node)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if isPolicyTag(node.Tag) && key.Value == "id" && value.Kind == yaml.ScalarNode && !isPolicyTag(value.Tag) {
+				nodes = append(nodes, value)
+			}
+			nodes = append(nodes, policyIDNodes(value)...)
+		}
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			nodes = append(nodes, policyIDNodes(child)...)
+		}
+	}
+	return nodes
+}
+
+type policyEdit struct {
+	start int
+	end   int
+	text  string
+}
+
+// scalarEdit locates the text of a scalar of a policy, after its tag, and returns the edit
+// replacing it with a new value written in the same style
+func scalarEdit(policy []byte, lineStarts []int, node *yaml.Node, value string) (policyEdit, error) {
+	edit := policyEdit{}
+	unsupported := fmt.Errorf("line %d: cannot rewrite %q", node.Line, node.Value)
+	if node.Line < 1 || node.Line > len(lineStarts) {
+		return edit, unsupported
+	}
+
+	offset := lineStarts[node.Line-1]
+	for column := 1; column < node.Column && offset < len(policy); column++ {
+		_, size := utf8.DecodeRune(policy[offset:])
+		offset += size
+	}
+	if node.Style&yaml.TaggedStyle != 0 {
+		for offset < len(policy) && policy[offset] != ' ' && policy[offset] != '\t' {
+			offset++
+		}
+		for offset < len(policy) && (policy[offset] == ' ' || policy[offset] == '\t') {
+			offset++
+		}
+	}
+
+	var raw string
+	switch {
+	case node.Style&yaml.DoubleQuotedStyle != 0:
+		end := offset + 1
+		for end < len(policy) && policy[end] != '"' {
+			if policy[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(policy) {
+			return edit, unsupported
+		}
+		raw = string(policy[offset : end+1])
+		edit.text = strconv.Quote(value)
+	case node.Style&yaml.SingleQuotedStyle != 0:
+		raw = "'" + strings.ReplaceAll(node.Value, "'", "''") + "'"
+		edit.text = "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	case node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) == 0:
+		raw = node.Value
+		edit.text = value
+	default:
+		return edit, unsupported
+	}
+
+	if !bytes.HasPrefix(policy[offset:], []byte(raw)) {
+		return edit, unsupported
+	}
+	edit.start = offset
+	edit.end = offset + len(raw)
+	return edit, nil
+}
+
+// RewritePolicy applies the rules to the IDs of a policy, and replaces the source account with the
+// target account in its fully-qualified IDs. Only the id of the records and the tagged references
+// to records are rewritten, in place, so that annotations, comments and formatting are kept.
+func RewritePolicy(policy []byte, rules []RewriteRule, sourceAccount string, targetAccount string) ([]byte, error) {
+	document := yaml.Node{}
+	if err := yaml.Unmarshal(policy, &document); err != nil {
+		return nil, err
+	}
+
+	lineStarts := []int{0}
+	for i, b := range policy {
+		if b == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	edits := []policyEdit{}
+	for _, node := range policyIDNodes(&document) {
+		value := rewritePolicyID(node.Value, rules, sourceAccount, targetAccount)
+		if value == node.Value {
+			continue
+		}
+		edit, err := scalarEdit(policy, lineStarts, node, value)
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, edit)
+	}
+
+	// Apply the edits from the end, so that the offsets of the others stay valid
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	rewritten := append([]byte{}, policy...)
+	for _, edit := range edits {
+		rewritten = append(rewritten[:edit.start], append([]byte(edit.text), rewritten[edit.end:]...)...)
+	}
+	return rewritten, nil
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRewriteRules(t *testing.T) {
+	rules, err := ParseRewriteRules([]string{"staging=prod", "-stg="})
+	assert.NoError(t, err)
+	assert.Equal(t, []RewriteRule{{From: "staging", To: "prod"}, {From: "-stg", To: ""}}, rules)
+
+	_, err = ParseRewriteRules([]string{"staging"})
+	assert.EqualError(t, err, `invalid rewrite rule "staging", expected from=to`)
+	_, err = ParseRewriteRules([]string{"=prod"})
+	assert.Error(t, err)
+}
+
+func TestRewriteID(t *testing.T) {
+	rules := []RewriteRule{{From: "staging", To: "prod"}, {From: "db-stg", To: "db"}}
+	assert.Equal(t, "apps/myapp/prod/db/password", RewriteID("apps/myapp/staging/db-stg/password", rules))
+	assert.Equal(t, "apps/other", RewriteID("apps/other", rules))
+
+	// Rules only match whole path segments
+	rules = []RewriteRule{{From: "prod", To: "staging"}}
+	assert.Equal(t, "staging/db/production", RewriteID("prod/db/production", rules))
+	assert.Equal(t, "/apps/staging", RewriteID("/apps/prod", rules))
+	assert.Equal(t, "apps/prod-db/reprod", RewriteID("apps/prod-db/reprod", rules))
+	assert.Equal(t, "apps/staging/db", RewriteID("apps/prod/db", []RewriteRule{{From: "apps/prod", To: "apps/staging"}}))
+}
+
+func TestRewritePolicy(t *testing.T) {
+	policy := `- !policy
+  id: myapp
+  body:
+  - !variable staging/password
+  - !permit
+    role: !group /staging:group:ops
+    resource: !variable staging/password
+    privileges: [read]
+  - !grant
+    role: !layer staging-hosts
+    member: !host staging:host:apps/staging-app
+`
+	rewritten, err := RewritePolicy([]byte(policy), []RewriteRule{{From: "staging/", To: "prod/"}}, "staging", "prod")
+	assert.NoError(t, err)
+	assert.Equal(t, `- !policy
+  id: myapp
+  body:
+  - !variable prod/password
+  - !permit
+    role: !group /prod:group:ops
+    resource: !variable prod/password
+    privileges: [read]
+  - !grant
+    role: !layer staging-hosts
+    member: !host prod:host:apps/staging-app
+`, string(rewritten))
+
+	rewritten, err = RewritePolicy([]byte(policy), nil, "staging", "staging")
+	assert.NoError(t, err)
+	assert.Equal(t, policy, string(rewritten))
+
+	t.Run("only rewrites IDs", func(t *testing.T) {
+		policy := `# prod policy, see the production runbook
+- !policy
+  id: prod
+  annotations:
+    description: prod database of production
+    prod: true
+  body:
+  - !variable "prod/password"
+  - !host 'prod-app'
+  - !group
+    id: prod/ops
+    owner: !user /prod
+  - !grant
+    role: !group prod/ops
+    members: [!host prod/app, !host production/app]
+`
+		rewritten, err := RewritePolicy([]byte(policy), []RewriteRule{{From: "prod", To: "staging"}}, "dev", "dev")
+		assert.NoError(t, err)
+		assert.Equal(t, `# prod policy, see the production runbook
+- !policy
+  id: staging
+  annotations:
+    description: prod database of production
+    prod: true
+  body:
+  - !variable "staging/password"
+  - !host 'prod-app'
+  - !group
+    id: staging/ops
+    owner: !user /staging
+  - !grant
+    role: !group staging/ops
+    members: [!host staging/app, !host production/app]
+`, string(rewritten))
+	})
+
+	t.Run("invalid policy", func(t *testing.T) {
+		_, err := RewritePolicy([]byte("- !variable [x"), nil, "dev", "dev")
+		assert.Error(t, err)
+	})
+}
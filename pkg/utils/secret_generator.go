@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	// SecretGeneratorPassword generates a password from character classes
+	SecretGeneratorPassword = "password"
+	// SecretGeneratorHex generates random bytes encoded in hexadecimal
+	SecretGeneratorHex = "hex"
+	// SecretGeneratorBase64 generates random bytes encoded in base64
+	SecretGeneratorBase64 = "base64"
+	// SecretGeneratorUUID generates a random (version 4) UUID
+	SecretGeneratorUUID = "uuid"
+	// SecretGeneratorRSA generates a PEM-encoded PKCS #8 RSA private key
+	SecretGeneratorRSA = "rsa"
+	// SecretGeneratorEd25519 generates a PEM-encoded PKCS #8 Ed25519 private key
+	SecretGeneratorEd25519 = "ed25519"
+)
+
+// SecretGenerators lists the generators supported by GenerateSecret
+var SecretGenerators = []string{
+	SecretGeneratorPassword,
+	SecretGeneratorHex,
+	SecretGeneratorBase64,
+	SecretGeneratorUUID,
+	SecretGeneratorRSA,
+	SecretGeneratorEd25519,
+}
+
+// PasswordCharacterClasses lists the character classes passwords can be built from. Symbols
+// exclude quotes, backslashes and spaces so that passwords can be used in shells and
+// connection strings without escaping.
+var PasswordCharacterClasses = []string{"lower", "upper", "digit", "symbol"}
+
+var passwordCharacters = map[string]string{
+	"lower":  "abcdefghijklmnopqrstuvwxyz",
+	"upper":  "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"digit":  "0123456789",
+	"symbol": "!#$%&()*+,-./:;<=>?@[]^_{|}~",
+}
+
+// defaultSecretLengths is the length used when none is given: characters for passwords, random
+// bytes for hex and base64, and bits for RSA keys
+var defaultSecretLengths = map[string]int{
+	SecretGeneratorPassword: 32,
+	SecretGeneratorHex:      32,
+	SecretGeneratorBase64:   32,
+	SecretGeneratorRSA:      4096,
+}
+
+// SecretGeneratorOptions configures GenerateSecret
+type SecretGeneratorOptions struct {
+	// Length of the secret, or 0 for the generator's default
+	Length int
+	// Classes are the character classes of passwords, all of them by default
+	Classes []string
+}
+
+// GenerateSecret generates a new random value with the given generator, using crypto/rand
+func GenerateSecret(generator string, options SecretGeneratorOptions) ([]byte, error) {
+	if !isSecretGenerator(generator) {
+		return nil, fmt.Errorf("generator must be one of %s", strings.Join(SecretGenerators, ", "))
+	}
+
+	defaultLength, hasLength := defaultSecretLengths[generator]
+	length := options.Length
+	switch {
+	case length < 0:
+		return nil, fmt.Errorf("length must be greater than 0")
+	case length > 0 && !hasLength:
+		return nil, fmt.Errorf("the %s generator does not support a length", generator)
+	case length == 0:
+		length = defaultLength
+	}
+
+	if len(options.Classes) > 0 && generator != SecretGeneratorPassword {
+		return nil, fmt.Errorf("the %s generator does not support character classes", generator)
+	}
+
+	switch generator {
+	case SecretGeneratorPassword:
+		return generatePassword(length, options.Classes)
+	case SecretGeneratorHex:
+		data, err := randomBytes(length)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(hex.EncodeToString(data)), nil
+	case SecretGeneratorBase64:
+		data, err := randomBytes(length)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(base64.StdEncoding.EncodeToString(data)), nil
+	case SecretGeneratorUUID:
+		data, err := randomBytes(16)
+		if err != nil {
+			return nil, err
+		}
+		data[6] = (data[6] & 0x0f) | 0x40
+		data[8] = (data[8] & 0x3f) | 0x80
+		return []byte(fmt.Sprintf("%x-%x-%x-%x-%x", data[0:4], data[4:6], data[6:8], data[8:10], data[10:16])), nil
+	case SecretGeneratorRSA:
+		if length < 2048 {
+			return nil, fmt.Errorf("RSA keys must be at least 2048 bits long")
+		}
+		key, err := rsa.GenerateKey(rand.Reader, length)
+		if err != nil {
+			return nil, err
+		}
+		return encodePrivateKey(key)
+	default:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return encodePrivateKey(key)
+	}
+}
+
+func isSecretGenerator(generator string) bool {
+	for _, known := range SecretGenerators {
+		if generator == known {
+			return true
+		}
+	}
+	return false
+}
+
+func randomBytes(length int) ([]byte, error) {
+	data := make([]byte, length)
+	_, err := rand.Read(data)
+	return data, err
+}
+
+func randomIndex(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
+
+// generatePassword picks one character of each class, so that passwords always satisfy
+// complexity rules, then fills and shuffles the rest from all the classes
+func generatePassword(length int, classes []string) ([]byte, error) {
+	if len(classes) == 0 {
+		classes = PasswordCharacterClasses
+	}
+
+	alphabet := ""
+	seen := map[string]bool{}
+	for _, class := range classes {
+		characters, ok := passwordCharacters[class]
+		if !ok {
+			return nil, fmt.Errorf("character class must be one of %s", strings.Join(PasswordCharacterClasses, ", "))
+		}
+		if !seen[class] {
+			seen[class] = true
+			alphabet += characters
+		}
+	}
+	if length < len(seen) {
+		return nil, fmt.Errorf("length must be at least %d to include every character class", len(seen))
+	}
+
+	password := make([]byte, 0, length)
+	for _, class := range PasswordCharacterClasses {
+		if !seen[class] {
+			continue
+		}
+		i, err := randomIndex(len(passwordCharacters[class]))
+		if err != nil {
+			return nil, err
+		}
+		password = append(password, passwordCharacters[class][i])
+	}
+	for len(password) < length {
+		i, err := randomIndex(len(alphabet))
+		if err != nil {
+			return nil, err
+		}
+		password = append(password, alphabet[i])
+	}
+
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomIndex(i + 1)
+		if err != nil {
+			return nil, err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+	return password, nil
+}
+
+func encodePrivateKey(key interface{}) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateSecretPassword(t *testing.T) {
+	password, err := GenerateSecret(SecretGeneratorPassword, SecretGeneratorOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, password, 32)
+	for _, class := range PasswordCharacterClasses {
+		assert.True(t, strings.ContainsAny(string(password), passwordCharacters[class]), "missing %s character in %s", class, password)
+	}
+
+	for i := 0; i < 20; i++ {
+		password, err = GenerateSecret(SecretGeneratorPassword, SecretGeneratorOptions{Length: 4, Classes: []string{"digit", "upper"}})
+		assert.NoError(t, err)
+		assert.Regexp(t, `^[0-9A-Z]{4}$`, string(password))
+		assert.Regexp(t, `[0-9]`, string(password))
+		assert.Regexp(t, `[A-Z]`, string(password))
+	}
+
+	_, err = GenerateSecret(SecretGeneratorPassword, SecretGeneratorOptions{Length: 2, Classes: PasswordCharacterClasses})
+	assert.EqualError(t, err, "length must be at least 4 to include every character class")
+
+	_, err = GenerateSecret(SecretGeneratorPassword, SecretGeneratorOptions{Classes: []string{"emoji"}})
+	assert.EqualError(t, err, "character class must be one of lower, upper, digit, symbol")
+}
+
+func TestGenerateSecretEncodedBytes(t *testing.T) {
+	value, err := GenerateSecret(SecretGeneratorHex, SecretGeneratorOptions{Length: 16})
+	assert.NoError(t, err)
+	data, err := hex.DecodeString(string(value))
+	assert.NoError(t, err)
+	assert.Len(t, data, 16)
+
+	value, err = GenerateSecret(SecretGeneratorBase64, SecretGeneratorOptions{})
+	assert.NoError(t, err)
+	data, err = base64.StdEncoding.DecodeString(string(value))
+	assert.NoError(t, err)
+	assert.Len(t, data, 32)
+
+	value, err = GenerateSecret(SecretGeneratorUUID, SecretGeneratorOptions{})
+	assert.NoError(t, err)
+	assert.True(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).Match(value), string(value))
+}
+
+func TestGenerateSecretKeys(t *testing.T) {
+	value, err := GenerateSecret(SecretGeneratorRSA, SecretGeneratorOptions{Length: 2048})
+	assert.NoError(t, err)
+	block, _ := pem.Decode(value)
+	assert.Equal(t, "PRIVATE KEY", block.Type)
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	assert.NoError(t, err)
+	assert.Equal(t, 2048, key.(*rsa.PrivateKey).N.BitLen())
+
+	value, err = GenerateSecret(SecretGeneratorEd25519, SecretGeneratorOptions{})
+	assert.NoError(t, err)
+	block, _ = pem.Decode(value)
+	key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	assert.NoError(t, err)
+	assert.IsType(t, ed25519.PrivateKey{}, key)
+
+	_, err = GenerateSecret(SecretGeneratorRSA, SecretGeneratorOptions{Length: 1024})
+	assert.EqualError(t, err, "RSA keys must be at least 2048 bits long")
+}
+
+func TestGenerateSecretInvalidOptions(t *testing.T) {
+	_, err := GenerateSecret("random", SecretGeneratorOptions{Length: 8})
+	assert.EqualError(t, err, "generator must be one of password, hex, base64, uuid, rsa, ed25519")
+
+	_, err = GenerateSecret(SecretGeneratorUUID, SecretGeneratorOptions{Length: 8})
+	assert.EqualError(t, err, "the uuid generator does not support a length")
+
+	_, err = GenerateSecret(SecretGeneratorHex, SecretGeneratorOptions{Classes: []string{"digit"}})
+	assert.EqualError(t, err, "the hex generator does not support character classes")
+
+	_, err = GenerateSecret(SecretGeneratorHex, SecretGeneratorOptions{Length: -1})
+	assert.EqualError(t, err, "length must be greater than 0")
+}
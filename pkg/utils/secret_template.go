@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// SecretTemplate is a Go text/template rendered with the values of Conjur variables
+type SecretTemplate struct {
+	Source      string
+	Destination string
+	Mode        os.FileMode
+}
+
+// ParseSecretTemplateSpec parses a "source:destination[:mode]" template specification. The mode
+// is octal and defaults to 0600.
+func ParseSecretTemplateSpec(spec string) (SecretTemplate, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return SecretTemplate{}, fmt.Errorf("template %q must be source:destination[:mode]", spec)
+	}
+
+	tmpl := SecretTemplate{Source: parts[0], Destination: parts[1], Mode: 0600}
+	if len(parts) == 3 {
+		mode, err := strconv.ParseUint(parts[2], 8, 32)
+		if err != nil || mode > 0777 {
+			return SecretTemplate{}, fmt.Errorf("template %q has an invalid mode %q", spec, parts[2])
+		}
+		tmpl.Mode = os.FileMode(mode)
+	}
+	return tmpl, nil
+}
+
+// RenderSecretTemplate renders a template in which {{ secret "id" }} is replaced by the value
+// returned by lookup for the variable id
+func RenderSecretTemplate(data []byte, name string, lookup func(id string) (string, error)) ([]byte, error) {
+	tmpl, err := template.New(name).Funcs(template.FuncMap{
+		"secret": lookup,
+	}).Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	out := &bytes.Buffer{}
+	if err := tmpl.Execute(out, nil); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// WriteFileAtomic writes data to a temporary file next to path and renames it over path, so that
+// readers never see a partially written file
+func WriteFileAtomic(path string, data []byte, mode os.FileMode) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Chmod(mode)
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSecretTemplateSpec(t *testing.T) {
+	tmpl, err := ParseSecretTemplateSpec("db.tmpl:/etc/app/db.conf")
+	assert.NoError(t, err)
+	assert.Equal(t, SecretTemplate{Source: "db.tmpl", Destination: "/etc/app/db.conf", Mode: 0600}, tmpl)
+
+	tmpl, err = ParseSecretTemplateSpec("db.tmpl:db.conf:644")
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), tmpl.Mode)
+
+	_, err = ParseSecretTemplateSpec("db.tmpl")
+	assert.EqualError(t, err, `template "db.tmpl" must be source:destination[:mode]`)
+
+	_, err = ParseSecretTemplateSpec("db.tmpl:db.conf:rw")
+	assert.EqualError(t, err, `template "db.tmpl:db.conf:rw" has an invalid mode "rw"`)
+}
+
+func TestRenderSecretTemplate(t *testing.T) {
+	lookup := func(id string) (string, error) {
+		if id == "prod/db/password" {
+			return "s3cr3t", nil
+		}
+		return "", errors.New("unknown variable " + id)
+	}
+
+	out, err := RenderSecretTemplate([]byte(`password={{ secret "prod/db/password" }}`), "db.tmpl", lookup)
+	assert.NoError(t, err)
+	assert.Equal(t, "password=s3cr3t", string(out))
+
+	_, err = RenderSecretTemplate([]byte(`{{ secret "prod/other" }}`), "db.tmpl", lookup)
+	assert.ErrorContains(t, err, "unknown variable prod/other")
+
+	_, err = RenderSecretTemplate([]byte(`{{ secret "prod/db/password" `), "db.tmpl", lookup)
+	assert.ErrorContains(t, err, "template: db.tmpl:1: unclosed action")
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db.conf")
+
+	assert.NoError(t, WriteFileAtomic(path, []byte("one"), 0600))
+	assert.NoError(t, WriteFileAtomic(path, []byte("two"), 0640))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "two", string(data))
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
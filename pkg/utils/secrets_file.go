@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// SecretsFormatJSON is a JSON object mapping variable IDs to values
+	SecretsFormatJSON = "json"
+	// SecretsFormatYAML is a YAML mapping of variable IDs to values
+	SecretsFormatYAML = "yaml"
+	// SecretsFormatEnv is a dotenv-style file of id=value lines
+	SecretsFormatEnv = "env"
+)
+
+// SecretsFormats lists the formats supported by ParseSecretsFile and FormatSecretsFile
+var SecretsFormats = []string{SecretsFormatJSON, SecretsFormatYAML, SecretsFormatEnv}
+
+// SecretsFileFormat returns format when it is set, or the format matching the extension of path
+func SecretsFileFormat(path string, format string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			format = SecretsFormatJSON
+		case ".yml", ".yaml":
+			format = SecretsFormatYAML
+		case ".env":
+			format = SecretsFormatEnv
+		default:
+			return "", fmt.Errorf("cannot guess the format of %s, use --format with one of %s", path, strings.Join(SecretsFormats, ", "))
+		}
+	}
+
+	for _, known := range SecretsFormats {
+		if format == known {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("format must be one of %s", strings.Join(SecretsFormats, ", "))
+}
+
+// ParseSecretsFile parses a file mapping variable IDs to values. Scalar values of JSON and YAML
+// files are converted to strings.
+func ParseSecretsFile(data []byte, format string) (map[string]string, error) {
+	switch format {
+	case SecretsFormatJSON, SecretsFormatYAML:
+		raw := map[string]interface{}{}
+		var err error
+		if format == SecretsFormatJSON {
+			decoder := json.NewDecoder(bytes.NewReader(data))
+			decoder.UseNumber()
+			err = decoder.Decode(&raw)
+		} else {
+			err = yaml.Unmarshal(data, &raw)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		secrets := make(map[string]string, len(raw))
+		for id, value := range raw {
+			switch v := value.(type) {
+			case string:
+				secrets[id] = v
+			case nil:
+				secrets[id] = ""
+			case map[string]interface{}, []interface{}:
+				return nil, fmt.Errorf("value of %s must be a string", id)
+			default:
+				secrets[id] = fmt.Sprintf("%v", v)
+			}
+		}
+		return secrets, nil
+	case SecretsFormatEnv:
+		return parseEnvSecrets(data)
+	}
+	return nil, fmt.Errorf("format must be one of %s", strings.Join(SecretsFormats, ", "))
+}
+
+func parseEnvSecrets(data []byte) (map[string]string, error) {
+	secrets := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		id, value, found := strings.Cut(line, "=")
+		id = strings.TrimSpace(id)
+		if !found || id == "" {
+			return nil, fmt.Errorf("line %d: must be id=value", lineNumber)
+		}
+
+		value = strings.TrimSpace(value)
+		switch {
+		case strings.HasPrefix(value, `"`):
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid quoted value", lineNumber)
+			}
+			value = unquoted
+		case len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'"):
+			value = value[1 : len(value)-1]
+		}
+		secrets[id] = value
+	}
+	return secrets, scanner.Err()
+}
+
+// FormatSecretsFile renders variable IDs and values, sorted by ID, in a format read by
+// ParseSecretsFile. Binary values are written as !!binary scalars in YAML and with \x escapes in
+// env files, while JSON strings cannot hold them, so they are rejected.
+func FormatSecretsFile(secrets map[string]string, format string) ([]byte, error) {
+	switch format {
+	case SecretsFormatJSON:
+		for id, value := range secrets {
+			if !utf8.ValidString(value) {
+				return nil, fmt.Errorf("value of %s is binary and cannot be written as JSON, use the yaml or env format", id)
+			}
+		}
+
+		data, err := json.MarshalIndent(secrets, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case SecretsFormatYAML:
+		return yaml.Marshal(secrets)
+	case SecretsFormatEnv:
+		ids := make([]string, 0, len(secrets))
+		for id := range secrets {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		out := &bytes.Buffer{}
+		for _, id := range ids {
+			fmt.Fprintf(out, "%s=%s\n", id, strconv.Quote(secrets[id]))
+		}
+		return out.Bytes(), nil
+	}
+	return nil, fmt.Errorf("format must be one of %s", strings.Join(SecretsFormats, ", "))
+}
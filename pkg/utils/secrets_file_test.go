@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretsFileFormat(t *testing.T) {
+	format, err := SecretsFileFormat("secrets.yml", "")
+	assert.NoError(t, err)
+	assert.Equal(t, SecretsFormatYAML, format)
+
+	format, err = SecretsFileFormat("secrets.JSON", "")
+	assert.NoError(t, err)
+	assert.Equal(t, SecretsFormatJSON, format)
+
+	format, err = SecretsFileFormat("secrets.txt", "env")
+	assert.NoError(t, err)
+	assert.Equal(t, SecretsFormatEnv, format)
+
+	_, err = SecretsFileFormat("-", "")
+	assert.EqualError(t, err, "cannot guess the format of -, use --format with one of json, yaml, env")
+
+	_, err = SecretsFileFormat("secrets.json", "xml")
+	assert.EqualError(t, err, "format must be one of json, yaml, env")
+}
+
+func TestParseSecretsFile(t *testing.T) {
+	testCases := []struct {
+		name        string
+		data        string
+		format      string
+		expected    map[string]string
+		expectedErr string
+	}{
+		{
+			name:     "json",
+			data:     `{"prod/db/password": "p@ss", "prod/db/port": 5432, "prod/empty": null}`,
+			format:   SecretsFormatJSON,
+			expected: map[string]string{"prod/db/password": "p@ss", "prod/db/port": "5432", "prod/empty": ""},
+		},
+		{
+			name:     "yaml",
+			data:     "prod/db/password: p@ss\nprod/db/enabled: true\nprod/cert: |\n  line 1\n  line 2\n",
+			format:   SecretsFormatYAML,
+			expected: map[string]string{"prod/db/password": "p@ss", "prod/db/enabled": "true", "prod/cert": "line 1\nline 2\n"},
+		},
+		{
+			name:        "nested value",
+			data:        "prod/db: {password: p@ss}",
+			format:      SecretsFormatYAML,
+			expectedErr: "value of prod/db must be a string",
+		},
+		{
+			name: "env",
+			data: `# comment
+
+prod/db/password="p@ss\nword"
+export prod/db/user=admin
+prod/db/single='it''s'
+prod/db/url = https://db?a=b
+`,
+			format: SecretsFormatEnv,
+			expected: map[string]string{
+				"prod/db/password": "p@ss\nword",
+				"prod/db/user":     "admin",
+				"prod/db/single":   "it''s",
+				"prod/db/url":      "https://db?a=b",
+			},
+		},
+		{
+			name:        "invalid env line",
+			data:        "prod/db/password\n",
+			format:      SecretsFormatEnv,
+			expectedErr: "line 1: must be id=value",
+		},
+		{
+			name:        "invalid json",
+			data:        `{"prod/db/password": }`,
+			format:      SecretsFormatJSON,
+			expectedErr: "invalid character",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			secrets, err := ParseSecretsFile([]byte(tc.data), tc.format)
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, secrets)
+		})
+	}
+}
+
+func TestFormatSecretsFile(t *testing.T) {
+	secrets := map[string]string{"prod/b": "multi\nline \"value\"", "prod/a": "simple"}
+
+	for _, format := range SecretsFormats {
+		t.Run(format+" round trip", func(t *testing.T) {
+			data, err := FormatSecretsFile(secrets, format)
+			assert.NoError(t, err)
+
+			parsed, err := ParseSecretsFile(data, format)
+			assert.NoError(t, err)
+			assert.Equal(t, secrets, parsed)
+		})
+	}
+
+	data, err := FormatSecretsFile(secrets, SecretsFormatEnv)
+	assert.NoError(t, err)
+	assert.Equal(t, "prod/a=\"simple\"\nprod/b=\"multi\\nline \\\"value\\\"\"\n", string(data))
+
+	t.Run("binary values", func(t *testing.T) {
+		binary := map[string]string{"prod/key": "\xff\x00\xfe"}
+
+		for _, format := range []string{SecretsFormatYAML, SecretsFormatEnv} {
+			data, err := FormatSecretsFile(binary, format)
+			assert.NoError(t, err)
+
+			parsed, err := ParseSecretsFile(data, format)
+			assert.NoError(t, err)
+			assert.Equal(t, binary, parsed)
+		}
+
+		_, err := FormatSecretsFile(binary, SecretsFormatJSON)
+		assert.EqualError(t, err, "value of prod/key is binary and cannot be written as JSON, use the yaml or env format")
+	})
+}
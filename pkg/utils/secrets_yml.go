@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SecretSource describes where the value of a secrets file entry comes from
+type SecretSource int
+
+const (
+	// SecretSourceLiteral is a value written verbatim in the secrets file
+	SecretSourceLiteral SecretSource = iota
+	// SecretSourceVariable is a value fetched from a Conjur variable
+	SecretSourceVariable
+)
+
+// SecretSpec is a single entry of a Summon-style secrets file
+type SecretSpec struct {
+	// Name is the environment variable the value is exposed as
+	Name string
+	// Source tells whether Value is a literal or a Conjur variable ID
+	Source SecretSource
+	// Value is either the literal value or the Conjur variable ID
+	Value string
+	// AsFile exposes the value through a temporary file whose path is
+	// set in the environment variable instead of the value itself
+	AsFile bool
+	// Line is the line of the entry in the secrets file
+	Line int
+}
+
+// IsVariable reports whether the entry must be fetched from Conjur
+func (s SecretSpec) IsVariable() bool {
+	return s.Source == SecretSourceVariable
+}
+
+// ParseSecretsYML parses a Summon-style secrets file. Supported tags are !var,
+// !var:file, !str and !file. Untagged scalars are treated as literal strings.
+//
+// When environment is not empty, the entries of the matching top-level section
+// are returned, merged on top of the entries of the optional "common" section.
+func ParseSecretsYML(data []byte, environment string) ([]SecretSpec, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return []SecretSpec{}, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: secrets file must be a mapping", root.Line)
+	}
+
+	if environment == "" {
+		return parseSecretsMapping(root)
+	}
+
+	specsByName := map[string]SecretSpec{}
+	found := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+		if key != "common" && key != environment {
+			continue
+		}
+		if key == environment {
+			found = true
+		}
+		if value.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: section %q must be a mapping", value.Line, key)
+		}
+		specs, err := parseSecretsMapping(value)
+		if err != nil {
+			return nil, err
+		}
+		for _, spec := range specs {
+			// The environment section takes precedence over the common one
+			if _, exists := specsByName[spec.Name]; exists && key == "common" {
+				continue
+			}
+			specsByName[spec.Name] = spec
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("environment %q not found in secrets file", environment)
+	}
+
+	specs := make([]SecretSpec, 0, len(specsByName))
+	for _, spec := range specsByName {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Line < specs[j].Line })
+
+	return specs, nil
+}
+
+func parseSecretsMapping(node *yaml.Node) ([]SecretSpec, error) {
+	specs := make([]SecretSpec, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		if value.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("line %d: value of %s must be a scalar", value.Line, key.Value)
+		}
+
+		spec := SecretSpec{
+			Name:  key.Value,
+			Value: value.Value,
+			Line:  key.Line,
+		}
+
+		switch value.Tag {
+		case "!var":
+			spec.Source = SecretSourceVariable
+		case "!var:file":
+			spec.Source = SecretSourceVariable
+			spec.AsFile = true
+		case "!file":
+			spec.AsFile = true
+		case "!str", "!!str", "!!int", "!!float", "!!bool", "!!null":
+			// Literal value
+		default:
+			return nil, fmt.Errorf("line %d: unsupported tag %s for %s", value.Line, value.Tag, key.Value)
+		}
+
+		if spec.IsVariable() && spec.Value == "" {
+			return nil, fmt.Errorf("line %d: missing variable ID for %s", value.Line, key.Value)
+		}
+
+		specs = append(specs, spec)
+	}
+
+	return specs, nil
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSecretsYML(t *testing.T) {
+	testCases := []struct {
+		name        string
+		yml         string
+		environment string
+		expected    []SecretSpec
+		expectedErr string
+	}{
+		{
+			name:     "empty",
+			yml:      "",
+			expected: []SecretSpec{},
+		},
+		{
+			name: "all tags",
+			yml: `DB_PASSWORD: !var prod/db/password
+SSL_CERT: !var:file prod/ssl/cert
+DB_USER: !str admin
+CONFIG: !file some content
+PORT: 8080`,
+			expected: []SecretSpec{
+				{Name: "DB_PASSWORD", Source: SecretSourceVariable, Value: "prod/db/password", Line: 1},
+				{Name: "SSL_CERT", Source: SecretSourceVariable, Value: "prod/ssl/cert", AsFile: true, Line: 2},
+				{Name: "DB_USER", Source: SecretSourceLiteral, Value: "admin", Line: 3},
+				{Name: "CONFIG", Source: SecretSourceLiteral, Value: "some content", AsFile: true, Line: 4},
+				{Name: "PORT", Source: SecretSourceLiteral, Value: "8080", Line: 5},
+			},
+		},
+		{
+			name: "environment merged with common",
+			yml: `common:
+  DB_USER: !str admin
+  DB_PASSWORD: !var common/db/password
+production:
+  DB_PASSWORD: !var prod/db/password
+staging:
+  DB_PASSWORD: !var staging/db/password`,
+			environment: "production",
+			expected: []SecretSpec{
+				{Name: "DB_USER", Source: SecretSourceLiteral, Value: "admin", Line: 2},
+				{Name: "DB_PASSWORD", Source: SecretSourceVariable, Value: "prod/db/password", Line: 5},
+			},
+		},
+		{
+			name:        "missing environment",
+			yml:         "staging:\n  DB_PASSWORD: !var staging/db/password",
+			environment: "production",
+			expectedErr: "environment \"production\" not found in secrets file",
+		},
+		{
+			name:        "unsupported tag",
+			yml:         "DB_PASSWORD: !secret prod/db/password",
+			expectedErr: "line 1: unsupported tag !secret for DB_PASSWORD",
+		},
+		{
+			name:        "missing variable ID",
+			yml:         "A: !str a\nDB_PASSWORD: !var",
+			expectedErr: "line 2: missing variable ID for DB_PASSWORD",
+		},
+		{
+			name:        "not a mapping",
+			yml:         "- !var prod/db/password",
+			expectedErr: "line 1: secrets file must be a mapping",
+		},
+		{
+			name:        "nested value without environment",
+			yml:         "production:\n  DB_PASSWORD: !var prod/db/password",
+			expectedErr: "line 2: value of production must be a scalar",
+		},
+	}
+
+	t.Parallel()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			specs, err := ParseSecretsYML([]byte(tc.yml), tc.environment)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, specs)
+		})
+	}
+}